@@ -4,53 +4,76 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-type Config struct {
-	AppEnv string
-	DBHost string
-	DBUser string
-	DBPass string
-	DBName string
-	DBPort string
-	// --- NEW FIELDS ---
-	LogLevel    string // e.g., "debug", "info", "warn"
-	ServiceName string // e.g., "auth-service"
-	HTTPPort    string // e.g., "3000"
-	InstanceID  string // The Container ID or Pod Name
+// UnknownInstance is the InstanceID used when neither INSTANCE_ID nor
+// HOSTNAME is set.
+const UnknownInstance = "unknown"
+
+// Base holds the settings every service needs.
+// Services embed it in their own Config struct next to the
+// sections they use (database, Kafka, ...):
+//
+//	type Config struct {
+//		config.Base `yaml:",inline"`
+//		DB database.Config `yaml:"db"`
+//	}
+//
+// and pre-fill ServiceName and HTTPPort before Load, so services started
+// side by side do not collide.
+type Base struct {
+	AppEnv      string `env:"APP_ENV" default:"development" yaml:"app_env"`
+	ServiceName string `env:"SERVICE_NAME" default:"unknown-service" yaml:"service_name"` // e.g., "auth-service"
+	InstanceID  string `env:"INSTANCE_ID,HOSTNAME" default:"unknown" yaml:"instance_id"`  // The Container ID or Pod Name
+	HTTPPort    string `env:"HTTP_PORT" required:"true" yaml:"http_port"`                 // pre-filled per service, e.g. "3000"
+
+	// Default to "info" if not set. Use "debug" in local .env
+	LogLevel string `env:"LOG_LEVEL" default:"info" yaml:"log_level"`
+	// Keep 1 of every N successful request logs (1 = log all)
+	LogSuccessSampleEvery uint32 `env:"LOG_SUCCESS_SAMPLE_EVERY" default:"1" yaml:"log_success_sample_every"`
+
+	// Max time to drain HTTP, consumers, producers and DB
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"15s" yaml:"shutdown_timeout"`
+
+	Tracing Tracing `yaml:"tracing"`
 }
 
-// Load reads configuration.
-// dbEnvKey: The specific env var name for this service's DB (e.g. "AUTH_DB_NAME")
-func Load(dbEnvKey string) *Config {
-	loadEnvFile()
+// IsProduction reports whether the service runs with APP_ENV=production.
+func (b Base) IsProduction() bool {
+	return b.AppEnv == "production"
+}
 
-	// Get Hostname (Container ID in Docker, Pod Name in K8s)
-	hostname, err := os.Hostname()
-	if err != nil {
-		hostname = "unknown"
-	}
+// Tracing configures pkg/tracing.
+type Tracing struct {
+	Exporter    string  `env:"OTEL_EXPORTER" default:"none" yaml:"exporter"` // "otlp", "stdout" or "none"
+	Endpoint    string  `env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"localhost:4318" yaml:"endpoint"`
+	SampleRatio float64 `env:"OTEL_SAMPLE_RATIO" default:"1.0" yaml:"sample_ratio"` // 0.0 - 1.0
+}
 
-	return &Config{
-		AppEnv: GetEnv("APP_ENV", "development"),
-		DBHost: GetEnv("DB_HOST", "localhost"),
-		DBUser: GetEnv("DB_USER", "postgres"),
-		DBPass: GetEnv("DB_PASS", "password"),
-		// Critical Change: Look for the specific key first, then fallback to generic
-		DBName: GetEnv(dbEnvKey, GetEnv("DB_NAME", "bitka_auth")),
-		DBPort: GetEnv("DB_PORT", "5432"),
-
-		// Logging
-		HTTPPort: GetEnv("HTTP_PORT", "3000"),
-		// Default to "info" if not set. Use "debug" in local .env
-		LogLevel: GetEnv("LOG_LEVEL", "info"),
-
-		// Default to "unknown" to alert if not set
-		ServiceName: GetEnv("SERVICE_NAME", "unknown-service"),
-		InstanceID:  GetEnv("INSTANCE_ID", hostname), // Fallback to env var if needed
-	}
+// Kafka holds broker connection and consumer settings.
+type Kafka struct {
+	Brokers []string `env:"KAFKA_BROKERS,KAFKA_BROKER" default:"kafka:9092" required:"true" yaml:"brokers"`
+	// "kafka" or "memory" (in-process broker, see pkg/kafka/transport)
+	Mode string `env:"KAFKA_MODE" default:"kafka" yaml:"mode"`
+
+	// Consumer group; services pre-fill their own name
+	GroupID              string        `env:"KAFKA_GROUP_ID" yaml:"group_id"`
+	ConsumerRetries      int           `env:"KAFKA_CONSUMER_RETRIES" default:"3" yaml:"consumer_retries"`
+	ConsumerRetryBackoff time.Duration `env:"KAFKA_CONSUMER_RETRY_BACKOFF" default:"200ms" yaml:"consumer_retry_backoff"`
+	// How long handled event IDs are remembered for deduplication
+	InboxRetention time.Duration `env:"KAFKA_INBOX_RETENTION" default:"720h" yaml:"inbox_retention"`
+
+	// Validate produced events against the AsyncAPI spec (ignored in production)
+	ValidateEvents bool   `env:"KAFKA_VALIDATE_EVENTS" default:"false" yaml:"validate_events"`
+	ContractSpec   string `env:"KAFKA_CONTRACT_SPEC" default:"docs/api/asyncapi/asyncapi.yaml" yaml:"contract_spec"`
+}
+
+// InMemory reports whether KAFKA_MODE selects the in-process broker.
+func (k Kafka) InMemory() bool {
+	return k.Mode == "memory"
 }
 
 func loadEnvFile() {
@@ -78,10 +101,3 @@ func loadEnvFile() {
 		currentDir = parent
 	}
 }
-
-func GetEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return fallback
-}
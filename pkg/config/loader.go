@@ -0,0 +1,251 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Struct tags understood by Load:
+//
+//	env:"DB_HOST"            environment variable(s), comma separated, first non-empty wins
+//	default:"localhost"      value used when no source sets the field
+//	required:"true"          must be set; in production the default does NOT count
+//	secret:"true"            redacted by Redact / logger.LogConfigSafe
+//	yaml:"host"              key in the optional YAML file
+//
+// Nested structs (and embedded ones) are walked recursively.
+const (
+	tagEnv      = "env"
+	tagDefault  = "default"
+	tagRequired = "required"
+	tagSecret   = "secret"
+)
+
+// fileSuffix marks an env var that holds a PATH to the value (Docker/K8s secrets),
+// e.g. DB_PASS_FILE=/run/secrets/db_pass.
+const fileSuffix = "_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Option customises Load.
+type Option func(*loader)
+
+// WithPrefix makes every key look for "<PREFIX>_<KEY>" before "<KEY>",
+// so one .env can hold AUTH_DB_NAME and ACCOUNT_DB_NAME side by side.
+func WithPrefix(prefix string) Option {
+	return func(l *loader) {
+		l.prefix = strings.TrimSuffix(strings.ToUpper(prefix), "_") + "_"
+	}
+}
+
+// WithFile reads a YAML file before applying env vars.
+// Without this option the path is taken from CONFIG_FILE (if set).
+func WithFile(path string) Option {
+	return func(l *loader) { l.file = path }
+}
+
+type loader struct {
+	prefix     string
+	file       string
+	production bool
+	missing    []string
+	errs       []error
+}
+
+// Load fills dst (a pointer to struct) from, in increasing precedence:
+// field defaults, the YAML file, env vars, and "<KEY>_FILE" secret files.
+//
+// Values already present in dst are kept unless a source overrides them,
+// which lets services pre-fill their own defaults (e.g. ServiceName).
+func Load(dst any, opts ...Option) error {
+	loadEnvFile()
+
+	l := &loader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.production = strings.EqualFold(l.lookupFirst([]string{"APP_ENV"}), "production")
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("config: Load expects a pointer to a struct")
+	}
+
+	if l.file == "" {
+		l.file = l.lookupFirst([]string{"CONFIG_FILE"})
+	}
+	if l.file != "" {
+		raw, err := os.ReadFile(l.file)
+		if err != nil {
+			return fmt.Errorf("config: read %s: %w", l.file, err)
+		}
+		if err := yaml.Unmarshal(raw, dst); err != nil {
+			return fmt.Errorf("config: parse %s: %w", l.file, err)
+		}
+	}
+
+	l.walk(v.Elem())
+
+	if len(l.missing) > 0 {
+		l.errs = append(l.errs, fmt.Errorf("config: missing required settings: %s", strings.Join(l.missing, ", ")))
+	}
+	return errors.Join(l.errs...)
+}
+
+// MustLoad is Load for main(): it exits the process on error.
+func MustLoad(dst any, opts ...Option) {
+	if err := Load(dst, opts...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func (l *loader) walk(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		keys := splitKeys(field.Tag.Get(tagEnv))
+		if fv.Kind() == reflect.Struct && fv.Type() != durationType && len(keys) == 0 {
+			l.walk(fv)
+			continue
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		raw, found, err := l.lookup(keys)
+		if err != nil {
+			l.errs = append(l.errs, err)
+			continue
+		}
+
+		required := field.Tag.Get(tagRequired) == "true"
+		def, hasDefault := field.Tag.Lookup(tagDefault)
+
+		switch {
+		case found:
+			// env / secret file wins
+		case !fv.IsZero():
+			continue // set by YAML or pre-filled by the service
+		case required && (l.production || !hasDefault):
+			// Dev defaults (e.g. a local DB password) must never leak into production
+			l.missing = append(l.missing, keys[0])
+			continue
+		case hasDefault:
+			raw = def
+		default:
+			continue
+		}
+
+		if err := setValue(fv, raw); err != nil {
+			l.errs = append(l.errs, fmt.Errorf("config: %s: %w", keys[0], err))
+		}
+	}
+}
+
+// lookup resolves keys in order, honouring the prefix and the _FILE suffix.
+func (l *loader) lookup(keys []string) (string, bool, error) {
+	for _, key := range l.candidates(keys) {
+		if path := os.Getenv(key + fileSuffix); path != "" {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return "", false, fmt.Errorf("config: read %s%s: %w", key, fileSuffix, err)
+			}
+			return strings.TrimRight(string(b), "\r\n"), true, nil
+		}
+		if v := os.Getenv(key); v != "" {
+			return v, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (l *loader) lookupFirst(keys []string) string {
+	v, _, _ := l.lookup(keys)
+	return v
+}
+
+func (l *loader) candidates(keys []string) []string {
+	if l.prefix == "" {
+		return keys
+	}
+	out := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		out = append(out, l.prefix+k)
+	}
+	return append(out, keys...)
+}
+
+func splitKeys(tag string) []string {
+	if tag == "" || tag == "-" {
+		return nil
+	}
+	var keys []string
+	for _, k := range strings.Split(tag, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func setValue(fv reflect.Value, raw string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", fv.Type())
+		}
+		parts := splitKeys(raw)
+		fv.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
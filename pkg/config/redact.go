@@ -0,0 +1,74 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces values of fields tagged secret:"true".
+const Redacted = "[REDACTED]"
+
+// Redact returns a loggable copy of cfg as nested maps, with every field
+// tagged secret:"true" replaced by "[REDACTED]". New secrets are covered
+// by tagging them; nobody has to remember to update a redaction list.
+func Redact(cfg any) map[string]any {
+	v := reflect.ValueOf(cfg)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	out := make(map[string]any)
+	redactInto(out, v)
+	return out
+}
+
+func redactInto(out map[string]any, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		// Flatten embedded structs (config.Base) like encoding/json does
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			redactInto(out, fv)
+			continue
+		}
+
+		name := fieldName(field)
+		switch {
+		case field.Tag.Get(tagSecret) == "true":
+			if fv.IsZero() {
+				out[name] = ""
+			} else {
+				out[name] = Redacted
+			}
+		case fv.Kind() == reflect.Struct && fv.Type() != durationType:
+			nested := make(map[string]any)
+			redactInto(nested, fv)
+			out[name] = nested
+		case fv.Type() == durationType:
+			out[name] = fv.Interface().(interface{ String() string }).String()
+		default:
+			out[name] = fv.Interface()
+		}
+	}
+}
+
+// fieldName prefers the yaml key so logs match the config file layout.
+func fieldName(f reflect.StructField) string {
+	if tag := f.Tag.Get("yaml"); tag != "" {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
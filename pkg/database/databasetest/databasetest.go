@@ -0,0 +1,72 @@
+// Package databasetest provides database fakes for tests. It is imported
+// from _test.go files only, so it never ends up in a binary.
+package databasetest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+
+	"bitka/pkg/database"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewTxManager returns a TxManager whose transactions begin and commit
+// without a database, for tests of use cases whose repositories are fakes.
+// ctx still carries the transaction, so database.InTx holds inside Do; any
+// query that reaches the connection fails.
+func NewTxManager() *database.TxManager {
+	registerFake.Do(func() { sql.Register(fakeDriverName, fakeDriver{}) })
+	sqlDB, err := sql.Open(fakeDriverName, "")
+	if err != nil {
+		panic(err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return database.NewTxManager(db)
+}
+
+const fakeDriverName = "bitka-fake"
+
+var (
+	registerFake sync.Once
+	errFakeQuery = errors.New("databasetest: the fake transaction manager runs no queries")
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errFakeQuery }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+// ExecContext accepts the SAVEPOINT statements of nested transactions.
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if !strings.HasPrefix(query, "SAVEPOINT ") && !strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT ") {
+		return nil, errFakeQuery
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, errFakeQuery
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
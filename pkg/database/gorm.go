@@ -1,33 +1,164 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
+// Config is loaded by pkg/config (see the struct tags).
+// Services prefix the keys, e.g. AUTH_DB_NAME takes precedence over DB_NAME.
 type Config struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `env:"DB_HOST" default:"localhost" yaml:"host"`
+	Port     string `env:"DB_PORT" default:"5432" yaml:"port"`
+	User     string `env:"DB_USER" default:"postgres" required:"true" yaml:"user"`
+	Password string `env:"DB_PASS" required:"true" secret:"true" yaml:"password"`
+	DBName   string `env:"DB_NAME" required:"true" yaml:"name"`
+	SSLMode  string `env:"DB_SSLMODE" default:"disable" yaml:"sslmode"`
+
+	// Apply pending migrations at boot. Safe with many replicas (advisory lock);
+	// disable to run "migrate up" as a separate deploy step instead.
+	MigrateOnStart bool `env:"DB_MIGRATE_ON_START" default:"true" yaml:"migrate_on_start"`
+
+	// Pool (applied to the primary and every replica)
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"25" yaml:"max_open_conns"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"10" yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m" yaml:"conn_max_idle_time"`
+
+	// Startup retry: Postgres often comes up after the service in docker compose
+	ConnectRetries int           `env:"DB_CONNECT_RETRIES" default:"10" yaml:"connect_retries"`
+	ConnectBackoff time.Duration `env:"DB_CONNECT_BACKOFF" default:"500ms" yaml:"connect_backoff"` // doubles per attempt, capped at 10s
+
+	// Query logging
+	LogLevel           string        `env:"DB_LOG_LEVEL" default:"warn" yaml:"log_level"` // "silent", "error", "warn", "info"
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD" default:"200ms" yaml:"slow_query_threshold"`
+
+	// Read replicas as "host:port" (same user/password/database as the primary).
+	// Only queries wrapped in database.ReadReplica are routed to them.
+	ReplicaHosts []string `env:"DB_REPLICA_HOSTS" yaml:"replica_hosts"`
 }
 
-func Connect(cfg Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
+// maxBackoff caps the delay between connection attempts.
+const maxBackoff = 10 * time.Second
+
+func (cfg Config) dsn(host, port string) string {
+	return fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		cfg.Host, cfg.User, cfg.Password, cfg.DBName, cfg.Port, cfg.SSLMode,
+		host, cfg.User, cfg.Password, cfg.DBName, port, cfg.SSLMode,
 	)
+}
+
+// DSN is the connection string of the primary, for clients that need a
+// raw pgx connection (e.g. LISTEN/NOTIFY).
+func (cfg Config) DSN() string {
+	return cfg.dsn(cfg.Host, cfg.Port)
+}
+
+// Connect opens the primary (retrying with exponential backoff until it
+// answers a ping), applies pool settings and registers read replicas.
+func Connect(cfg Config) (*gorm.DB, error) {
+	db, err := openWithRetry(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	return gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	if len(cfg.ReplicaHosts) > 0 {
+		if err := registerReplicas(db, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+func openWithRetry(cfg Config) (*gorm.DB, error) {
+	gormCfg := &gorm.Config{
+		Logger: NewGormLogger(ParseLogLevel(cfg.LogLevel), cfg.SlowQueryThreshold),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
-	})
+	}
+
+	backoff := cfg.ConnectBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= cfg.ConnectRetries; attempt++ {
+		if attempt > 0 {
+			log.Warn().
+				Err(lastErr).
+				Str("action", "db_connect").
+				Str("status", "retrying").
+				Str("host", cfg.Host).
+				Int("attempt", attempt).
+				Dur("backoff", backoff).
+				Msg("Database not ready, retrying")
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		db, err := gorm.Open(postgres.Open(cfg.dsn(cfg.Host, cfg.Port)), gormCfg)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if lastErr = ping(db); lastErr == nil {
+			return db, nil
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+
+	return nil, fmt.Errorf("database: connect to %s after %d attempts: %w", cfg.Host, cfg.ConnectRetries+1, lastErr)
+}
+
+func ping(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+func registerReplicas(db *gorm.DB, cfg Config) error {
+	replicas := make([]gorm.Dialector, 0, len(cfg.ReplicaHosts))
+	for _, hostPort := range cfg.ReplicaHosts {
+		host, port, found := strings.Cut(hostPort, ":")
+		if !found {
+			port = cfg.Port
+		}
+		replicas = append(replicas, postgres.Open(cfg.dsn(host, port)))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}, replicaResolver).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return db.Use(resolver)
 }
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	bitkalog "bitka/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger bridges GORM to zerolog. Entries go through the context
+// logger (logger.From), so they carry the request's trace_id.
+type GormLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger logs errors and slow queries at the given level;
+// at gormlogger.Info every query is logged at DEBUG. Queries are logged
+// with placeholders, never their arguments.
+func NewGormLogger(level gormlogger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	// Scan traces through gorm's recorder, which filters with this
+	// package-level hook instead of asking the logger
+	gormlogger.RecorderParamsFilter = dropParams
+	return &GormLogger{level: level, slowThreshold: slowThreshold}
+}
+
+// ParseLogLevel maps "silent", "error", "warn", "info" (default "warn").
+func ParseLogLevel(s string) gormlogger.LogLevel {
+	switch strings.ToLower(s) {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Info {
+		bitkalog.From(ctx).Info().Str("action", "db").Msgf(msg, args...)
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Warn {
+		bitkalog.From(ctx).Warn().Str("action", "db").Msgf(msg, args...)
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Error {
+		bitkalog.From(ctx).Error().Str("action", "db").Msgf(msg, args...)
+	}
+}
+
+// ParamsFilter drops the query arguments, so Trace logs the SQL with its
+// placeholders: arguments carry emails, phone numbers and hashes.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return dropParams(ctx, sql, params...)
+}
+
+func dropParams(_ context.Context, sql string, _ ...any) (string, []any) {
+	return sql, nil
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	var event *zerolog.Event
+	status := "success"
+
+	switch {
+	// "record not found" is a normal outcome (e.g. GetProfile), not an error
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		event = bitkalog.From(ctx).Error().Err(err)
+		status = "error"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		event = bitkalog.From(ctx).Warn().Dur("threshold", l.slowThreshold)
+		status = "slow"
+	case l.level >= gormlogger.Info:
+		event = bitkalog.From(ctx).Debug()
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	event.
+		Str("action", "db_query").
+		Str("status", status).
+		Str("sql", sql).
+		Int64("rows", rows).
+		Dur("latency", elapsed).
+		Msg("Database query")
+}
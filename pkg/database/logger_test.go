@@ -0,0 +1,66 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	bitkalog "bitka/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestGormLoggerOmitsArguments(t *testing.T) {
+	var buf bytes.Buffer
+	l := zerolog.New(&buf).Level(zerolog.DebugLevel)
+	ctx := bitkalog.WithContext(context.Background(), &l)
+
+	db, err := gorm.Open(openRecording(t, "primary"), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               NewGormLogger(gormlogger.Info, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query func(*gorm.DB) error
+	}{
+		{
+			name: "where clause",
+			query: func(db *gorm.DB) error {
+				var rows []map[string]any
+				return db.Table("users").Where("email = ? AND password_hash = ?", "a@x.io", "secret-hash").Find(&rows).Error
+			},
+		},
+		{
+			name: "raw query",
+			query: func(db *gorm.DB) error {
+				var rows []map[string]any
+				return db.Raw("SELECT id FROM phone_verifications WHERE phone = ? AND code_hash = ?", "+48123456789", "secret-hash").Scan(&rows).Error
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			if err := tt.query(db.WithContext(ctx)); err != nil {
+				t.Fatal(err)
+			}
+			logged := buf.String()
+			if !strings.Contains(logged, "$1") {
+				t.Fatalf("query not logged with placeholders: %s", logged)
+			}
+			for _, value := range []string{"a@x.io", "+48123456789", "secret-hash"} {
+				if strings.Contains(logged, value) {
+					t.Fatalf("argument %q logged: %s", value, logged)
+				}
+			}
+		})
+	}
+}
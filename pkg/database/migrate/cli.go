@@ -0,0 +1,132 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: migrate <command> [args]
+
+Commands:
+  up                 apply all pending migrations
+  down [N]           roll back the last N migrations (default 1)
+  status             list migrations and whether they are applied
+  create <name>      write empty <version>_<name>.up/down.sql files into -dir
+
+Flags:
+`
+
+// Run implements the migrate CLI shared by every service's cmd/migrate.
+// newMigrator is only called for commands that need the database,
+// so "create" works without a running Postgres.
+func Run(ctx context.Context, args []string, defaultDir string, newMigrator func() (*Migrator, error)) error {
+	fset := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fset.String("dir", defaultDir, "migrations directory (for create)")
+	fset.Usage = func() {
+		fmt.Fprint(fset.Output(), usage)
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		fset.Usage()
+		return errors.New("migrate: missing command")
+	}
+
+	cmd, rest := fset.Arg(0), fset.Args()[1:]
+
+	if cmd == "create" {
+		if len(rest) != 1 {
+			return errors.New("migrate: create needs exactly one name")
+		}
+		up, down, err := Create(*dir, rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\nCreated %s\n", up, down)
+		return nil
+	}
+
+	m, err := newMigrator()
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil {
+				return fmt.Errorf("migrate: invalid step count %q", rest[0])
+			}
+		}
+		return m.Down(ctx, steps)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(os.Stdout, statuses)
+		return nil
+	default:
+		fset.Usage()
+		return fmt.Errorf("migrate: unknown command %q", cmd)
+	}
+}
+
+func printStatus(w io.Writer, statuses []Status) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, at := "pending", ""
+		if s.Applied {
+			state, at = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+	}
+	tw.Flush()
+}
+
+var nameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes the next numbered up/down pair into dir.
+func Create(dir, name string) (string, string, error) {
+	name = nameRe.ReplaceAllString(strings.ToLower(name), "_")
+	if name == "" || name == "_" {
+		return "", "", errors.New("migrate: empty migration name")
+	}
+
+	existing, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	next := int64(1)
+	if n := len(existing); n > 0 {
+		next = existing[n-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%04d_%s", next, name)
+	up := filepath.Join(dir, base+".up.sql")
+	down := filepath.Join(dir, base+".down.sql")
+
+	if err := os.WriteFile(up, []byte("-- "+base+" (up)\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- "+base+" (down)\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
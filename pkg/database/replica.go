@@ -0,0 +1,21 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// replicaResolver names the dbresolver configuration holding the replicas.
+// It is registered by name rather than as the global resolver, so queries
+// that do not ask for it keep using the primary connection pool.
+const replicaResolver = "bitka:replicas"
+
+// ReadReplica marks a query as safe to serve from a read replica.
+// Use it only where slightly stale data is acceptable (profile reads,
+// market history); everything else keeps reading from the primary so
+// users always see their own writes. Without replicas it is a no-op.
+//
+//	database.ReadReplica(r.db.WithContext(ctx)).First(&p, "user_id = ?", id)
+func ReadReplica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(replicaResolver), dbresolver.Read)
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+// recordingDriver answers every query with an empty result and remembers
+// which connection (primary or replica) served it.
+type recordingDriver struct {
+	mu   sync.Mutex
+	hits []string
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	return &recordingConn{name: name, d: d}, nil
+}
+
+func (d *recordingDriver) last() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.hits) == 0 {
+		return ""
+	}
+	return d.hits[len(d.hits)-1]
+}
+
+type recordingConn struct {
+	name string
+	d    *recordingDriver
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return nil, errors.New("tx not supported") }
+
+func (c *recordingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	c.d.hits = append(c.d.hits, c.name)
+	c.d.mu.Unlock()
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"id"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+var registerOnce sync.Once
+var recorder = &recordingDriver{}
+
+func openRecording(t *testing.T, name string) gorm.Dialector {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("recording", recorder) })
+	sqlDB, err := sql.Open("recording", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return postgres.New(postgres.Config{Conn: sqlDB})
+}
+
+func TestReadReplicaRouting(t *testing.T) {
+	db, err := gorm.Open(openRecording(t, "primary"), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{openRecording(t, "replica")},
+	}, replicaResolver)
+	if err := db.Use(resolver); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []map[string]any
+	tests := []struct {
+		name  string
+		query func(*gorm.DB) error
+		want  string
+	}{
+		{
+			name:  "unmarked read stays on primary",
+			query: func(db *gorm.DB) error { return db.Table("profiles").Find(&rows).Error },
+			want:  "primary",
+		},
+		{
+			name:  "unmarked raw select stays on primary",
+			query: func(db *gorm.DB) error { return db.Raw("SELECT id FROM profiles").Scan(&rows).Error },
+			want:  "primary",
+		},
+		{
+			name:  "marked read goes to replica",
+			query: func(db *gorm.DB) error { return ReadReplica(db).Table("profiles").Find(&rows).Error },
+			want:  "replica",
+		},
+		{
+			name: "marked locking read stays on primary",
+			query: func(db *gorm.DB) error {
+				return ReadReplica(db).Table("profiles").
+					Clauses(clause.Locking{Strength: "UPDATE"}).Find(&rows).Error
+			},
+			want: "primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query(db.WithContext(context.Background())); err != nil {
+				t.Fatalf("query: %v", err)
+			}
+			if got := recorder.last(); got != tt.want {
+				t.Errorf("served by %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// txKey is an unexported type to prevent collisions in context
+type txKey struct{}
+
+// Conn returns the transaction bound to ctx by TxManager.Do, or db itself.
+// Repositories call it instead of db.WithContext(ctx) so they join the
+// caller's unit of work automatically:
+//
+//	func (r *repo) CreateUser(ctx context.Context, u *User) error {
+//		return database.Conn(ctx, r.db).Create(u).Error
+//	}
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTx reports whether ctx carries a transaction.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// Postgres SQLSTATEs that mean "the transaction lost a race, run it again".
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// TxManager runs use-case code as one atomic unit of work.
+type TxManager struct {
+	db         *gorm.DB
+	maxRetries int
+	backoff    time.Duration
+}
+
+// TxManagerOption customises a TxManager.
+type TxManagerOption func(*TxManager)
+
+// WithMaxRetries sets how often a serialisation failure / deadlock is retried (default 3).
+func WithMaxRetries(n int) TxManagerOption {
+	return func(m *TxManager) { m.maxRetries = n }
+}
+
+// NewTxManager creates a TxManager on the primary database.
+func NewTxManager(db *gorm.DB, opts ...TxManagerOption) *TxManager {
+	m := &TxManager{db: db, maxRetries: 3, backoff: 20 * time.Millisecond}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Do runs fn in a transaction carried by the ctx passed to fn.
+//
+//   - fn returns nil: commit. fn returns an error (or panics): roll back.
+//   - Called inside another Do: a SAVEPOINT is used, so the inner unit can
+//     fail and roll back alone while the outer one carries on.
+//   - Serialisation failures and deadlocks restart the OUTERMOST unit only
+//     (a savepoint cannot be retried once Postgres aborted the transaction),
+//     so fn must be safe to run more than once.
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.DoWithOptions(ctx, nil, fn)
+}
+
+// DoWithOptions is Do with explicit options (e.g. sql.LevelSerializable)
+// for the outermost transaction. Options are ignored for savepoints.
+func (m *TxManager) DoWithOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if outer, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		// gorm turns a nested Transaction into SAVEPOINT / ROLLBACK TO
+		return outer.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	}
+
+	backoff := m.backoff
+	for attempt := 0; ; attempt++ {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		}, opts)
+
+		if err == nil || !isRetryable(err) || attempt >= m.maxRetries {
+			return err
+		}
+
+		log.Warn().
+			Err(err).
+			Str("action", "db_tx").
+			Str("status", "retrying").
+			Int("attempt", attempt+1).
+			Msg("Transaction conflict, retrying")
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
@@ -0,0 +1,134 @@
+// Package encryption seals sensitive column values at rest with
+// AES-256-GCM. Keys are named so they can be rotated: the first key of
+// the ring encrypts, every key decrypts, and each ciphertext records the
+// key that sealed it.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNoKeys     = errors.New("encryption: no keys configured")
+	ErrUnknownKey = errors.New("encryption: ciphertext sealed with an unknown key")
+	ErrMalformed  = errors.New("encryption: malformed ciphertext")
+)
+
+// ephemeralKeyID names the random key Load makes up outside production.
+const ephemeralKeyID = "ephemeral"
+
+// keySize is the AES-256 key length in bytes.
+const keySize = 32
+
+// Config is loaded by pkg/config. Each key is "<id>:<base64 of 32 bytes>",
+// e.g. generated with `openssl rand -base64 32`. To rotate, put the new
+// key first and keep the old ones until the data is re-encrypted.
+type Config struct {
+	Keys []string `env:"ENCRYPTION_KEYS" secret:"true" yaml:"keys"`
+}
+
+// Keyring encrypts with its primary key and decrypts with any of its keys.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+func New(cfg Config) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	k := &Keyring{aeads: make(map[string]cipher.AEAD, len(cfg.Keys))}
+	for i, entry := range cfg.Keys {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("encryption: key %d is not <id>:<base64>", i+1)
+		}
+		if _, dup := k.aeads[id]; dup {
+			return nil, fmt.Errorf("encryption: duplicate key id %q", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("encryption: key %q must be %d base64-encoded bytes", id, keySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		k.aeads[id] = aead
+		if i == 0 {
+			k.primary = id
+		}
+	}
+	return k, nil
+}
+
+// Load is New with the deployment policy: production needs configured
+// keys; elsewhere an empty ring gets a random key for this process, so
+// values sealed before a restart no longer decrypt.
+func Load(cfg Config, production bool) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		if production {
+			return nil, ErrNoKeys
+		}
+		key := make([]byte, keySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		log.Warn().
+			Str("action", "encryption_keys").
+			Msg("No encryption keys configured; using a random key for this process")
+		cfg.Keys = []string{ephemeralKeyID + ":" + base64.StdEncoding.EncodeToString(key)}
+	}
+	return New(cfg)
+}
+
+// Encrypt seals plaintext as "<key id>:<base64 of nonce and ciphertext>".
+// The same aad (e.g. the column name) must be given to Decrypt, so a value
+// copied into another column does not decrypt.
+func (k *Keyring) Encrypt(plaintext, aad []byte) (string, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, aad)
+	return k.primary + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any key of the ring.
+func (k *Keyring) Decrypt(ciphertext string, aad []byte) ([]byte, error) {
+	id, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return nil, ErrMalformed
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: key %q: %w", id, err)
+	}
+	return plaintext, nil
+}
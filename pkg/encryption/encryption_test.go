@@ -0,0 +1,202 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newKey(t *testing.T, id string) string {
+	t.Helper()
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func TestNew(t *testing.T) {
+	valid := newKey(t, "k1")
+	short := "k2:" + base64.StdEncoding.EncodeToString(make([]byte, 16))
+
+	tests := []struct {
+		name    string
+		keys    []string
+		primary string
+		wantErr error
+		errText string
+	}{
+		{name: "single key", keys: []string{valid}, primary: "k1"},
+		{name: "first key is primary", keys: []string{newKey(t, "new"), valid}, primary: "new"},
+		{name: "surrounding spaces", keys: []string{"  " + valid + " "}, primary: "k1"},
+		{name: "no keys", wantErr: ErrNoKeys},
+		{name: "missing separator", keys: []string{"k1"}, errText: "not <id>:<base64>"},
+		{name: "empty id", keys: []string{":" + strings.SplitN(valid, ":", 2)[1]}, errText: "not <id>:<base64>"},
+		{name: "duplicate id", keys: []string{valid, newKey(t, "k1")}, errText: "duplicate key id"},
+		{name: "not base64", keys: []string{"k1:not base64!"}, errText: "base64-encoded bytes"},
+		{name: "wrong length", keys: []string{short}, errText: "base64-encoded bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := New(Config{Keys: tt.keys})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.errText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.errText)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case k.primary != tt.primary:
+				t.Fatalf("primary = %q, want %q", k.primary, tt.primary)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	prod := newKey(t, "prod1")
+
+	tests := []struct {
+		name       string
+		keys       []string
+		production bool
+		primary    string
+		wantErr    error
+	}{
+		{name: "development without keys makes one up", primary: ephemeralKeyID},
+		{name: "development with keys", keys: []string{prod}, primary: "prod1"},
+		{name: "production without keys", production: true, wantErr: ErrNoKeys},
+		{name: "production with keys", keys: []string{prod}, production: true, primary: "prod1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := Load(Config{Keys: tt.keys}, tt.production)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if k.primary != tt.primary {
+				t.Fatalf("primary = %q, want %q", k.primary, tt.primary)
+			}
+		})
+	}
+
+	// Each process makes up its own key, so nothing is sealed with a key
+	// anyone else knows
+	a, _ := Load(Config{}, false)
+	b, _ := Load(Config{}, false)
+	sealed, err := a.Encrypt([]byte("+48123456789"), []byte("phone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Decrypt(sealed, []byte("phone")); err == nil {
+		t.Fatal("two processes share the made-up key")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, newKeyEntry := newKey(t, "old"), newKey(t, "new")
+	aad := []byte("phone")
+
+	before, err := New(Config{Keys: []string{oldKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedOld, err := before.Encrypt([]byte("+48123456789"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := New(Config{Keys: []string{newKeyEntry, oldKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealedNew, err := rotated.Encrypt([]byte("+48987654321"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealedNew, "new:") {
+		t.Fatalf("rotated ring sealed with %q, want the new key", sealedNew)
+	}
+
+	retired, err := New(Config{Keys: []string{newKeyEntry}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ring       *Keyring
+		ciphertext string
+		want       string
+		wantErr    error
+	}{
+		{name: "rotated ring opens old values", ring: rotated, ciphertext: sealedOld, want: "+48123456789"},
+		{name: "rotated ring opens new values", ring: rotated, ciphertext: sealedNew, want: "+48987654321"},
+		{name: "old ring cannot open new values", ring: before, ciphertext: sealedNew, wantErr: ErrUnknownKey},
+		{name: "retired key no longer opens", ring: retired, ciphertext: sealedOld, wantErr: ErrUnknownKey},
+		{name: "no key id", ring: rotated, ciphertext: "garbage", wantErr: ErrMalformed},
+		{name: "truncated", ring: rotated, ciphertext: "new:AAAA", wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ring.Decrypt(tt.ciphertext, aad)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsOtherColumn(t *testing.T) {
+	k, err := New(Config{Keys: []string{newKey(t, "k1")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := k.Encrypt([]byte("secret"), []byte("phone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		aad     string
+		wantErr bool
+	}{
+		{name: "same column", aad: "phone"},
+		{name: "other column", aad: "email", wantErr: true},
+		{name: "no column", aad: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := k.Decrypt(sealed, []byte(tt.aad))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
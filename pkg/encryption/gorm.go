@@ -0,0 +1,69 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer that encrypts a field:
+//
+//	Phone string `gorm:"serializer:encrypted"`
+//
+// The value is stored as the encrypted JSON of the field, bound to its
+// column name; the zero value is stored as an empty string, unencrypted.
+// GORM applies serializers to struct writes and reads only: map updates
+// bypass them and would store plaintext.
+const SerializerName = "encrypted"
+
+// RegisterSerializer makes k the keyring of every encrypted field. It
+// must run before the first query on a model that uses the serializer.
+func RegisterSerializer(k *Keyring) {
+	schema.RegisterSerializer(SerializerName, serializer{keys: k})
+}
+
+type serializer struct {
+	keys *Keyring
+}
+
+func (s serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	var ciphertext string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		ciphertext = v
+	case []byte:
+		ciphertext = string(v)
+	default:
+		return fmt.Errorf("encryption: %s: unsupported column type %T", field.DBName, dbValue)
+	}
+
+	if ciphertext != "" {
+		plaintext, err := s.keys.Decrypt(ciphertext, []byte(field.DBName))
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.DBName, err)
+		}
+		if err := json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+			return fmt.Errorf("encryption: %s: %w", field.DBName, err)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+func (s serializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	if fieldValue == nil || reflect.ValueOf(fieldValue).IsZero() {
+		return "", nil
+	}
+	plaintext, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %s: %w", field.DBName, err)
+	}
+	return s.keys.Encrypt(plaintext, []byte(field.DBName))
+}
@@ -0,0 +1,21 @@
+package events
+
+// Ledger transaction types.
+const (
+	LedgerDeposit    = "deposit"
+	LedgerWithdrawal = "withdrawal"
+)
+
+// LedgerTransaction is emitted for every balance-changing deposit or withdrawal.
+type LedgerTransaction struct {
+	TxID           string `json:"tx_id"`
+	UserID         string `json:"user_id"`
+	Type           string `json:"type"`
+	Asset          string `json:"asset"`
+	Amount         string `json:"amount"`
+	BalanceAfter   string `json:"balance_after"`
+	BlockchainHash string `json:"blockchain_hash,omitempty"` // empty for internal transfers
+}
+
+func (LedgerTransaction) EventType() string  { return "LedgerTransaction" }
+func (LedgerTransaction) SchemaVersion() int { return 1 }
@@ -0,0 +1,25 @@
+package events
+
+// Entry pairs a topic with an event type published on it.
+type Entry struct {
+	Topic string
+	Event Event
+}
+
+// Catalogue lists every (topic, event) pair produced or consumed by the
+// services. A test in pkg/events/contract validates each one against the
+// AsyncAPI spec, so new events must be added here.
+func Catalogue() []Entry {
+	return []Entry{
+		{Topic: TopicUserRegistered, Event: UserRegistered{}},
+		{Topic: TopicUserLogin, Event: UserLogin{}},
+		{Topic: TopicKycUpdated, Event: KycUpdated{}},
+		{Topic: TopicPreferences, Event: PreferencesChanged{}},
+		{Topic: TopicUserErasureRequested, Event: UserErasureRequested{}},
+		{Topic: TopicUserErased, Event: UserErased{}},
+		{Topic: TopicTradeExecuted, Event: TradeExecuted{}},
+		{Topic: TopicLedgerDeposited, Event: LedgerTransaction{}},
+		{Topic: TopicLedgerWithdrawn, Event: LedgerTransaction{}},
+		{Topic: TopicAuditLog, Event: AuditLog{}},
+	}
+}
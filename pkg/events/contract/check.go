@@ -0,0 +1,122 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"bitka/pkg/events"
+)
+
+// Result is the outcome for one catalogue entry.
+type Result struct {
+	Topic     string
+	EventType string
+	Err       error
+}
+
+// CheckCatalogue validates every entry against spec:
+//
+//  1. the topic is a channel and carries a message with the event's type;
+//  2. every JSON field of the Go type is declared in the schema;
+//  3. a sample built from the schema decodes into the Go type (no unknown fields);
+//  4. the Go value, wrapped in an envelope, validates against the schema.
+func CheckCatalogue(spec *Spec, entries []events.Entry) []Result {
+	results := make([]Result, 0, len(entries))
+	for _, e := range entries {
+		results = append(results, Result{
+			Topic:     e.Topic,
+			EventType: e.Event.EventType(),
+			Err:       checkEntry(spec, e),
+		})
+	}
+	return results
+}
+
+func checkEntry(spec *Spec, e events.Entry) error {
+	msg, err := spec.Message(e.Topic, e.Event.EventType())
+	if err != nil {
+		return err
+	}
+	data := msg.Payload.Properties["data"]
+	if data == nil {
+		return fmt.Errorf("%s: payload has no data property", msg.File)
+	}
+
+	var errs []error
+
+	// 2. Go fields must be documented
+	for _, field := range jsonFields(reflect.TypeOf(e.Event)) {
+		if _, ok := data.Properties[field]; !ok {
+			errs = append(errs, fmt.Errorf("Go field %q is not in the schema (%s)", field, msg.File))
+		}
+	}
+
+	// 3. Schema → Go
+	sample, err := json.Marshal(Sample(data))
+	if err != nil {
+		return err
+	}
+	ptr := reflect.New(reflect.TypeOf(e.Event))
+	dec := json.NewDecoder(bytes.NewReader(sample))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(ptr.Interface()); err != nil {
+		errs = append(errs, fmt.Errorf("schema sample does not decode into %T: %w", e.Event, err))
+		return errors.Join(errs...)
+	}
+
+	// 4. Go → schema
+	raw, _, err := events.Encode(ptr.Elem().Interface().(events.Event))
+	if err != nil {
+		return err
+	}
+	if err := Validate(msg.Payload, raw); err != nil {
+		errs = append(errs, fmt.Errorf("encoded event violates the schema: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// jsonFields lists the JSON names of t's exported fields.
+func jsonFields(t reflect.Type) []string {
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Validator returns a function validating produced records against spec,
+// for kafka.ProducerConfig.Validate. Topics or types missing from the
+// spec are errors too: an undocumented event is a contract violation.
+func Validator(spec *Spec) func(topic string, value []byte) error {
+	return func(topic string, value []byte) error {
+		env, err := events.Unwrap(value)
+		if err != nil {
+			return err
+		}
+		msg, err := spec.Message(topic, env.Type)
+		if err != nil {
+			return err
+		}
+		if err := Validate(msg.Payload, value); err != nil {
+			return fmt.Errorf("%s on %s: %w", env.Type, topic, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+package contract_test
+
+import (
+	"testing"
+
+	"bitka/pkg/events"
+	"bitka/pkg/events/contract"
+)
+
+// specPath is the root AsyncAPI document, relative to this package.
+const specPath = "../../../docs/api/asyncapi/asyncapi.yaml"
+
+// TestCatalogueMatchesSpec checks every registered event against the
+// AsyncAPI contract, so a new or changed event fails CI until it is documented.
+func TestCatalogueMatchesSpec(t *testing.T) {
+	spec, err := contract.Load(specPath)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	for _, w := range spec.Warnings {
+		t.Logf("spec warning: %s", w)
+	}
+
+	results := contract.CheckCatalogue(spec, events.Catalogue())
+	if len(results) != len(events.Catalogue()) {
+		t.Fatalf("checked %d events, catalogue has %d", len(results), len(events.Catalogue()))
+	}
+	for _, r := range results {
+		t.Run(r.EventType, func(t *testing.T) {
+			if r.Err != nil {
+				t.Errorf("%s (%s): %v", r.EventType, r.Topic, r.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package contract checks events against the AsyncAPI documents in
+// docs/api/asyncapi. It understands the subset of JSON Schema those
+// documents use: type, properties, required, enum, const, items, format.
+package contract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is a JSON Schema node.
+type Schema struct {
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Required   []string           `yaml:"required"`
+	Enum       []any              `yaml:"enum"`
+	Const      any                `yaml:"const"`
+	Items      *Schema            `yaml:"items"`
+	Example    any                `yaml:"example"`
+}
+
+// Message is an AsyncAPI message definition.
+type Message struct {
+	Name    string  `yaml:"name"`
+	Title   string  `yaml:"title"`
+	Payload *Schema `yaml:"payload"`
+	File    string  `yaml:"-"` // where it was loaded from, for error messages
+}
+
+// EventType is the envelope "type" const, or "" for messages without one.
+func (m *Message) EventType() string {
+	if m.Payload == nil || m.Payload.Properties["type"] == nil {
+		return ""
+	}
+	s, _ := m.Payload.Properties["type"].Const.(string)
+	return s
+}
+
+// Spec is a loaded AsyncAPI document: channel (topic) → messages.
+type Spec struct {
+	Channels map[string][]*Message
+
+	// Warnings lists references that could not be resolved. They do not
+	// fail the check because they may describe services not built yet.
+	Warnings []string
+}
+
+type operation struct {
+	Message yaml.Node `yaml:"message"`
+}
+
+type channel struct {
+	Publish   *operation `yaml:"publish"`
+	Subscribe *operation `yaml:"subscribe"`
+}
+
+// Load reads the root AsyncAPI file and follows its relative $refs.
+func Load(path string) (*Spec, error) {
+	var root struct {
+		Channels map[string]yaml.Node `yaml:"channels"`
+	}
+	if err := readYAML(path, "", &root); err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{Channels: make(map[string][]*Message)}
+
+	for name, node := range root.Channels {
+		var ch channel
+		file, err := resolve(path, &node, &ch)
+		if err != nil {
+			spec.Warnings = append(spec.Warnings, fmt.Sprintf("channel %s: %v", name, err))
+			continue
+		}
+		for _, op := range []*operation{ch.Publish, ch.Subscribe} {
+			if op == nil || op.Message.Kind == 0 {
+				continue
+			}
+			var msg Message
+			msgFile, err := resolve(file, &op.Message, &msg)
+			if err != nil {
+				spec.Warnings = append(spec.Warnings, fmt.Sprintf("channel %s: %v", name, err))
+				continue
+			}
+			msg.File = msgFile
+			if !spec.has(name, &msg) {
+				spec.Channels[name] = append(spec.Channels[name], &msg)
+			}
+		}
+	}
+	return spec, nil
+}
+
+func (s *Spec) has(channel string, msg *Message) bool {
+	for _, m := range s.Channels[channel] {
+		if m.File == msg.File && m.Name == msg.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Message returns the message of eventType on topic.
+func (s *Spec) Message(topic, eventType string) (*Message, error) {
+	msgs, ok := s.Channels[topic]
+	if !ok {
+		return nil, fmt.Errorf("no channel %q in the AsyncAPI spec", topic)
+	}
+	for _, m := range msgs {
+		if m.EventType() == eventType {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("channel %q has no message with type %q", topic, eventType)
+}
+
+// resolve decodes node (found in file from) into out, following a
+// {$ref: "file#/pointer"} relative to it. It returns the file the value came from.
+func resolve(from string, node *yaml.Node, out any) (string, error) {
+	var ref struct {
+		Ref string `yaml:"$ref"`
+	}
+	if node.Kind == yaml.MappingNode {
+		_ = node.Decode(&ref)
+	}
+	if ref.Ref == "" {
+		return from, node.Decode(out)
+	}
+
+	file, pointer, _ := strings.Cut(ref.Ref, "#")
+	path := from // "#/pointer" refers to the same file
+	if file != "" {
+		path = filepath.Join(filepath.Dir(from), file)
+	}
+	if err := readYAML(path, pointer, out); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// readYAML decodes the node at the JSON pointer ("/a/b") of a YAML file.
+func readYAML(path, pointer string, out any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	node := &doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, key := range strings.Split(strings.Trim(pointer, "/"), "/") {
+		if key == "" {
+			continue
+		}
+		node = child(node, key)
+		if node == nil {
+			return fmt.Errorf("%s: no %q at #%s", path, key, pointer)
+		}
+	}
+	return node.Decode(out)
+}
+
+func child(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Validate checks a JSON document against schema and returns every
+// violation joined, with a path such as "data.user_id".
+func Validate(schema *Schema, raw []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	var errs []error
+	validate(schema, v, "$", &errs)
+	return errors.Join(errs...)
+}
+
+func validate(s *Schema, v any, path string, errs *[]error) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("%s: "+format, append([]any{path}, args...)...))
+	}
+
+	if s.Const != nil && fmt.Sprint(v) != fmt.Sprint(s.Const) {
+		fail("must be %v, got %v", s.Const, v)
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		fail("must be one of %v, got %v", s.Enum, v)
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("expected object, got %T", v)
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for name, prop := range s.Properties {
+			if val, ok := obj[name]; ok && val != nil {
+				validate(prop, val, path+"."+name, errs)
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("expected array, got %T", v)
+			return
+		}
+		for i, item := range arr {
+			validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("expected string, got %T", v)
+			return
+		}
+		if err := checkFormat(s.Format, str); err != nil {
+			fail("%v", err)
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			fail("expected integer, got %v", v)
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			fail("expected number, got %T", v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected boolean, got %T", v)
+		}
+	}
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFormat(format, s string) error {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(s); err != nil {
+			return fmt.Errorf("not a uuid: %q", s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return fmt.Errorf("not an RFC 3339 date-time: %q", s)
+		}
+	case "email":
+		if _, err := mail.ParseAddress(s); err != nil || !strings.Contains(s, "@") {
+			return fmt.Errorf("not an email: %q", s)
+		}
+	case "ipv4":
+		if ip := net.ParseIP(s); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("not an IPv4 address: %q", s)
+		}
+	}
+	return nil
+}
+
+// Sample builds a document that satisfies schema, filling every property
+// (optional ones too) so decoders see the full shape.
+func Sample(s *Schema) any {
+	if s == nil {
+		return nil
+	}
+	if s.Const != nil {
+		return s.Const
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+
+	switch s.Type {
+	case "object":
+		obj := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			obj[name] = Sample(prop)
+		}
+		return obj
+	case "array":
+		return []any{Sample(s.Items)}
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	case "string":
+		switch s.Format {
+		case "uuid":
+			return "8d3e4f0a-6b1c-4c2e-9f7a-1b2c3d4e5f60"
+		case "date-time":
+			return "2024-01-02T03:04:05Z"
+		case "email":
+			return "user@example.com"
+		case "ipv4":
+			return "192.0.2.1"
+		}
+		if ex, ok := s.Example.(string); ok {
+			return ex
+		}
+		return "sample"
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package events is the catalogue of integration events exchanged over Kafka.
+//
+// Every message is an Envelope whose Data holds one of the typed payloads
+// below. The payload types mirror the AsyncAPI messages under
+// docs/api/asyncapi/components/messages; change both together.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Header names set on every Kafka message next to the envelope, so
+// consumers and tooling can route without decoding the body.
+const (
+	HeaderEventID       = "event_id"
+	HeaderEventType     = "event_type"
+	HeaderSchemaVersion = "schema_version"
+)
+
+var (
+	// ErrNotEnvelope means the message is not a Bitka event envelope.
+	ErrNotEnvelope = errors.New("events: message is not an event envelope")
+	// ErrTypeMismatch means the envelope carries another event type.
+	ErrTypeMismatch = errors.New("events: unexpected event type")
+	// ErrUnsupportedVersion means the producer uses a newer schema than
+	// this consumer understands; the consumer must be upgraded first.
+	ErrUnsupportedVersion = errors.New("events: unsupported schema version")
+)
+
+// Event is implemented by every payload in the catalogue.
+type Event interface {
+	// EventType is the stable name used in the envelope ("UserRegistered").
+	EventType() string
+	// SchemaVersion is the payload version this build produces and the
+	// highest one it can decode. Bump it on breaking changes only;
+	// adding optional fields is backwards compatible.
+	SchemaVersion() int
+}
+
+// Envelope wraps every event on the bus.
+type Envelope struct {
+	EventID   uuid.UUID       `json:"event_id"`
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// New wraps e in an envelope with a fresh event ID.
+func New(e Event) (Envelope, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("events: encode %s: %w", e.EventType(), err)
+	}
+	return Envelope{
+		EventID:   uuid.New(),
+		Type:      e.EventType(),
+		Version:   e.SchemaVersion(),
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}, nil
+}
+
+// Encode wraps e and returns the wire bytes plus the envelope (for its ID).
+func Encode(e Event) ([]byte, Envelope, error) {
+	env, err := New(e)
+	if err != nil {
+		return nil, Envelope{}, err
+	}
+	raw, err := json.Marshal(env)
+	return raw, env, err
+}
+
+// Headers returns the Kafka headers describing env.
+func (env Envelope) Headers() map[string]string {
+	return map[string]string{
+		HeaderEventID:       env.EventID.String(),
+		HeaderEventType:     env.Type,
+		HeaderSchemaVersion: fmt.Sprint(env.Version),
+	}
+}
+
+// Unwrap parses the envelope without decoding Data, for consumers that
+// dispatch on Type.
+func Unwrap(raw []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrNotEnvelope, err)
+	}
+	if env.EventID == uuid.Nil || env.Type == "" || env.Version < 1 || len(env.Data) == 0 {
+		return Envelope{}, ErrNotEnvelope
+	}
+	return env, nil
+}
+
+// Decode parses raw as an envelope carrying T, checking type and version.
+//
+//	env, evt, err := events.Decode[events.UserRegistered](msg.Value)
+func Decode[T Event](raw []byte) (Envelope, T, error) {
+	var out T
+	env, err := Unwrap(raw)
+	if err != nil {
+		return Envelope{}, out, err
+	}
+	out, err = DecodeData[T](env)
+	return env, out, err
+}
+
+// DecodeData decodes the payload of an already unwrapped envelope.
+func DecodeData[T Event](env Envelope) (T, error) {
+	var out T
+	if env.Type != out.EventType() {
+		return out, fmt.Errorf("%w: got %q, want %q", ErrTypeMismatch, env.Type, out.EventType())
+	}
+	if env.Version > out.SchemaVersion() {
+		return out, fmt.Errorf("%w: %s v%d (supports up to v%d)", ErrUnsupportedVersion, env.Type, env.Version, out.SchemaVersion())
+	}
+	if err := json.Unmarshal(env.Data, &out); err != nil {
+		return out, fmt.Errorf("events: decode %s: %w", env.Type, err)
+	}
+	return out, nil
+}
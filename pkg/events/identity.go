@@ -0,0 +1,104 @@
+package events
+
+import "github.com/google/uuid"
+
+// UserRegistered is emitted by auth once a user row is committed.
+// Consumed by account to create the profile and attribute the referral.
+type UserRegistered struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+	// ReferralCode is the code entered at registration, as given; account
+	// validates it.
+	ReferralCode string `json:"referral_code,omitempty"`
+}
+
+func (UserRegistered) EventType() string  { return "UserRegistered" }
+func (UserRegistered) SchemaVersion() int { return 1 }
+
+// UserLogin records a login attempt, successful or not.
+type UserLogin struct {
+	UserID    string `json:"user_id"`
+	IPAddress string `json:"ip_address"`
+	DeviceID  string `json:"device_id,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Success   bool   `json:"success"`
+}
+
+func (UserLogin) EventType() string  { return "UserLogin" }
+func (UserLogin) SchemaVersion() int { return 1 }
+
+// KYC statuses carried by KycUpdated.
+const (
+	KycStatusNone     = "none"
+	KycStatusPending  = "pending"
+	KycStatusApproved = "approved"
+	KycStatusRejected = "rejected"
+	KycStatusExpired  = "expired"
+)
+
+// KycUpdated is emitted whenever a user's verification status changes.
+// Level is the effective tier: consumers gate limits on it, since a
+// pending or rejected upgrade keeps the tier of the earlier approval.
+type KycUpdated struct {
+	UserID         string `json:"user_id"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	Level          int    `json:"level"`
+	Reason         string `json:"reason,omitempty"` // rejection reason
+}
+
+func (KycUpdated) EventType() string  { return "KycUpdated" }
+func (KycUpdated) SchemaVersion() int { return 1 }
+
+// NotificationChannels says where one kind of notification is delivered.
+type NotificationChannels struct {
+	Email bool `json:"email"`
+	Push  bool `json:"push"`
+	SMS   bool `json:"sms"`
+}
+
+// NotificationSettings holds the channels per notification type.
+type NotificationSettings struct {
+	Login      NotificationChannels `json:"login"`
+	Deposit    NotificationChannels `json:"deposit"`
+	TradeFill  NotificationChannels `json:"trade_fill"`
+	Withdrawal NotificationChannels `json:"withdrawal"`
+}
+
+// PreferencesChanged carries a user's full preferences after a change, so
+// consumers (e.g. notifications) can keep a copy without calling back.
+type PreferencesChanged struct {
+	UserID          string               `json:"user_id"`
+	Locale          string               `json:"locale"`
+	Timezone        string               `json:"timezone"`
+	DisplayCurrency string               `json:"display_currency"`
+	Theme           string               `json:"theme"`
+	Notifications   NotificationSettings `json:"notifications"`
+	Changed         []string             `json:"changed"` // top-level fields that changed
+}
+
+func (PreferencesChanged) EventType() string  { return "PreferencesChanged" }
+func (PreferencesChanged) SchemaVersion() int { return 1 }
+
+// UserErasureRequested is emitted by account once a deletion request has
+// passed its cooling-off period and the account's own copy is
+// pseudonymised. Every service holding personal data about the user must
+// pseudonymise it and answer with UserErased. Financial records are kept.
+type UserErasureRequested struct {
+	UserID    string `json:"user_id"`
+	RequestID string `json:"request_id"`
+}
+
+func (UserErasureRequested) EventType() string  { return "UserErasureRequested" }
+func (UserErasureRequested) SchemaVersion() int { return 1 }
+
+// UserErased confirms that Service has pseudonymised its copy of the user.
+type UserErased struct {
+	UserID    string `json:"user_id"`
+	RequestID string `json:"request_id"`
+	Service   string `json:"service"`
+}
+
+func (UserErased) EventType() string  { return "UserErased" }
+func (UserErased) SchemaVersion() int { return 1 }
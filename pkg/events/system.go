@@ -0,0 +1,24 @@
+package events
+
+import "encoding/json"
+
+// Audit levels.
+const (
+	AuditInfo     = "INFO"
+	AuditWarn     = "WARN"
+	AuditError    = "ERROR"
+	AuditCritical = "CRITICAL"
+)
+
+// AuditLog is a security-relevant action taken by a user, admin or service.
+type AuditLog struct {
+	ServiceName string          `json:"service_name"`
+	Level       string          `json:"level"`
+	Action      string          `json:"action"`
+	ActorID     string          `json:"actor_id,omitempty"`
+	ResourceID  string          `json:"resource_id,omitempty"`
+	Changes     json.RawMessage `json:"changes,omitempty"` // JSON diff
+}
+
+func (AuditLog) EventType() string  { return "AuditLog" }
+func (AuditLog) SchemaVersion() int { return 1 }
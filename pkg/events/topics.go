@@ -0,0 +1,23 @@
+package events
+
+// Kafka topics, matching the channels in docs/api/asyncapi/asyncapi.yaml.
+const (
+	// Identity
+	TopicUserRegistered = "user-registered" // kept from before the catalogue; renaming needs a migration
+	TopicUserLogin      = "identity.user.login"
+	TopicKycUpdated     = "identity.kyc.updated"
+	TopicPreferences    = "identity.preferences.changed"
+
+	TopicUserErasureRequested = "identity.user.erasure-requested"
+	TopicUserErased           = "identity.user.erased"
+
+	// Trading
+	TopicTradeExecuted = "trading.matches.executed"
+
+	// Accounting
+	TopicLedgerDeposited = "accounting.ledger.deposited"
+	TopicLedgerWithdrawn = "accounting.ledger.withdrawn"
+
+	// System
+	TopicAuditLog = "system.audit.log"
+)
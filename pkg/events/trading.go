@@ -0,0 +1,32 @@
+package events
+
+// Taker sides in TradeExecuted.
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// TradeExecuted is emitted by the matching engine when two orders match.
+// Amounts are decimal strings to avoid float rounding.
+type TradeExecuted struct {
+	MatchID  string `json:"match_id"`
+	Symbol   string `json:"symbol"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+
+	// Maker (passive)
+	MakerOrderID  string `json:"maker_order_id"`
+	MakerUserID   string `json:"maker_user_id"`
+	MakerFee      string `json:"maker_fee"`
+	MakerFeeAsset string `json:"maker_fee_asset"`
+
+	// Taker (aggressive)
+	TakerOrderID  string `json:"taker_order_id"`
+	TakerUserID   string `json:"taker_user_id"`
+	TakerFee      string `json:"taker_fee"`
+	TakerFeeAsset string `json:"taker_fee_asset"`
+	TakerSide     string `json:"taker_side"`
+}
+
+func (TradeExecuted) EventType() string  { return "TradeExecuted" }
+func (TradeExecuted) SchemaVersion() int { return 1 }
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bitka/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Check reports whether a dependency is reachable. Return nil when healthy.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single Check, as rendered by /readyz.
+type Result struct {
+	Status  string `json:"status"` // "up" or "down"
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// Checker aggregates dependency checks for the readiness probe.
+type Checker struct {
+	mu           sync.RWMutex
+	checks       map[string]Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates a Checker. timeout bounds every readiness run.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Checker{
+		checks:  make(map[string]Check),
+		timeout: timeout,
+	}
+}
+
+// Add registers a named dependency check (e.g. "postgres", "kafka").
+func (h *Checker) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetShuttingDown makes readiness fail immediately so the load balancer
+// stops routing new traffic while in-flight requests drain.
+func (h *Checker) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Run executes all checks concurrently and reports whether all passed.
+func (h *Checker) Run(ctx context.Context) (map[string]Result, bool) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		healthy = true
+		results = make(map[string]Result, len(h.checks))
+	)
+
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+			res := Result{Status: "up", Latency: time.Since(start).String()}
+			if err != nil {
+				res.Status = "down"
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = res
+			if err != nil {
+				healthy = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return results, healthy
+}
+
+// Liveness answers "is the process alive?". It never touches dependencies,
+// otherwise a DB outage would make the orchestrator restart every replica.
+func (h *Checker) Liveness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return response.Success(c, fiber.Map{"status": "ok"})
+	}
+}
+
+// Readiness answers "can this replica serve traffic?".
+func (h *Checker) Readiness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if h.shuttingDown.Load() {
+			return response.Error(c, fiber.StatusServiceUnavailable, "shutting down")
+		}
+
+		results, ok := h.Run(c.UserContext())
+		if !ok {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(response.APIResponse{
+				Success: false,
+				Data:    results,
+				Error:   "dependency check failed",
+			})
+		}
+		return response.Success(c, results)
+	}
+}
+
+// Register mounts /healthz (liveness) and /readyz (readiness) on the app root.
+func (h *Checker) Register(app *fiber.App) {
+	app.Get("/healthz", h.Liveness())
+	app.Get("/readyz", h.Readiness())
+}
@@ -0,0 +1,177 @@
+// Package imaging re-encodes user-supplied images. Decoding and encoding
+// again drops everything but the pixels (EXIF GPS, camera serials, ICC
+// payloads, polyglot tails), and thumbnails are produced on the way.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	// Registered decoders
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+	ErrTooLarge          = errors.New("imaging: image dimensions too large")
+)
+
+// Options bounds and shapes the output.
+type Options struct {
+	MaxPixels     int // decoded width*height limit (decompression bombs); default 40M
+	MaxDimension  int // longer side of the main image is scaled down to this; 0 keeps it
+	ThumbnailSize int // longer side of the thumbnail; 0 skips it
+	JPEGQuality   int // default 85
+}
+
+func (o *Options) setDefaults() {
+	if o.MaxPixels <= 0 {
+		o.MaxPixels = 40_000_000
+	}
+	if o.JPEGQuality <= 0 {
+		o.JPEGQuality = 85
+	}
+}
+
+// Result is a re-encoded image. Thumbnail is nil when not requested.
+type Result struct {
+	Image       []byte
+	Thumbnail   []byte
+	ContentType string // of both Image and Thumbnail
+	Width       int
+	Height      int
+}
+
+// decodable are the sniffed types Process accepts.
+var decodable = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Sniff returns the content type detected from the first bytes of data.
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// IsImage reports whether Process can decode the sniffed contentType.
+func IsImage(contentType string) bool {
+	return decodable[contentType]
+}
+
+// Process decodes data, checks its size, and re-encodes it. Images with
+// transparency stay PNG; everything else becomes JPEG. Animated GIFs keep
+// their first frame only.
+func Process(data []byte, opts Options) (*Result, error) {
+	opts.setDefaults()
+
+	if !IsImage(Sniff(data)) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	// Check dimensions before allocating the full bitmap
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > opts.MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	encode, contentType := encodeJPEG(opts.JPEGQuality), "image/jpeg"
+	if hasAlpha(src) {
+		encode, contentType = encodePNG, "image/png"
+	}
+
+	main := fit(src, opts.MaxDimension)
+	out, err := encode(main)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Image:       out,
+		ContentType: contentType,
+		Width:       main.Bounds().Dx(),
+		Height:      main.Bounds().Dy(),
+	}
+	if opts.ThumbnailSize > 0 {
+		if res.Thumbnail, err = encode(fit(src, opts.ThumbnailSize)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// fit scales img down so its longer side is at most size. It always
+// returns a fresh RGBA copy, so no decoder state leaks into the output.
+func fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if size > 0 && (w > size || h > size) {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	}
+	return dst
+}
+
+// hasAlpha reports whether any pixel is not fully opaque.
+func hasAlpha(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func encodeJPEG(quality int) func(image.Image) ([]byte, error) {
+	return func(img image.Image) ([]byte, error) {
+		// JPEG has no alpha: flatten onto white
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality})
+		return buf.Bytes(), err
+	}
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	err := enc.Encode(&buf, img)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,59 @@
+// Command dlq-replay republishes dead-lettered messages to their original topic.
+//
+//	go run ./pkg/kafka/cmd/dlq-replay -topic user-registered.dlq [-to topic] [-limit N] [-dry-run]
+//
+// Brokers are read from KAFKA_BROKERS like the services.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"bitka/pkg/config"
+	"bitka/pkg/kafka"
+	"bitka/pkg/logger"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	topic := flag.String("topic", "", "dead-letter topic to replay (e.g. user-registered.dlq)")
+	target := flag.String("to", "", "override destination topic (default: the original topic of each message)")
+	limit := flag.Int("limit", 0, "replay at most N messages (0 = all)")
+	dryRun := flag.Bool("dry-run", false, "only log what would be replayed")
+	flag.Parse()
+
+	logger.Init(logger.Config{Environment: "development", LogLevel: "info", ServiceName: "dlq-replay"})
+
+	if *topic == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var cfg struct {
+		Kafka config.Kafka
+	}
+	config.MustLoad(&cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	res, err := kafka.Replay(ctx, kafka.ReplayConfig{
+		Brokers:  cfg.Kafka.Brokers,
+		DLQTopic: *topic,
+		Target:   *target,
+		Limit:    *limit,
+		DryRun:   *dryRun,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Int("replayed", res.Replayed).Msg("Replay failed")
+	}
+	log.Info().
+		Int("replayed", res.Replayed).
+		Int("skipped", res.Skipped).
+		Bool("dry_run", *dryRun).
+		Msg("Replay finished")
+}
@@ -0,0 +1,218 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"bitka/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog/log"
+)
+
+// ConsumerConfig configures a consumer-group member.
+type ConsumerConfig struct {
+	Brokers []string
+	GroupID string   // instances sharing it split the partitions
+	Topics  []string // usually Mux.Topics()
+
+	// InitialOffset is where a group with no committed offset starts:
+	// "oldest" (default, nothing produced before the first deploy is lost)
+	// or "newest".
+	InitialOffset string
+
+	MaxRetries   int           // retries after the first attempt (default 3)
+	RetryBackoff time.Duration // first retry delay, doubled per retry (default 200ms)
+	MaxBackoff   time.Duration // default 10s
+
+	// DisableDLQ drops messages that exhausted their retries (logged)
+	// instead of moving them to "<topic>.dlq".
+	DisableDLQ bool
+}
+
+func (c *ConsumerConfig) setDefaults() {
+	if c.InitialOffset == "" {
+		c.InitialOffset = "oldest"
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	} else if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = 200 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 10 * time.Second
+	}
+}
+
+// Consumer is a running consumer-group member (Kafka or in-memory).
+type Consumer interface {
+	// Start connects and consumes in the background.
+	Start()
+	// Close leaves the group after the in-flight message finishes.
+	Close(ctx context.Context) error
+}
+
+// groupConsumer reads all partitions of its topics as a member of a Kafka
+// consumer group. Offsets are committed only after a message was handled
+// or dead-lettered, so a restart resumes where the group left off.
+type groupConsumer struct {
+	cfg       ConsumerConfig
+	processor *Processor
+
+	mu     sync.Mutex
+	client sarama.Client
+	group  sarama.ConsumerGroup
+	dlq    Producer
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumer wraps handler with mws (see DefaultMiddleware).
+// Call Start to begin consuming and Close to stop.
+func NewConsumer(cfg ConsumerConfig, handler Handler, mws ...Middleware) Consumer {
+	cfg.setDefaults()
+	return &groupConsumer{
+		cfg:       cfg,
+		processor: NewProcessor(cfg, Chain(handler, mws...), nil),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start connects (retrying until Kafka is up or Close is called) and
+// consumes in the background.
+func (c *groupConsumer) Start() {
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.mu.Unlock()
+		return // already started
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.mu.Unlock()
+
+	go c.run(ctx)
+}
+
+func (c *groupConsumer) run(ctx context.Context) {
+	defer close(c.done)
+
+	if !c.connect(ctx) {
+		return
+	}
+	log.Info().
+		Str("action", "kafka_connect").
+		Str("group", c.cfg.GroupID).
+		Strs("topics", c.cfg.Topics).
+		Msg("Kafka consumer group started")
+
+	// Consume returns on every rebalance; loop to rejoin
+	for ctx.Err() == nil {
+		if err := c.group.Consume(ctx, c.cfg.Topics, c); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return
+			}
+			log.Error().Err(err).Str("action", "kafka_consume").Str("group", c.cfg.GroupID).Msg("Consumer group session ended, rejoining in 2s")
+			if !sleep(ctx, 2*time.Second) {
+				return
+			}
+		}
+	}
+}
+
+func (c *groupConsumer) connect(ctx context.Context) bool {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	if c.cfg.InitialOffset == "newest" {
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	}
+
+	for {
+		client, err := sarama.NewClient(c.cfg.Brokers, config)
+		if err == nil {
+			var group sarama.ConsumerGroup
+			var dlq Producer
+			if group, err = sarama.NewConsumerGroupFromClient(c.cfg.GroupID, client); err == nil {
+				if dlq, err = NewProducer(ProducerConfig{Brokers: c.cfg.Brokers}); err == nil {
+					c.mu.Lock()
+					c.client, c.group, c.dlq = client, group, dlq
+					c.mu.Unlock()
+					c.processor.dlq = dlq
+					go c.logErrors(group)
+					return true
+				}
+				group.Close()
+			}
+			client.Close()
+		}
+
+		log.Warn().Err(err).Str("action", "kafka_connect").Msg("Kafka not ready, retrying in 2s")
+		if !sleep(ctx, 2*time.Second) {
+			return false
+		}
+	}
+}
+
+func (c *groupConsumer) logErrors(group sarama.ConsumerGroup) {
+	for err := range group.Errors() {
+		log.Error().Err(err).Str("action", "kafka_consume").Str("group", c.cfg.GroupID).Msg("Kafka error")
+	}
+}
+
+// Close leaves the group after the in-flight message finishes, commits
+// offsets and releases the broker connections.
+func (c *groupConsumer) Close(ctx context.Context) error {
+	c.mu.Lock()
+	cancel := c.cancel
+	c.mu.Unlock()
+	if cancel == nil {
+		return nil // never started
+	}
+	cancel()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.group == nil {
+		return nil // never connected
+	}
+	return errors.Join(c.group.Close(), c.dlq.Close(), c.client.Close())
+}
+
+// Setup implements sarama.ConsumerGroupHandler.
+func (c *groupConsumer) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+// Cleanup implements sarama.ConsumerGroupHandler.
+func (c *groupConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler for one partition.
+func (c *groupConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if err := c.processor.Process(session.Context(), fromSarama(msg)); err != nil {
+				// Not marked: the message is redelivered after the session restarts
+				return err
+			}
+			session.MarkMessage(msg, "")
+			metrics.SetConsumerLag(msg.Topic, msg.Partition, claim.HighWaterMarkOffset(), msg.Offset)
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitka/pkg/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DLQSuffix is appended to a topic name to get its dead-letter topic.
+const DLQSuffix = ".dlq"
+
+// Headers added to dead-lettered messages, next to the original headers.
+const (
+	HeaderDLQPrefix            = "dlq."
+	HeaderDLQOriginalTopic     = "dlq.original_topic"
+	HeaderDLQOriginalPartition = "dlq.original_partition"
+	HeaderDLQOriginalOffset    = "dlq.original_offset"
+	HeaderDLQConsumerGroup     = "dlq.consumer_group"
+	HeaderDLQError             = "dlq.error"
+	HeaderDLQAttempts          = "dlq.attempts"
+	HeaderDLQFailedAt          = "dlq.failed_at"
+)
+
+// DLQTopic returns the dead-letter topic for topic.
+func DLQTopic(topic string) string {
+	return topic + DLQSuffix
+}
+
+func (p *Processor) deadLetter(ctx context.Context, msg *Message, cause error) error {
+	headers := make(map[string]string, len(msg.Headers)+7)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDLQOriginalTopic] = msg.Topic
+	headers[HeaderDLQOriginalPartition] = strconv.Itoa(int(msg.Partition))
+	headers[HeaderDLQOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderDLQConsumerGroup] = p.cfg.GroupID
+	headers[HeaderDLQError] = cause.Error()
+	headers[HeaderDLQAttempts] = strconv.Itoa(msg.Attempt)
+	headers[HeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	out := &Record{
+		Topic:   DLQTopic(msg.Topic),
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+	if err := p.dlq.Send(ctx, out); err != nil {
+		log.Error().Err(err).
+			Str("action", "kafka_dlq").
+			Str("status", "failed").
+			Str("topic", msg.Topic).
+			Msg("Failed to dead-letter message")
+		return err
+	}
+
+	metrics.IncDeadLettered(msg.Topic)
+	log.Error().Err(cause).
+		Str("action", "kafka_dlq").
+		Str("status", "dead_lettered").
+		Str("topic", msg.Topic).
+		Int32("partition", msg.Partition).
+		Int64("offset", msg.Offset).
+		Int("attempts", msg.Attempt).
+		Str("dlq_topic", out.Topic).
+		Msg("Message moved to dead-letter topic")
+	return nil
+}
+
+// stripDLQHeaders returns the original headers of a dead-lettered message.
+func stripDLQHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if !strings.HasPrefix(k, HeaderDLQPrefix) {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package kafka
+
+import "errors"
+
+// ErrProducerClosed is returned when sending through a closed producer.
+var ErrProducerClosed = errors.New("kafka: producer is closed")
+
+// ErrContractViolation wraps validation failures of ProducerConfig.Validate.
+var ErrContractViolation = errors.New("kafka: record violates the event contract")
+
+// permanentError marks a failure that retrying cannot fix (bad payload,
+// unsupported schema version).
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the consumer skips the remaining retries and
+// dead-letters the message immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err (or anything it wraps) was marked Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeProducer records everything sent to it, for tests and tooling that
+// must not talk to a broker. Offsets count up per topic from 0.
+type FakeProducer struct {
+	// Err, when set, fails every send.
+	Err error
+	// OnDelivery is called for SendAsync like the real producer's callback.
+	OnDelivery DeliveryFunc
+	// Validate mirrors ProducerConfig.Validate.
+	Validate func(topic string, value []byte) error
+
+	mu      sync.Mutex
+	records map[string][]*Record
+	closed  bool
+}
+
+var _ Producer = (*FakeProducer)(nil)
+
+func NewFakeProducer() *FakeProducer {
+	return &FakeProducer{records: make(map[string][]*Record)}
+}
+
+func (f *FakeProducer) Send(_ context.Context, rec *Record) error {
+	_, _, err := f.store(rec)
+	return err
+}
+
+func (f *FakeProducer) SendAsync(_ context.Context, rec *Record) {
+	partition, offset, err := f.store(rec)
+	if f.OnDelivery != nil {
+		f.OnDelivery(rec, partition, offset, err)
+	}
+}
+
+func (f *FakeProducer) store(rec *Record) (int32, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, -1, ErrProducerClosed
+	}
+	if f.Err != nil {
+		return 0, -1, f.Err
+	}
+	if f.Validate != nil {
+		if err := f.Validate(rec.Topic, rec.Value); err != nil {
+			return 0, -1, fmt.Errorf("%w: %w", ErrContractViolation, err)
+		}
+	}
+	f.records[rec.Topic] = append(f.records[rec.Topic], rec)
+	return 0, int64(len(f.records[rec.Topic]) - 1), nil
+}
+
+// Records returns a copy of what was sent to topic, in order.
+func (f *FakeProducer) Records(topic string) []*Record {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Record(nil), f.records[topic]...)
+}
+
+// Reset forgets all recorded messages.
+func (f *FakeProducer) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = make(map[string][]*Record)
+}
+
+func (f *FakeProducer) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
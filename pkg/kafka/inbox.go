@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/events"
+	"bitka/pkg/logger"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoEventID is returned for messages that carry no event ID, which the
+// inbox cannot deduplicate.
+var ErrNoEventID = errors.New("kafka: message has no event ID")
+
+// InboxMessage records that a consumer handled an event.
+// The table is created by each consuming service's migrations.
+type InboxMessage struct {
+	Consumer    string `gorm:"primaryKey"` // consumer group
+	EventID     string `gorm:"primaryKey"`
+	Topic       string `gorm:"not null"`
+	ProcessedAt time.Time
+}
+
+func (InboxMessage) TableName() string { return "inbox_messages" }
+
+// Inbox makes handlers run at most once per event ID.
+//
+// The inbox row and the handler's writes share one transaction: if the
+// handler fails, both roll back and the redelivery runs it again; if it
+// succeeded, a redelivery finds the row and is skipped. Handlers must use
+// the ctx they receive (database.Conn) for their writes to join it.
+type Inbox struct {
+	db        *gorm.DB
+	tx        *database.TxManager
+	consumer  string
+	retention time.Duration
+}
+
+// NewInbox creates an inbox for one consumer group. Rows older than
+// retention are pruned by RunPruner; it must comfortably exceed the
+// longest time a message can be redelivered (retries, DLQ replay).
+func NewInbox(db *gorm.DB, tx *database.TxManager, consumer string, retention time.Duration) *Inbox {
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	return &Inbox{db: db, tx: tx, consumer: consumer, retention: retention}
+}
+
+// Wrap returns a Handler that runs next inside the inbox transaction.
+// Place it innermost, below DefaultMiddleware.
+func (i *Inbox) Wrap(next Handler) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		eventID := eventIDOf(msg)
+		if eventID == "" {
+			return Permanent(ErrNoEventID)
+		}
+
+		return i.tx.Do(ctx, func(ctx context.Context) error {
+			res := database.Conn(ctx, i.db).
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&InboxMessage{
+					Consumer:    i.consumer,
+					EventID:     eventID,
+					Topic:       msg.Topic,
+					ProcessedAt: time.Now(),
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				logger.From(ctx).Info().
+					Str("action", "kafka_inbox").
+					Str("status", "skipped").
+					Str("event_id", eventID).
+					Msg("Event already processed, skipping")
+				return nil
+			}
+			return next(ctx, msg)
+		})
+	}
+}
+
+// eventIDOf prefers the header and falls back to the envelope.
+func eventIDOf(msg *Message) string {
+	if id := msg.Header(events.HeaderEventID); id != "" {
+		return id
+	}
+	if env, err := events.Unwrap(msg.Value); err == nil {
+		return env.EventID.String()
+	}
+	return ""
+}
+
+// Prune deletes inbox rows older than the retention window.
+func (i *Inbox) Prune(ctx context.Context) (int64, error) {
+	res := i.db.WithContext(ctx).
+		Where("consumer = ? AND processed_at < ?", i.consumer, time.Now().Add(-i.retention)).
+		Delete(&InboxMessage{})
+	return res.RowsAffected, res.Error
+}
+
+// RunPruner prunes every interval until ctx is cancelled.
+func (i *Inbox) RunPruner(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := i.Prune(ctx)
+			if err != nil {
+				log.Error().Err(err).Str("action", "kafka_inbox_prune").Str("status", "failed").Msg("Inbox prune failed")
+				continue
+			}
+			if n > 0 {
+				log.Info().Str("action", "kafka_inbox_prune").Str("status", "success").Int64("deleted", n).Msg("Pruned inbox")
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package kafka
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"bitka/pkg/database"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// inboxTable is a fake database holding only inbox_messages keys. Inserts
+// made in a transaction become visible on commit and vanish on rollback.
+type inboxTable struct {
+	mu   sync.Mutex
+	rows map[string]bool
+}
+
+func (t *inboxTable) Connect(context.Context) (driver.Conn, error) { return &inboxConn{table: t}, nil }
+func (t *inboxTable) Driver() driver.Driver                        { return nil }
+
+func (t *inboxTable) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.rows)
+}
+
+type inboxConn struct {
+	table   *inboxTable
+	pending map[string]bool // nil outside a transaction
+}
+
+func (c *inboxConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("inboxConn: unexpected prepare %q", query)
+}
+func (c *inboxConn) Close() error { return nil }
+
+func (c *inboxConn) Begin() (driver.Tx, error) {
+	c.pending = make(map[string]bool)
+	return c, nil
+}
+
+func (c *inboxConn) Commit() error {
+	c.table.mu.Lock()
+	defer c.table.mu.Unlock()
+	for k := range c.pending {
+		c.table.rows[k] = true
+	}
+	c.pending = nil
+	return nil
+}
+
+func (c *inboxConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+// ExecContext runs the inbox INSERT ... ON CONFLICT DO NOTHING, keyed by
+// its first two arguments (consumer, event_id).
+func (c *inboxConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !strings.HasPrefix(query, `INSERT INTO "inbox_messages"`) || !strings.Contains(query, "ON CONFLICT DO NOTHING") {
+		return nil, fmt.Errorf("inboxConn: unexpected query %q", query)
+	}
+	key := fmt.Sprint(args[0].Value, "/", args[1].Value)
+
+	c.table.mu.Lock()
+	defer c.table.mu.Unlock()
+	if c.table.rows[key] || c.pending[key] {
+		return driver.RowsAffected(0), nil
+	}
+	if c.pending == nil {
+		c.table.rows[key] = true
+	} else {
+		c.pending[key] = true
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *inboxConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return nil, fmt.Errorf("inboxConn: unexpected query %q", query)
+}
+
+func newTestInbox(t *testing.T) (*Inbox, *inboxTable) {
+	t.Helper()
+	table := &inboxTable{rows: make(map[string]bool)}
+	sqlDB := sql.OpenDB(table)
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewInbox(db, database.NewTxManager(db), "account", 0), table
+}
+
+func TestInboxSkipsRedelivery(t *testing.T) {
+	inbox, table := newTestInbox(t)
+	var calls int
+	handler := inbox.Wrap(func(ctx context.Context, _ *Message) error {
+		calls++
+		if !database.InTx(ctx) {
+			t.Error("handler runs outside the inbox transaction")
+		}
+		return nil
+	})
+
+	for range 2 {
+		if err := handler(context.Background(), testMessage()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+
+	// Another event still goes through
+	other := testMessage()
+	other.Headers["event_id"] = "e-2"
+	if err := handler(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || table.len() != 2 {
+		t.Fatalf("handler ran %d times, %d inbox rows", calls, table.len())
+	}
+}
+
+func TestInboxRollsBackFailedHandler(t *testing.T) {
+	inbox, table := newTestInbox(t)
+	var calls int
+	handler := inbox.Wrap(failing(1, errors.New("db down"), &calls))
+
+	if err := handler(context.Background(), testMessage()); err == nil {
+		t.Fatal("the handler error was swallowed")
+	}
+	if table.len() != 0 {
+		t.Fatal("a failed handler left its inbox row behind")
+	}
+
+	// The redelivery runs the handler again and then records the event
+	if err := handler(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || table.len() != 1 {
+		t.Fatalf("handler ran %d times, %d inbox rows", calls, table.len())
+	}
+}
+
+func TestInboxEventID(t *testing.T) {
+	inbox, table := newTestInbox(t)
+	handler := inbox.Wrap(func(context.Context, *Message) error { return nil })
+
+	// Without the header the envelope's ID is used
+	msg := testMessage()
+	delete(msg.Headers, "event_id")
+	msg.Value = []byte(`{"event_id":"8f1f6a52-3c1a-4a43-9a57-3a1a8f6f9d11","type":"user.registered","version":1,"data":{}}`)
+	if err := handler(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if !table.rows["account/8f1f6a52-3c1a-4a43-9a57-3a1a8f6f9d11"] {
+		t.Fatalf("inbox rows = %v", table.rows)
+	}
+
+	// Nothing to deduplicate on: retrying cannot help
+	msg.Value = []byte(`not json`)
+	if err := handler(context.Background(), msg); !errors.Is(err, ErrNoEventID) || !IsPermanent(err) {
+		t.Fatalf("err = %v, want permanent %v", err, ErrNoEventID)
+	}
+}
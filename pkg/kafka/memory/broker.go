@@ -0,0 +1,215 @@
+// Package memory is an in-process stand-in for Kafka: topics with
+// partitions, keyed partitioning, consumer groups with committed offsets.
+// It implements the kafka.Producer and kafka.Consumer interfaces so a
+// service (or a whole test) runs without Zookeeper/Kafka.
+//
+// Messages live only as long as the process; nothing crosses process
+// boundaries, so auth, the outbox relay and account only talk to each
+// other through it when they run in the same process.
+package memory
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"bitka/pkg/kafka"
+)
+
+// DefaultPartitions is used for topics created implicitly.
+const DefaultPartitions = 3
+
+// Broker holds all topics and consumer-group offsets.
+type Broker struct {
+	partitions int
+
+	mu      sync.Mutex
+	topics  map[string][][]*kafka.Message // topic → partition → log
+	offsets map[string]map[string][]int64 // group → topic → next offset per partition
+	claims  map[claimKey]bool             // partitions currently being processed
+	next    map[string]int                // round-robin cursor for keyless records
+	changed chan struct{}                 // closed and replaced on every append
+}
+
+type claimKey struct {
+	group     string
+	topic     string
+	partition int32
+}
+
+// NewBroker creates an empty broker whose topics get partitions partitions.
+func NewBroker(partitions int) *Broker {
+	if partitions <= 0 {
+		partitions = DefaultPartitions
+	}
+	return &Broker{
+		partitions: partitions,
+		topics:     make(map[string][][]*kafka.Message),
+		offsets:    make(map[string]map[string][]int64),
+		claims:     make(map[claimKey]bool),
+		next:       make(map[string]int),
+		changed:    make(chan struct{}),
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultBroker *Broker
+)
+
+// Default is the process-wide broker used by KAFKA_MODE=memory.
+func Default() *Broker {
+	defaultOnce.Do(func() { defaultBroker = NewBroker(DefaultPartitions) })
+	return defaultBroker
+}
+
+// topic returns the partitions of name, creating it on first use. Caller holds mu.
+func (b *Broker) topic(name string) [][]*kafka.Message {
+	parts, ok := b.topics[name]
+	if !ok {
+		parts = make([][]*kafka.Message, b.partitions)
+		b.topics[name] = parts
+	}
+	return parts
+}
+
+// append stores rec and returns its partition and offset.
+func (b *Broker) append(rec *kafka.Record) (int32, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parts := b.topic(rec.Topic)
+	var partition int32
+	if len(rec.Key) > 0 {
+		h := fnv.New32a()
+		h.Write(rec.Key)
+		partition = int32(h.Sum32() % uint32(len(parts)))
+	} else {
+		partition = int32(b.next[rec.Topic] % len(parts))
+		b.next[rec.Topic]++
+	}
+
+	headers := make(map[string]string, len(rec.Headers))
+	for k, v := range rec.Headers {
+		headers[k] = v
+	}
+	offset := int64(len(parts[partition]))
+	parts[partition] = append(parts[partition], &kafka.Message{
+		Topic:     rec.Topic,
+		Partition: partition,
+		Offset:    offset,
+		Key:       append([]byte(nil), rec.Key...),
+		Value:     append([]byte(nil), rec.Value...),
+		Headers:   headers,
+		Timestamp: time.Now(),
+	})
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return partition, offset
+}
+
+// wait returns a channel closed on the next append.
+func (b *Broker) wait() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.changed
+}
+
+// join registers group on topic, starting at the end when newest is set.
+func (b *Broker) join(group, topic string, newest bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parts := b.topic(topic)
+	if b.offsets[group] == nil {
+		b.offsets[group] = make(map[string][]int64)
+	}
+	if b.offsets[group][topic] != nil {
+		return // committed offsets survive members leaving
+	}
+	offsets := make([]int64, len(parts))
+	if newest {
+		for p := range parts {
+			offsets[p] = int64(len(parts[p]))
+		}
+	}
+	b.offsets[group][topic] = offsets
+}
+
+// claimNext returns the next uncommitted message of a partition no other
+// member of group is processing, and claims that partition. One message
+// per partition is in flight at a time, which keeps partition order.
+func (b *Broker) claimNext(group, topic string) (*kafka.Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parts := b.topic(topic)
+	offsets := b.offsets[group][topic]
+	for p := range parts {
+		key := claimKey{group, topic, int32(p)}
+		if b.claims[key] || offsets[p] >= int64(len(parts[p])) {
+			continue
+		}
+		b.claims[key] = true
+		msg := *parts[p][offsets[p]]
+		msg.Headers = copyHeaders(msg.Headers)
+		return &msg, true
+	}
+	return nil, false
+}
+
+// release ends a claim, committing past msg when commit is set.
+func (b *Broker) release(group string, msg *kafka.Message, commit bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if commit {
+		b.offsets[group][msg.Topic][msg.Partition] = msg.Offset + 1
+	}
+	delete(b.claims, claimKey{group, msg.Topic, msg.Partition})
+}
+
+// Messages returns a copy of everything on topic, partition by partition.
+func (b *Broker) Messages(topic string) []*kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []*kafka.Message
+	for _, part := range b.topics[topic] {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// highWaterMark is the offset the next record of a partition will get.
+func (b *Broker) highWaterMark(topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.topic(topic)[partition]))
+}
+
+// Lag returns how many messages of topic group has not committed yet.
+func (b *Broker) Lag(group, topic string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lag int64
+	offsets := b.offsets[group][topic]
+	for p, part := range b.topics[topic] {
+		committed := int64(0)
+		if offsets != nil {
+			committed = offsets[p]
+		}
+		lag += int64(len(part)) - committed
+	}
+	return lag
+}
+
+func copyHeaders(h map[string]string) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = v
+	}
+	return out
+}
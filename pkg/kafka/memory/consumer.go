@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"bitka/pkg/kafka"
+	"bitka/pkg/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+type consumer struct {
+	broker    *Broker
+	cfg       kafka.ConsumerConfig
+	processor *kafka.Processor
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumer returns a kafka.Consumer reading from b with the same retry,
+// DLQ and middleware behaviour as kafka.NewConsumer. Several consumers
+// with one GroupID split the partitions between them.
+func (b *Broker) NewConsumer(cfg kafka.ConsumerConfig, handler kafka.Handler, mws ...kafka.Middleware) kafka.Consumer {
+	dlq := b.NewProducer(kafka.ProducerConfig{})
+	return &consumer{
+		broker:    b,
+		cfg:       cfg,
+		processor: kafka.NewProcessor(cfg, kafka.Chain(handler, mws...), dlq),
+		done:      make(chan struct{}),
+	}
+}
+
+func (c *consumer) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return
+	}
+	for _, topic := range c.cfg.Topics {
+		c.broker.join(c.cfg.GroupID, topic, c.cfg.InitialOffset == "newest")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.run(ctx)
+
+	log.Info().
+		Str("action", "kafka_connect").
+		Str("group", c.cfg.GroupID).
+		Strs("topics", c.cfg.Topics).
+		Msg("In-memory consumer started")
+}
+
+func (c *consumer) run(ctx context.Context) {
+	defer close(c.done)
+	for {
+		// Take the channel before looking so an append in between wakes us
+		changed := c.broker.wait()
+
+		for c.poll(ctx) {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+// poll handles at most one message per topic and reports whether it did any work.
+func (c *consumer) poll(ctx context.Context) bool {
+	worked := false
+	for _, topic := range c.cfg.Topics {
+		if ctx.Err() != nil {
+			return false
+		}
+		msg, ok := c.broker.claimNext(c.cfg.GroupID, topic)
+		if !ok {
+			continue
+		}
+		worked = true
+
+		err := c.processor.Process(ctx, msg)
+		// Like Kafka: an unprocessed message stays uncommitted and is retried
+		c.broker.release(c.cfg.GroupID, msg, err == nil)
+		metrics.SetConsumerLag(topic, msg.Partition, c.broker.highWaterMark(topic, msg.Partition), msg.Offset)
+	}
+	return worked
+}
+
+func (c *consumer) Close(ctx context.Context) error {
+	c.mu.Lock()
+	cancel := c.cancel
+	c.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
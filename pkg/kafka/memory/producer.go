@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"bitka/pkg/kafka"
+	"bitka/pkg/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type producer struct {
+	broker *Broker
+	cfg    kafka.ProducerConfig
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewProducer returns a kafka.Producer writing to b. Brokers and flush
+// settings are ignored; OnDelivery and Validate behave as with Kafka.
+func (b *Broker) NewProducer(cfg kafka.ProducerConfig) kafka.Producer {
+	return &producer{broker: b, cfg: cfg}
+}
+
+func (p *producer) Send(ctx context.Context, rec *kafka.Record) error {
+	_, _, err := p.send(ctx, rec)
+	return err
+}
+
+// SendAsync delivers immediately; the callback runs before it returns.
+func (p *producer) SendAsync(ctx context.Context, rec *kafka.Record) {
+	partition, offset, err := p.send(ctx, rec)
+	if p.cfg.OnDelivery != nil {
+		p.cfg.OnDelivery(rec, partition, offset, err)
+	}
+}
+
+func (p *producer) send(ctx context.Context, rec *kafka.Record) (int32, int64, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	err := p.check(rec)
+	if err == nil && p.closed {
+		err = kafka.ErrProducerClosed
+	}
+	if err != nil {
+		metrics.ObserveProduce(rec.Topic, err)
+		return -1, -1, err
+	}
+
+	// Same trace propagation as the Kafka producer
+	out := *rec
+	out.Headers = copyHeaders(rec.Headers)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(out.Headers))
+
+	partition, offset := p.broker.append(&out)
+	metrics.ObserveProduce(rec.Topic, nil)
+	return partition, offset, nil
+}
+
+func (p *producer) check(rec *kafka.Record) error {
+	if p.cfg.Validate == nil {
+		return nil
+	}
+	if err := p.cfg.Validate(rec.Topic, rec.Value); err != nil {
+		return fmt.Errorf("%w: %w", kafka.ErrContractViolation, err)
+	}
+	return nil
+}
+
+func (p *producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
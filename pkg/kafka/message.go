@@ -0,0 +1,60 @@
+// Package kafka holds the shared Kafka plumbing: a consumer-group based
+// consumer with handler middleware, bounded retries and a dead-letter topic.
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Message is a consumed record, decoupled from the Sarama types so
+// handlers do not depend on the client library.
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Timestamp time.Time
+
+	// Attempt is 1 on first delivery and grows with every retry.
+	Attempt int
+}
+
+// Header returns the header value for key, or "".
+func (m *Message) Header(key string) string {
+	return m.Headers[key]
+}
+
+// Handler processes one message. Returning an error triggers a retry;
+// wrap it with Permanent to send the message straight to the DLQ.
+type Handler func(ctx context.Context, msg *Message) error
+
+func fromSarama(msg *sarama.ConsumerMessage) *Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h != nil {
+			headers[string(h.Key)] = string(h.Value)
+		}
+	}
+	return &Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Timestamp: msg.Timestamp,
+	}
+}
+
+func toRecordHeaders(headers map[string]string) []sarama.RecordHeader {
+	out := make([]sarama.RecordHeader, 0, len(headers))
+	for k, v := range headers {
+		out = append(out, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return out
+}
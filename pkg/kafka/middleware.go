@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"bitka/pkg/logger"
+	"bitka/pkg/metrics"
+	"bitka/pkg/tracing"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Middleware wraps a Handler, like Fiber middleware wraps a route.
+type Middleware func(Handler) Handler
+
+// Chain applies mws to h; the first middleware is the outermost.
+func Chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// DefaultMiddleware is the stack every service consumer should use:
+// trace → log → metrics → recover → handler.
+func DefaultMiddleware() []Middleware {
+	return []Middleware{Tracing(), Logging(), Metrics(), Recover()}
+}
+
+// Tracing continues the producer's trace (traceparent header).
+func Tracing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			ctx, span := tracing.StartConsumerSpanFromHeaders(ctx, msg.Topic, msg.Partition, msg.Offset, msg.Headers, len(msg.Value))
+			defer span.End()
+
+			err := next(ctx, msg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// Logging puts a message-scoped logger in ctx (logger.From) and logs failures.
+// Successful messages are only logged at debug level; handlers log their
+// own business outcome.
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			scoped := log.With().
+				Str("topic", msg.Topic).
+				Int32("partition", msg.Partition).
+				Int64("offset", msg.Offset).
+				Str("event_id", msg.Header("event_id")).
+				Int("attempt", msg.Attempt).
+				Logger()
+			ctx = logger.WithSpan(logger.WithContext(ctx, &scoped))
+			l := logger.From(ctx)
+
+			start := time.Now()
+			err := next(ctx, msg)
+
+			if err != nil {
+				l.Warn().Err(err).
+					Str("action", "kafka_consume").
+					Str("status", "failed").
+					Dur("latency", time.Since(start)).
+					Msg("Message handler failed")
+				return err
+			}
+			l.Debug().
+				Str("action", "kafka_consume").
+				Str("status", "success").
+				Dur("latency", time.Since(start)).
+				Msg("Message handled")
+			return nil
+		}
+	}
+}
+
+// Metrics counts handled and failed messages per topic.
+func Metrics() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			err := next(ctx, msg)
+			metrics.ObserveConsume(msg.Topic, err)
+			return err
+		}
+	}
+}
+
+// Recover turns a handler panic into an error so the message is retried
+// and eventually dead-lettered instead of crashing the consumer.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.From(ctx).Error().
+						Str("action", "kafka_consume").
+						Str("status", "panic").
+						Str("stack", string(debug.Stack())).
+						Msgf("Handler panic: %v", r)
+					err = fmt.Errorf("kafka: handler panic: %v", r)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
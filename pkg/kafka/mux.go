@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Mux routes messages to a handler per topic.
+//
+//	mux := kafka.NewMux()
+//	mux.Handle(events.TopicUserRegistered, h.HandleUserRegistered)
+//	consumer := kafka.NewConsumer(kafka.ConsumerConfig{Topics: mux.Topics(), ...}, mux.Handler(), kafka.DefaultMiddleware()...)
+type Mux struct {
+	routes map[string]Handler
+}
+
+func NewMux() *Mux {
+	return &Mux{routes: make(map[string]Handler)}
+}
+
+// Handle registers h for topic, replacing any previous handler.
+func (m *Mux) Handle(topic string, h Handler) {
+	m.routes[topic] = h
+}
+
+// Topics lists the registered topics in a stable order.
+func (m *Mux) Topics() []string {
+	topics := make([]string, 0, len(m.routes))
+	for t := range m.routes {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Handler dispatches on msg.Topic.
+func (m *Mux) Handler() Handler {
+	return func(ctx context.Context, msg *Message) error {
+		h, ok := m.routes[msg.Topic]
+		if !ok {
+			return Permanent(fmt.Errorf("kafka: no handler for topic %q", msg.Topic))
+		}
+		return h(ctx, msg)
+	}
+}
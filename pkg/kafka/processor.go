@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Processor applies the retry and dead-letter policy of a ConsumerConfig
+// to one message at a time. Consumer implementations (Kafka, memory) share
+// it so both behave the same.
+type Processor struct {
+	cfg     ConsumerConfig
+	handler Handler
+	dlq     Producer
+}
+
+// NewProcessor creates a Processor; dlq may be nil when DisableDLQ is set
+// (or set later, once the connection is up).
+func NewProcessor(cfg ConsumerConfig, handler Handler, dlq Producer) *Processor {
+	cfg.setDefaults()
+	return &Processor{cfg: cfg, handler: handler, dlq: dlq}
+}
+
+// Process runs the handler with bounded retries, then dead-letters.
+// A nil result means the offset may be committed.
+func (p *Processor) Process(ctx context.Context, msg *Message) error {
+	// Shutdown must not abort a handler midway (e.g. during its DB
+	// transaction); it only stops further retries.
+	handlerCtx := context.WithoutCancel(ctx)
+
+	backoff := p.cfg.RetryBackoff
+	var err error
+	for msg.Attempt = 1; ; msg.Attempt++ {
+		if err = p.handler(handlerCtx, msg); err == nil {
+			return nil
+		}
+		if IsPermanent(err) || msg.Attempt > p.cfg.MaxRetries {
+			break
+		}
+		if !sleep(ctx, backoff) {
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, p.cfg.MaxBackoff)
+	}
+
+	if p.cfg.DisableDLQ || p.dlq == nil {
+		log.Error().Err(err).
+			Str("action", "kafka_consume").
+			Str("status", "dropped").
+			Str("topic", msg.Topic).
+			Int32("partition", msg.Partition).
+			Int64("offset", msg.Offset).
+			Msg("Message failed permanently, dropping")
+		return nil
+	}
+	return p.deadLetter(handlerCtx, msg, err)
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
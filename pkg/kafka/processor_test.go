@@ -0,0 +1,166 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testMessage() *Message {
+	return &Message{
+		Topic:     "identity.user",
+		Partition: 2,
+		Offset:    41,
+		Key:       []byte("user-1"),
+		Value:     []byte(`{"id":"1"}`),
+		Headers:   map[string]string{"event_id": "e-1"},
+	}
+}
+
+// failing returns a handler that fails its first n calls with err and
+// counts every call.
+func failing(n int, err error, calls *int) Handler {
+	return func(context.Context, *Message) error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func testConfig() ConsumerConfig {
+	return ConsumerConfig{GroupID: "account", MaxRetries: 2, RetryBackoff: time.Millisecond}
+}
+
+func TestProcessorRetriesThenSucceeds(t *testing.T) {
+	dlq := NewFakeProducer()
+	var calls int
+	p := NewProcessor(testConfig(), failing(2, errors.New("db down"), &calls), dlq)
+
+	msg := testMessage()
+	if err := p.Process(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || msg.Attempt != 3 {
+		t.Fatalf("handler ran %d times, attempt %d", calls, msg.Attempt)
+	}
+	if got := dlq.Records(DLQTopic(msg.Topic)); len(got) != 0 {
+		t.Fatalf("dead-lettered %d messages", len(got))
+	}
+}
+
+func TestProcessorDeadLetters(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"after the last retry", errors.New("db down"), 3},
+		{"permanent error at once", Permanent(errors.New("bad payload")), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dlq := NewFakeProducer()
+			var calls int
+			p := NewProcessor(testConfig(), failing(10, tt.err, &calls), dlq)
+
+			msg := testMessage()
+			if err := p.Process(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+
+			recs := dlq.Records("identity.user.dlq")
+			if len(recs) != 1 {
+				t.Fatalf("dead-lettered %d messages, want 1", len(recs))
+			}
+			rec := recs[0]
+			if string(rec.Key) != "user-1" || string(rec.Value) != `{"id":"1"}` {
+				t.Fatalf("record = %s %s", rec.Key, rec.Value)
+			}
+			want := map[string]string{
+				"event_id":                 "e-1",
+				HeaderDLQOriginalTopic:     "identity.user",
+				HeaderDLQOriginalPartition: "2",
+				HeaderDLQOriginalOffset:    "41",
+				HeaderDLQConsumerGroup:     "account",
+				HeaderDLQError:             tt.err.Error(),
+				HeaderDLQAttempts:          strconv.Itoa(tt.wantCalls),
+			}
+			for k, v := range want {
+				if rec.Headers[k] != v {
+					t.Errorf("header %s = %q, want %q", k, rec.Headers[k], v)
+				}
+			}
+			if _, err := time.Parse(time.RFC3339Nano, rec.Headers[HeaderDLQFailedAt]); err != nil {
+				t.Errorf("header %s: %v", HeaderDLQFailedAt, err)
+			}
+			if got := stripDLQHeaders(rec.Headers); len(got) != 1 || got["event_id"] != "e-1" {
+				t.Errorf("stripped headers = %v", got)
+			}
+		})
+	}
+}
+
+func TestProcessorDropsWithoutDLQ(t *testing.T) {
+	dlq := NewFakeProducer()
+	cfg := testConfig()
+	cfg.DisableDLQ = true
+	var calls int
+	p := NewProcessor(cfg, failing(10, errors.New("db down"), &calls), dlq)
+
+	if err := p.Process(context.Background(), testMessage()); err != nil {
+		t.Fatalf("a dropped message must be committed, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("handler ran %d times, want 3", calls)
+	}
+	if got := dlq.Records("identity.user.dlq"); len(got) != 0 {
+		t.Fatalf("dead-lettered %d messages with the DLQ disabled", len(got))
+	}
+}
+
+func TestProcessorKeepsOffsetWhenDLQFails(t *testing.T) {
+	dlq := NewFakeProducer()
+	dlq.Err = errors.New("broker unavailable")
+	var calls int
+	p := NewProcessor(testConfig(), failing(10, Permanent(errors.New("bad payload")), &calls), dlq)
+
+	// An error leaves the offset uncommitted, so the message comes back
+	if err := p.Process(context.Background(), testMessage()); !errors.Is(err, dlq.Err) {
+		t.Fatalf("err = %v, want %v", err, dlq.Err)
+	}
+}
+
+func TestProcessorStopsRetryingOnShutdown(t *testing.T) {
+	dlq := NewFakeProducer()
+	cfg := testConfig()
+	cfg.RetryBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	p := NewProcessor(cfg, func(handlerCtx context.Context, _ *Message) error {
+		calls++
+		cancel()
+		// The running handler is not cut short by the shutdown
+		if handlerCtx.Err() != nil {
+			t.Error("handler context was cancelled")
+		}
+		return errors.New("db down")
+	}, dlq)
+
+	if err := p.Process(ctx, testMessage()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if got := dlq.Records("identity.user.dlq"); len(got) != 0 {
+		t.Fatalf("dead-lettered %d messages on shutdown", len(got))
+	}
+}
@@ -0,0 +1,278 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"bitka/pkg/events"
+	"bitka/pkg/logger"
+	"bitka/pkg/metrics"
+	"bitka/pkg/tracing"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Record is a message to produce.
+type Record struct {
+	Topic   string
+	Key     []byte // same key → same partition → ordered (use the user/aggregate ID)
+	Value   []byte
+	Headers map[string]string
+}
+
+// DeliveryFunc is called once per SendAsync record with the broker result.
+type DeliveryFunc func(rec *Record, partition int32, offset int64, err error)
+
+// Producer publishes records. Send blocks until the broker acknowledged
+// the write; SendAsync returns immediately and reports through the
+// DeliveryFunc of the config. Both inject the trace context from ctx.
+type Producer interface {
+	Send(ctx context.Context, rec *Record) error
+	SendAsync(ctx context.Context, rec *Record)
+	Close() error
+}
+
+// ProducerConfig configures NewProducer.
+type ProducerConfig struct {
+	Brokers []string
+
+	// OnDelivery receives async results (optional; failures are always
+	// logged and counted in metrics).
+	OnDelivery DeliveryFunc
+
+	// Async batching: flush when either limit is reached (0 = Sarama defaults).
+	FlushMessages  int
+	FlushFrequency time.Duration
+
+	// Validate, when set, checks every record before it is sent (see
+	// contract.Validator). Meant for development and staging: a record
+	// that violates the AsyncAPI contract fails instead of reaching consumers.
+	Validate func(topic string, value []byte) error
+}
+
+// saramaProducer is the Kafka implementation of Producer.
+type saramaProducer struct {
+	client     sarama.Client
+	sync       sarama.SyncProducer
+	async      sarama.AsyncProducer
+	onDelivery DeliveryFunc
+	validate   func(topic string, value []byte) error
+	wg         sync.WaitGroup
+
+	mu      sync.RWMutex // guards closed and sending.Add against Close
+	closed  bool
+	sending sync.WaitGroup // SendAsync calls still handing a record to Sarama
+}
+
+// pending travels with an async message through Sarama's Metadata.
+type pending struct {
+	ctx   context.Context
+	rec   *Record
+	span  trace.Span
+	start time.Time
+}
+
+// NewProducer creates an idempotent producer (acks=all, one in-flight
+// request per broker), so Sarama's own retries never duplicate or reorder
+// records within a partition.
+func NewProducer(cfg ProducerConfig) (Producer, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Idempotent = true
+	config.Net.MaxOpenRequests = 1
+	config.Producer.Retry.Max = 5
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	if cfg.FlushMessages > 0 {
+		config.Producer.Flush.Messages = cfg.FlushMessages
+	}
+	if cfg.FlushFrequency > 0 {
+		config.Producer.Flush.Frequency = cfg.FlushFrequency
+	}
+
+	client, err := sarama.NewClient(cfg.Brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	syncProducer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	asyncProducer, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		syncProducer.Close()
+		client.Close()
+		return nil, err
+	}
+
+	p := &saramaProducer{
+		client:     client,
+		sync:       syncProducer,
+		async:      asyncProducer,
+		onDelivery: cfg.OnDelivery,
+		validate:   cfg.Validate,
+	}
+	p.wg.Add(2)
+	go p.drainSuccesses()
+	go p.drainErrors()
+	return p, nil
+}
+
+func (p *saramaProducer) Send(ctx context.Context, rec *Record) error {
+	if err := p.check(rec); err != nil {
+		return err
+	}
+	msg := toSarama(rec)
+	_, span := tracing.StartProducerSpan(ctx, msg)
+	start := time.Now()
+
+	partition, offset, err := p.sync.SendMessage(msg)
+
+	tracing.EndProducerSpan(span, partition, offset, err)
+	metrics.ObserveProduce(rec.Topic, err)
+	metrics.ObserveProduceDuration(rec.Topic, time.Since(start))
+	return err
+}
+
+func (p *saramaProducer) SendAsync(ctx context.Context, rec *Record) {
+	err := p.check(rec)
+	if err == nil {
+		err = p.beginSend()
+	}
+	if err != nil {
+		metrics.ObserveProduce(rec.Topic, err)
+		if p.onDelivery != nil {
+			p.onDelivery(rec, -1, -1, err)
+		}
+		return
+	}
+	defer p.sending.Done()
+
+	msg := toSarama(rec)
+	_, span := tracing.StartProducerSpan(ctx, msg)
+	msg.Metadata = &pending{ctx: ctx, rec: rec, span: span, start: time.Now()}
+
+	// No lock is held here: a full input channel must not block Close
+	select {
+	case p.async.Input() <- msg:
+	case <-ctx.Done():
+		p.delivered(msg, ctx.Err())
+	}
+}
+
+// beginSend registers an in-flight SendAsync unless the producer is closed.
+// Close waits for registered sends before closing Sarama's input channel.
+func (p *saramaProducer) beginSend() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+	p.sending.Add(1)
+	return nil
+}
+
+func (p *saramaProducer) drainSuccesses() {
+	defer p.wg.Done()
+	for msg := range p.async.Successes() {
+		p.delivered(msg, nil)
+	}
+}
+
+func (p *saramaProducer) drainErrors() {
+	defer p.wg.Done()
+	for perr := range p.async.Errors() {
+		p.delivered(perr.Msg, perr.Err)
+	}
+}
+
+func (p *saramaProducer) delivered(msg *sarama.ProducerMessage, err error) {
+	pm, ok := msg.Metadata.(*pending)
+	if !ok {
+		return
+	}
+	tracing.EndProducerSpan(pm.span, msg.Partition, msg.Offset, err)
+	metrics.ObserveProduce(pm.rec.Topic, err)
+	metrics.ObserveProduceDuration(pm.rec.Topic, time.Since(pm.start))
+
+	if err != nil {
+		logger.From(pm.ctx).Error().Err(err).
+			Str("action", "kafka_produce").
+			Str("status", "failed").
+			Str("topic", pm.rec.Topic).
+			Msg("Async publish failed")
+	}
+	if p.onDelivery != nil {
+		p.onDelivery(pm.rec, msg.Partition, msg.Offset, err)
+	}
+}
+
+func (p *saramaProducer) check(rec *Record) error {
+	if p.validate == nil {
+		return nil
+	}
+	if err := p.validate(rec.Topic, rec.Value); err != nil {
+		return fmt.Errorf("%w: %w", ErrContractViolation, err)
+	}
+	return nil
+}
+
+// Close flushes buffered async records (their callbacks still fire),
+// then releases the broker connections.
+func (p *saramaProducer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.sending.Wait()
+	p.async.AsyncClose()
+	p.wg.Wait()
+	return errors.Join(p.sync.Close(), p.client.Close())
+}
+
+func toSarama(rec *Record) *sarama.ProducerMessage {
+	msg := &sarama.ProducerMessage{
+		Topic:   rec.Topic,
+		Value:   sarama.ByteEncoder(rec.Value),
+		Headers: toRecordHeaders(rec.Headers),
+	}
+	if len(rec.Key) > 0 {
+		msg.Key = sarama.ByteEncoder(rec.Key)
+	}
+	return msg
+}
+
+// EventRecord wraps e in an envelope and builds the record with the
+// event_id / event_type / schema_version headers.
+func EventRecord(topic, key string, e events.Event) (*Record, events.Envelope, error) {
+	raw, env, err := events.Encode(e)
+	if err != nil {
+		return nil, env, err
+	}
+	return &Record{
+		Topic:   topic,
+		Key:     []byte(key),
+		Value:   raw,
+		Headers: env.Headers(),
+	}, env, nil
+}
+
+// PublishEvent sends e synchronously through p.
+func PublishEvent(ctx context.Context, p Producer, topic, key string, e events.Event) (events.Envelope, error) {
+	rec, env, err := EventRecord(topic, key, e)
+	if err != nil {
+		return env, err
+	}
+	return env, p.Send(ctx, rec)
+}
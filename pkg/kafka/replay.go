@@ -0,0 +1,172 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog/log"
+)
+
+// ReplayConfig configures a DLQ replay.
+type ReplayConfig struct {
+	Brokers  []string
+	DLQTopic string // e.g. "user-registered.dlq"
+	// Target overrides the destination; by default each message goes back
+	// to its dlq.original_topic.
+	Target string
+	Limit  int  // stop after this many messages (0 = all)
+	DryRun bool // log what would be replayed, commit nothing
+}
+
+// ReplayResult summarises a replay.
+type ReplayResult struct {
+	Replayed int
+	Skipped  int // no original topic and no Target
+}
+
+// Replay republishes dead-lettered messages that arrived before the call,
+// with the dlq.* headers removed. Progress is committed under the
+// "<dlq>.replay" group, so running it again only picks up new failures.
+func Replay(ctx context.Context, cfg ReplayConfig) (ReplayResult, error) {
+	var res ReplayResult
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+
+	client, err := sarama.NewClient(cfg.Brokers, config)
+	if err != nil {
+		return res, err
+	}
+	defer client.Close()
+
+	partitions, err := client.Partitions(cfg.DLQTopic)
+	if err != nil {
+		return res, err
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return res, err
+	}
+	defer consumer.Close()
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return res, err
+	}
+	defer producer.Close()
+
+	offsets, err := sarama.NewOffsetManagerFromClient(cfg.DLQTopic+".replay", client)
+	if err != nil {
+		return res, err
+	}
+	defer offsets.Close()
+
+	for _, p := range partitions {
+		if err := replayPartition(ctx, cfg, client, consumer, producer, offsets, p, &res); err != nil {
+			return res, err
+		}
+		if cfg.Limit > 0 && res.Replayed >= cfg.Limit {
+			break
+		}
+	}
+
+	if !cfg.DryRun {
+		offsets.Commit()
+	}
+	return res, nil
+}
+
+func replayPartition(
+	ctx context.Context,
+	cfg ReplayConfig,
+	client sarama.Client,
+	consumer sarama.Consumer,
+	producer sarama.SyncProducer,
+	offsets sarama.OffsetManager,
+	partition int32,
+	res *ReplayResult,
+) error {
+	pom, err := offsets.ManagePartition(cfg.DLQTopic, partition)
+	if err != nil {
+		return err
+	}
+	defer pom.Close()
+
+	// Snapshot the end so messages dead-lettered during the replay wait for the next run
+	end, err := client.GetOffset(cfg.DLQTopic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return err
+	}
+	start, _ := pom.NextOffset()
+	if start < 0 {
+		if start, err = client.GetOffset(cfg.DLQTopic, partition, sarama.OffsetOldest); err != nil {
+			return err
+		}
+	}
+	if start >= end {
+		return nil
+	}
+
+	pc, err := consumer.ConsumePartition(cfg.DLQTopic, partition, start)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case cerr := <-pc.Errors():
+			return cerr
+		case raw := <-pc.Messages():
+			msg := fromSarama(raw)
+			target := cfg.Target
+			if target == "" {
+				target = msg.Header(HeaderDLQOriginalTopic)
+			}
+
+			l := log.With().
+				Str("action", "kafka_dlq_replay").
+				Int32("partition", partition).
+				Int64("offset", msg.Offset).
+				Str("target", target).
+				Str("error", msg.Header(HeaderDLQError)).
+				Logger()
+
+			switch {
+			case target == "":
+				res.Skipped++
+				if !cfg.DryRun {
+					pom.MarkOffset(msg.Offset+1, "")
+				}
+				l.Warn().Str("status", "skipped").Msg("No original topic header, skipping")
+			case cfg.DryRun:
+				res.Replayed++
+				l.Info().Str("status", "dry_run").Msg("Would replay message")
+			default:
+				_, _, err := producer.SendMessage(&sarama.ProducerMessage{
+					Topic:   target,
+					Key:     sarama.ByteEncoder(msg.Key),
+					Value:   sarama.ByteEncoder(msg.Value),
+					Headers: toRecordHeaders(stripDLQHeaders(msg.Headers)),
+				})
+				if err != nil {
+					return fmt.Errorf("replay offset %d: %w", msg.Offset, err)
+				}
+				res.Replayed++
+				pom.MarkOffset(msg.Offset+1, "")
+				l.Info().Str("status", "success").Msg("Replayed message")
+			}
+
+			if msg.Offset+1 >= end || (cfg.Limit > 0 && res.Replayed >= cfg.Limit) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package transport builds producers and consumers for the configured
+// KAFKA_MODE: "kafka" (default) talks to the brokers, "memory" uses the
+// process-wide in-memory broker (local development, tests).
+package transport
+
+import (
+	"errors"
+
+	"bitka/pkg/config"
+	"bitka/pkg/kafka"
+	"bitka/pkg/kafka/memory"
+)
+
+// ErrMemoryInProduction refuses to start a production service whose events
+// would vanish with the process.
+var ErrMemoryInProduction = errors.New("transport: KAFKA_MODE=memory is not allowed in production")
+
+// ErrMemoryOutOfProcess refuses memory mode for a process whose consumers
+// all live in other processes: everything it publishes would be lost.
+var ErrMemoryOutOfProcess = errors.New("transport: KAFKA_MODE=memory only reaches consumers in the same process")
+
+// Validate rejects settings that must not reach production.
+func Validate(cfg config.Kafka, production bool) error {
+	if production && cfg.InMemory() {
+		return ErrMemoryInProduction
+	}
+	return nil
+}
+
+// ValidatePublisher is Validate for a process that only publishes for
+// other services (the outbox relay), where memory mode is never useful.
+func ValidatePublisher(cfg config.Kafka) error {
+	if cfg.InMemory() {
+		return ErrMemoryOutOfProcess
+	}
+	return nil
+}
+
+// NewProducer returns a producer for cfg.Mode. Brokers are taken from cfg.
+func NewProducer(cfg config.Kafka, pcfg kafka.ProducerConfig) (kafka.Producer, error) {
+	if cfg.InMemory() {
+		return memory.Default().NewProducer(pcfg), nil
+	}
+	pcfg.Brokers = cfg.Brokers
+	return kafka.NewProducer(pcfg)
+}
+
+// NewConsumer returns a consumer for cfg.Mode. Brokers are taken from cfg.
+func NewConsumer(cfg config.Kafka, ccfg kafka.ConsumerConfig, handler kafka.Handler, mws ...kafka.Middleware) kafka.Consumer {
+	if cfg.InMemory() {
+		return memory.Default().NewConsumer(ccfg, handler, mws...)
+	}
+	ccfg.Brokers = cfg.Brokers
+	return kafka.NewConsumer(ccfg, handler, mws...)
+}
@@ -3,6 +3,8 @@ package logger
 import (
 	"context"
 
+	"bitka/pkg/tracing"
+
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -41,3 +43,21 @@ func TraceIDFrom(ctx context.Context) string {
 	}
 	return ""
 }
+
+// WithSpan returns a context carrying a logger tagged with the trace_id/span_id
+// of the active OTel span. Use it in background workers (e.g. Kafka consumers)
+// where no HTTP middleware has prepared the context.
+func WithSpan(ctx context.Context) context.Context {
+	traceID := tracing.TraceIDFrom(ctx)
+	if traceID == "" {
+		return ctx
+	}
+
+	l := From(ctx).With().
+		Str("trace_id", traceID).
+		Str("span_id", tracing.SpanIDFrom(ctx)).
+		Logger()
+
+	ctx = WithContext(ctx, &l)
+	return WithTraceID(ctx, traceID)
+}
@@ -3,17 +3,36 @@ package logger
 import (
 	"time"
 
+	"bitka/pkg/tracing"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// successSampler is set by Init from Config.SuccessSampleEvery (nil = log all).
+var successSampler zerolog.Sampler
+
+func successLogger() *zerolog.Logger {
+	if successSampler == nil {
+		return &log.Logger
+	}
+	l := log.Logger.Sample(successSampler)
+	return &l
+}
+
 func FiberMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
 
-		// Try to get existing ID (from Traefik), otherwise generate new one
-		traceID := c.Get("X-Request-ID")
+		// Prefer the OTel trace ID (set by tracing.FiberMiddleware) so logs link to spans.
+		// Otherwise reuse the existing ID (from Traefik), or generate a new one
+		traceID := tracing.TraceIDFrom(c.UserContext())
+		spanID := tracing.SpanIDFrom(c.UserContext())
+		if traceID == "" {
+			traceID = c.Get("X-Request-ID")
+		}
 		if traceID == "" {
 			traceID = uuid.NewString()
 		}
@@ -22,7 +41,7 @@ func FiberMiddleware() fiber.Handler {
 		c.Set("X-Request-ID", traceID)
 
 		// Create Context-Aware Logger
-		reqLogger := log.With().Str("trace_id", traceID).Logger()
+		reqLogger := log.With().Str("trace_id", traceID).Str("span_id", spanID).Logger()
 
 		// INJECT INTO GO CONTEXT
 		// Create a new context derived from the request's context
@@ -47,8 +66,9 @@ func FiberMiddleware() fiber.Handler {
 		}
 
 		// Determine Logic Status (success/error) and Log Level
+		// Successful requests are sampled; failures are always logged
 		statusStr := "success"
-		event := log.Info()
+		event := successLogger().Info()
 
 		if httpCode >= 500 {
 			event = log.Error()
@@ -61,6 +81,7 @@ func FiberMiddleware() fiber.Handler {
 		// Write the Log
 		event.
 			Str("trace_id", traceID).
+			Str("span_id", spanID).
 			Str("action", "http_request").
 			Str("status", statusStr). // "success" or "error"
 
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces any value the Redactor decides to hide.
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeys are field names whose value is always hidden, whatever it looks like.
+// Matching is case-insensitive and ignores "-" / "_" ("Refresh-Token" == "refresh_token").
+var sensitiveKeys = map[string]struct{}{
+	"password":      {},
+	"passwd":        {},
+	"pass":          {},
+	"secret":        {},
+	"token":         {},
+	"accesstoken":   {},
+	"refreshtoken":  {},
+	"idtoken":       {},
+	"apikey":        {},
+	"authorization": {},
+	"cookie":        {},
+	"setcookie":     {},
+	"privatekey":    {},
+	"otp":           {},
+}
+
+var (
+	// Bearer/Basic credentials inside free text, e.g. error messages
+	authHeaderRe = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	// Compact JWS/JWT: three base64url segments, header starts with {"  => eyJ
+	jwtRe = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// Emails are masked, not removed, so support can still recognise the user: j***@bitka.io
+	emailRe = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+)
+
+// Redactor is an io.Writer filter that scrubs PII and credentials from
+// zerolog's JSON output before it reaches the real sink.
+//
+// zerolog hooks cannot read fields that were already added to an event,
+// so filtering the encoded line is the only place every field is visible.
+type Redactor struct {
+	out io.Writer
+}
+
+// NewRedactor wraps out. Lines that are not JSON are scrubbed as plain text.
+func NewRedactor(out io.Writer) *Redactor {
+	return &Redactor{out: out}
+}
+
+func (r *Redactor) Write(p []byte) (int, error) {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber() // keep numbers byte-for-byte (no float64 rounding)
+
+	if err := dec.Decode(&fields); err != nil {
+		if _, err := r.out.Write([]byte(redactString(string(p)))); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	redactMap(fields)
+
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.out.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	// Report the original length: zerolog treats a short write as an error
+	return len(p), nil
+}
+
+func redactMap(m map[string]any) {
+	for k, v := range m {
+		if isSensitiveKey(k) {
+			m[k] = RedactedValue
+			continue
+		}
+		m[k] = redactValue(v)
+	}
+}
+
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case string:
+		return redactString(val)
+	case map[string]any:
+		redactMap(val)
+		return val
+	case []any:
+		for i := range val {
+			val[i] = redactValue(val[i])
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func redactString(s string) string {
+	// Cheap pre-checks: most log values contain none of these
+	if strings.ContainsAny(s, "@") {
+		s = emailRe.ReplaceAllString(s, "$1***@$2")
+	}
+	if strings.Contains(s, "eyJ") {
+		s = jwtRe.ReplaceAllString(s, RedactedValue)
+	}
+	if strings.ContainsAny(s, "bB") {
+		s = authHeaderRe.ReplaceAllString(s, "$1 "+RedactedValue)
+	}
+	return s
+}
+
+func isSensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	k = strings.NewReplacer("_", "", "-", "").Replace(k)
+	_, ok := sensitiveKeys[k]
+	return ok
+}
@@ -16,6 +16,10 @@ type Config struct {
 	LogLevel    string // "debug", "info", "warn", "error"
 	ServiceName string // e.g., "auth-service"
 	InstanceID  string // e.g., "auth-pod-xyz" or container ID
+
+	// SuccessSampleEvery keeps 1 of every N successful request logs.
+	// Warnings and errors are never sampled. 0 or 1 logs everything.
+	SuccessSampleEvery uint32
 }
 
 // Init initializes the global logger
@@ -36,35 +40,46 @@ func Init(cfg Config) {
 	zerolog.SetGlobalLevel(level)
 
 	// 2. Configure Output (Dev vs Prod)
+	// Every line passes through the Redactor (emails, tokens, passwords)
+	// before reaching the sink, in both modes.
 	if cfg.Environment == "dev" || cfg.Environment == "development" {
 		// Pretty printing for humans
-		log.Logger = log.Output(zerolog.ConsoleWriter{
+		log.Logger = log.Output(NewRedactor(zerolog.ConsoleWriter{
 			Out:        os.Stderr,
 			TimeFormat: time.RFC3339,
-		})
+		}))
 	} else {
 		// JSON for machines (Default)
 		// Unix timestamps are faster and easier for log aggregators
 		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-		log.Logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+		log.Logger = zerolog.New(NewRedactor(os.Stderr)).With().Timestamp().Logger()
+	}
+
+	// 3. Sampling for high-volume success paths
+	successSampler = nil
+	if cfg.SuccessSampleEvery > 1 {
+		successSampler = &zerolog.BasicSampler{N: cfg.SuccessSampleEvery}
 	}
 
-	// 3. Add Service Context (Crucial for microservices!)
+	// 4. Add Service Context (Crucial for microservices!)
 	// We add InstanceID so we know WHICH replica generated the log.
 	log.Logger = log.Logger.With().
 		Str("service", cfg.ServiceName).
 		Str("instance_id", cfg.InstanceID).
 		Logger()
-}
 
-// logConfigSafe prints the config without revealing secrets
-func LogConfigSafe(cfg config.Config) {
-	// Redact sensitive fields
-	cfg.DBPass = "[REDACTED]"
-
-	// You can add other redactions here if needed (e.g. API Keys)
+	// config.Base falls back to "unknown" when neither INSTANCE_ID nor HOSTNAME is set
+	if cfg.InstanceID == "" || cfg.InstanceID == config.UnknownInstance {
+		log.Warn().
+			Str("action", "logger_init").
+			Msg("INSTANCE_ID and HOSTNAME are unset; replicas cannot be told apart in logs and traces")
+	}
+}
 
+// LogConfigSafe prints the config without revealing secrets.
+// Fields tagged secret:"true" are redacted by config.Redact.
+func LogConfigSafe(cfg any) {
 	log.Info().
-		Interface("config", cfg).
+		Interface("config", config.Redact(cfg)).
 		Msg("Loaded Configuration")
 }
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	kafkaProduced = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "messages_produced_total",
+		Help:      "Messages successfully published, by topic.",
+	}, []string{"topic"})
+
+	kafkaProduceErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "produce_errors_total",
+		Help:      "Messages that failed to publish, by topic.",
+	}, []string{"topic"})
+
+	kafkaProduceDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "produce_duration_seconds",
+		Help:      "Time from send until the broker acknowledged, by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	kafkaConsumed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "messages_consumed_total",
+		Help:      "Messages handled successfully, by topic.",
+	}, []string{"topic"})
+
+	kafkaConsumeErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "consume_errors_total",
+		Help:      "Messages whose handler returned an error, by topic.",
+	}, []string{"topic"})
+
+	kafkaConsumerLag = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "consumer_lag",
+		Help:      "Messages between the last handled offset and the partition high watermark.",
+	}, []string{"topic", "partition"})
+)
+
+// ObserveProduce records the outcome of one publish.
+func ObserveProduce(topic string, err error) {
+	if err != nil {
+		kafkaProduceErrors.WithLabelValues(topic).Inc()
+		return
+	}
+	kafkaProduced.WithLabelValues(topic).Inc()
+}
+
+// ObserveProduceDuration records how long a publish took to be acknowledged.
+func ObserveProduceDuration(topic string, d time.Duration) {
+	kafkaProduceDuration.WithLabelValues(topic).Observe(d.Seconds())
+}
+
+// ObserveConsume records the outcome of one handled message.
+func ObserveConsume(topic string, err error) {
+	if err != nil {
+		kafkaConsumeErrors.WithLabelValues(topic).Inc()
+		return
+	}
+	kafkaConsumed.WithLabelValues(topic).Inc()
+}
+
+// SetConsumerLag records lag for a partition.
+// highWaterMark is the offset of the NEXT message to be produced,
+// so a fully caught-up consumer at offset hwm-1 reports 0.
+func SetConsumerLag(topic string, partition int32, highWaterMark, offset int64) {
+	lag := highWaterMark - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	kafkaConsumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+var kafkaDeadLettered = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Subsystem: "kafka",
+	Name:      "messages_dead_lettered_total",
+	Help:      "Messages moved to the dead-letter topic after exhausting retries, by source topic.",
+}, []string{"topic"})
+
+// IncDeadLettered counts a message sent to the dead-letter topic.
+func IncDeadLettered(topic string) {
+	kafkaDeadLettered.WithLabelValues(topic).Inc()
+}
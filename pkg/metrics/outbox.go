@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	outboxPublished = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "messages_relayed_total",
+		Help:      "Outbox messages handed to Kafka, by topic.",
+	}, []string{"topic"})
+
+	outboxPublishErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "relay_errors_total",
+		Help:      "Failed relay attempts (the message is retried), by topic.",
+	}, []string{"topic"})
+
+	outboxDead = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "messages_dead_total",
+		Help:      "Messages given up on after the maximum number of attempts, by topic.",
+	}, []string{"topic"})
+
+	outboxPending = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "pending_messages",
+		Help:      "Messages waiting to be relayed.",
+	})
+
+	outboxOldest = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "outbox",
+		Name:      "oldest_pending_age_seconds",
+		Help:      "Age of the oldest message waiting to be relayed (0 when empty).",
+	})
+)
+
+// ObserveOutboxRelay records the outcome of one relay attempt.
+func ObserveOutboxRelay(topic string, err error) {
+	if err != nil {
+		outboxPublishErrors.WithLabelValues(topic).Inc()
+		return
+	}
+	outboxPublished.WithLabelValues(topic).Inc()
+}
+
+// IncOutboxDead counts a message the relay stopped retrying.
+func IncOutboxDead(topic string) {
+	outboxDead.WithLabelValues(topic).Inc()
+}
+
+// SetOutboxBacklog records the size and age of the unsent backlog.
+func SetOutboxBacklog(pending int64, oldest time.Duration) {
+	outboxPending.Set(float64(pending))
+	outboxOldest.Set(oldest.Seconds())
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Drift kinds found by a reconciliation run
+const (
+	DriftMissing    = "missing"    // registered users without a profile
+	DriftOrphaned   = "orphaned"   // profiles of users auth does not know
+	DriftMismatched = "mismatched" // email or username differ between services
+)
+
+var (
+	reconcileDrift = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "reconcile",
+		Name:      "drift_records",
+		Help:      "Records out of sync at the end of the last reconciliation run, by kind.",
+	}, []string{"kind"})
+
+	reconcileBackfilled = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "reconcile",
+		Name:      "backfilled_total",
+		Help:      "Missing records created by reconciliation.",
+	})
+
+	reconcileRuns = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "reconcile",
+		Name:      "runs_total",
+		Help:      "Reconciliation runs, by status (success, error).",
+	}, []string{"status"})
+
+	reconcileLastSuccess = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "reconcile",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time the last successful reconciliation run finished.",
+	})
+)
+
+// ObserveReconcile records the outcome of one reconciliation run. drift
+// maps each kind to the records still out of sync; it is ignored on error.
+func ObserveReconcile(drift map[string]int, backfilled int, err error) {
+	if err != nil {
+		reconcileRuns.WithLabelValues("error").Inc()
+		return
+	}
+	reconcileRuns.WithLabelValues("success").Inc()
+	for kind, n := range drift {
+		reconcileDrift.WithLabelValues(kind).Set(float64(n))
+	}
+	reconcileBackfilled.Add(float64(backfilled))
+	reconcileLastSuccess.Set(float64(time.Now().Unix()))
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var tokenValidationFailures = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Subsystem: "auth",
+	Name:      "token_validation_failures_total",
+	Help:      "Rejected bearer tokens, by reason.",
+}, []string{"reason"})
+
+// Reasons used by middleware.Protected
+const (
+	TokenMissingHeader  = "missing_header"
+	TokenInvalidFormat  = "invalid_format"
+	TokenInvalid        = "invalid"
+	TokenMissingSubject = "missing_subject"
+)
+
+// IncTokenValidationFailure counts a rejected token.
+func IncTokenValidationFailure(reason string) {
+	tokenValidationFailures.WithLabelValues(reason).Inc()
+}
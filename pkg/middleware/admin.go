@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"bitka/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminOnly allows only the listed user IDs. Mount it after Protected,
+// which stores the token subject as "user_id".
+func AdminOnly(adminIDs []string) fiber.Handler {
+	admins := make(map[string]struct{}, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = struct{}{}
+	}
+
+	return func(c *fiber.Ctx) error {
+		sub, _ := c.Locals("user_id").(string)
+		if _, ok := admins[sub]; !ok || sub == "" {
+			return response.Error(c, fiber.StatusForbidden, "Admin access required")
+		}
+		return c.Next()
+	}
+}
@@ -3,23 +3,28 @@ package middleware
 import (
 	"strings"
 
+	"bitka/pkg/metrics"
 	"bitka/pkg/response"
 	"bitka/pkg/token"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// Protected returns a middleware that verifies the JWT.
-func Protected(v *token.Validator) fiber.Handler {
+// Protected returns a middleware that verifies the JWT. Tokens acting as
+// a sub-account also set "sub_account_id" and "scope", which ParentOnly
+// and RequireScope check.
+func Protected(v token.Verifier) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// 1. Get Token from Header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
+			metrics.IncTokenValidationFailure(metrics.TokenMissingHeader)
 			return response.Error(c, fiber.StatusUnauthorized, "Missing Authorization header")
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
+			metrics.IncTokenValidationFailure(metrics.TokenInvalidFormat)
 			return response.Error(c, fiber.StatusUnauthorized, "Invalid Authorization format")
 		}
 		tokenStr := parts[1]
@@ -28,6 +33,7 @@ func Protected(v *token.Validator) fiber.Handler {
 		// Use UserContext to ensure we respect cancellations
 		parsedToken, err := v.Validate(c.UserContext(), tokenStr)
 		if err != nil {
+			metrics.IncTokenValidationFailure(metrics.TokenInvalid)
 			return response.Error(c, fiber.StatusUnauthorized, err.Error())
 		}
 
@@ -35,12 +41,24 @@ func Protected(v *token.Validator) fiber.Handler {
 		// We store the Subject (User ID) for the handler to use
 		sub, ok := parsedToken.Subject()
 		if !ok {
+			metrics.IncTokenValidationFailure(metrics.TokenMissingSubject)
 			return response.Error(c, fiber.StatusUnauthorized, "Invalid token: missing subject")
 		}
 
 		c.Locals("user_id", sub)
 		c.Locals("claims", parsedToken) // Store full token if needed
+		if subAccount, ok := token.SubAccountOf(parsedToken); ok {
+			c.Locals("sub_account_id", subAccount)
+			c.Locals("scope", token.ScopeOf(parsedToken))
+		}
 
 		return c.Next()
 	}
 }
+
+// SubAccount returns the sub-account the request's token acts as, or ""
+// when it acts as the user.
+func SubAccount(c *fiber.Ctx) string {
+	id, _ := c.Locals("sub_account_id").(string)
+	return id
+}
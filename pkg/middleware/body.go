@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"strings"
+
+	"bitka/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies larger than limit with 413, except on
+// the routes listed in exempt as "METHOD /path" (e.g. "POST /api/v1/kyc/documents").
+//
+// Fiber's own BodyLimit is per app, so an app serving uploads sets it to
+// the upload size and uses this to keep every other route at limit.
+func BodyLimit(limit int, exempt ...string) fiber.Handler {
+	skip := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		skip[strings.ToLower(route)] = true
+	}
+	return func(c *fiber.Ctx) error {
+		route := c.Method() + " " + strings.TrimSuffix(c.Path(), "/")
+		if skip[strings.ToLower(route)] {
+			return c.Next()
+		}
+		size := c.Request().Header.ContentLength()
+		if size < 0 { // chunked: the body has been read already
+			size = len(c.Request().Body())
+		}
+		if size > limit {
+			return response.Error(c, fiber.StatusRequestEntityTooLarge, "Request body too large")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New()
+	app.Use(BodyLimit(8, "POST /upload"))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Post("/upload", ok)
+	app.Post("/json", ok)
+	app.Put("/upload", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"small body", fiber.MethodPost, "/json", "12345678", fiber.StatusNoContent},
+		{"large body", fiber.MethodPost, "/json", "123456789", fiber.StatusRequestEntityTooLarge},
+		{"exempt route", fiber.MethodPost, "/upload", strings.Repeat("x", 64), fiber.StatusNoContent},
+		{"exempt route, trailing slash", fiber.MethodPost, "/upload/", strings.Repeat("x", 64), fiber.StatusNoContent},
+		{"exempt path, other method", fiber.MethodPut, "/upload", strings.Repeat("x", 64), fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"bitka/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// InternalTokenHeader carries the shared secret on service-to-service calls.
+const InternalTokenHeader = "X-Internal-Token"
+
+// InternalOnly admits requests carrying the shared internal API token
+// (INTERNAL_API_TOKEN). The /internal routes are not meant to be exposed
+// by the gateway; the token guards against anything else on the network.
+// An empty token rejects every request.
+func InternalOnly(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		got := c.Get(InternalTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return response.Error(c, fiber.StatusForbidden, "Internal access required")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"slices"
+
+	"bitka/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParentOnly rejects tokens acting as a sub-account, for routes that
+// manage the user themselves (profile, privacy, KYC, sub-accounts, admin).
+// Mount it after Protected.
+func ParentOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if SubAccount(c) != "" {
+			return response.Error(c, fiber.StatusForbidden, "Not allowed while acting as a sub-account")
+		}
+		return c.Next()
+	}
+}
+
+// RequireScope admits tokens acting as the user, and sub-account tokens
+// whose scope holds every one of permissions. Mount it after Protected.
+func RequireScope(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if SubAccount(c) == "" {
+			return c.Next()
+		}
+		scope, _ := c.Locals("scope").([]string)
+		for _, p := range permissions {
+			if !slices.Contains(scope, p) {
+				return response.Error(c, fiber.StatusForbidden, "Token scope lacks the "+p+" permission")
+			}
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestScopeGuards(t *testing.T) {
+	// as stands in for Protected: it sets the locals a token would
+	as := func(subAccount string, scope ...string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", "user-1")
+			if subAccount != "" {
+				c.Locals("sub_account_id", subAccount)
+				c.Locals("scope", scope)
+			}
+			return c.Next()
+		}
+	}
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+
+	tests := []struct {
+		name  string
+		token fiber.Handler
+		guard fiber.Handler
+		want  int
+	}{
+		{"parent only, user token", as(""), ParentOnly(), fiber.StatusNoContent},
+		{"parent only, sub-account token", as("sub-1", "read", "trade"), ParentOnly(), fiber.StatusForbidden},
+		{"scope, user token", as(""), RequireScope("trade"), fiber.StatusNoContent},
+		{"scope, granted", as("sub-1", "read", "trade"), RequireScope("read", "trade"), fiber.StatusNoContent},
+		{"scope, one missing", as("sub-1", "read"), RequireScope("read", "trade"), fiber.StatusForbidden},
+		{"scope, empty scope", as("sub-1"), RequireScope("read"), fiber.StatusForbidden},
+		{"scope, nothing required", as("sub-1"), RequireScope(), fiber.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", tt.token, tt.guard, ok)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
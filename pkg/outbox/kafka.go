@@ -0,0 +1,33 @@
+package outbox
+
+import (
+	"context"
+
+	"bitka/pkg/kafka"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// kafkaPublisher relays outbox rows through the shared producer.
+type kafkaPublisher struct {
+	producer kafka.Producer
+}
+
+// KafkaPublisher adapts p for the Relay. Rows are keyed by aggregate ID,
+// so one aggregate always lands on the same partition and keeps its order.
+func KafkaPublisher(p kafka.Producer) Publisher {
+	return kafkaPublisher{producer: p}
+}
+
+func (k kafkaPublisher) Publish(ctx context.Context, msg *Message) error {
+	// Continue the trace of the request that wrote the row
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Headers))
+
+	return k.producer.Send(ctx, &kafka.Record{
+		Topic:   msg.Topic,
+		Key:     []byte(msg.AggregateID),
+		Value:   msg.Payload,
+		Headers: msg.Headers,
+	})
+}
@@ -0,0 +1,56 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Listen wakes the relay on every NOTIFY sent by Enqueue, reconnecting
+// with backoff when the connection drops. Polling keeps the relay correct
+// without it; LISTEN only cuts the latency to near zero.
+func Listen(ctx context.Context, dsn string, r *Relay) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := listen(ctx, dsn, r)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().
+			Err(err).
+			Str("action", "outbox_listen").
+			Str("status", "reconnecting").
+			Dur("backoff", backoff).
+			Msg("Outbox LISTEN connection lost")
+
+		select {
+		case <-time.After(backoff):
+			backoff = min(backoff*2, 30*time.Second)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, r *Relay) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{NotifyChannel}.Sanitize()); err != nil {
+		return err
+	}
+	// Catch up on anything committed while we were disconnected
+	r.Wake()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		r.Wake()
+	}
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers are Kafka headers stored alongside the message (jsonb).
+type Headers map[string]string
+
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *Headers) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case nil:
+		*h = Headers{}
+		return nil
+	default:
+		return errors.New("outbox: unsupported headers type")
+	}
+	return json.Unmarshal(raw, h)
+}
+
+// Message is one row of the outbox table.
+//
+// Rows are written in the same transaction as the domain change and
+// relayed to Kafka by the outbox service in ID order per aggregate.
+type Message struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement"` // global order
+	EventID       uuid.UUID `gorm:"type:uuid;not null"`       // consumer dedup key
+	AggregateType string    `gorm:"not null"`                 // e.g. "user"
+	AggregateID   string    `gorm:"not null"`                 // ordering + Kafka key
+	Topic         string    `gorm:"not null"`
+	EventType     string    `gorm:"not null"`
+	Payload       []byte    `gorm:"type:jsonb;not null"`
+	Headers       Headers   `gorm:"type:jsonb"`
+	CreatedAt     time.Time
+
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	FailedAt      *time.Time // gave up after MaxAttempts; needs an operator
+}
+
+// TableName pins the table created by each service's migration.
+func (Message) TableName() string { return TableName }
+
+// TableName is the outbox table name used by services and the relay.
+const TableName = "outbox_messages"
+
+// NotifyChannel is the Postgres channel signalled on every enqueue,
+// letting the relay wake up immediately instead of waiting for the next poll.
+const NotifyChannel = "outbox_messages"
+
+// Standard header names set on every relayed Kafka message.
+const (
+	HeaderEventID   = "event_id"
+	HeaderEventType = "event_type"
+)
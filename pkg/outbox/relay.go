@@ -0,0 +1,247 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitka/pkg/metrics"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Publisher hands one message to the broker. It must only return nil once
+// the broker acknowledged the write (acks=all).
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// RelayConfig tunes the relay loop.
+type RelayConfig struct {
+	BatchSize     int           // rows per transaction
+	PollInterval  time.Duration // fallback when no NOTIFY arrives
+	MaxAttempts   int           // then the row is marked failed
+	Lease         time.Duration // how long a claimed row is hidden; must exceed the publish timeout
+	RetryBackoff  time.Duration // first retry delay, doubled per attempt
+	MaxBackoff    time.Duration
+	Retention     time.Duration // sent rows older than this are deleted
+	PruneInterval time.Duration
+}
+
+func (c *RelayConfig) setDefaults() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 20
+	}
+	if c.Lease <= 0 {
+		c.Lease = time.Minute
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Minute
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+	if c.PruneInterval <= 0 {
+		c.PruneInterval = time.Hour
+	}
+}
+
+// Relay moves committed outbox rows to Kafka.
+//
+// Delivery is at-least-once: a crash between the broker ack and the update
+// that marks the row sent republishes it with the same event ID once its
+// lease expires, which is what consumers deduplicate on.
+type Relay struct {
+	store store
+	pub   Publisher
+	cfg   RelayConfig
+	now   func() time.Time
+	wake  chan struct{}
+}
+
+func NewRelay(db *gorm.DB, pub Publisher, cfg RelayConfig) *Relay {
+	return newRelay(pgStore{db: db}, pub, cfg)
+}
+
+func newRelay(s store, pub Publisher, cfg RelayConfig) *Relay {
+	cfg.setDefaults()
+	return &Relay{store: s, pub: pub, cfg: cfg, now: time.Now, wake: make(chan struct{}, 1)}
+}
+
+// Wake triggers a relay round without waiting for the poll interval.
+func (r *Relay) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default: // a round is already pending
+	}
+}
+
+// Run relays until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) error {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(r.cfg.PruneInterval)
+	defer prune.Stop()
+
+	for {
+		r.drain(ctx)
+		r.observeBacklog(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.wake:
+		case <-poll.C:
+		case <-prune.C:
+			if n, err := r.Prune(ctx); err != nil {
+				log.Error().Err(err).Str("action", "outbox_prune").Str("status", "failed").Msg("Outbox prune failed")
+			} else if n > 0 {
+				log.Info().Str("action", "outbox_prune").Str("status", "success").Int64("deleted", n).Msg("Pruned sent outbox messages")
+			}
+		}
+	}
+}
+
+// drain relays full batches until the backlog is empty or blocked.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Error().Err(err).Str("action", "outbox_relay").Str("status", "failed").Msg("Outbox relay round failed")
+			}
+			return
+		}
+		if n < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// RelayBatch claims one batch, publishes it outside the claiming
+// transaction and records each outcome. It returns how many rows it claimed,
+// or 0 without error when another relay holds the lock.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	batch, err := r.claim(ctx)
+	if err != nil || len(batch) == 0 {
+		return 0, err
+	}
+
+	// Once a row fails, the rest of its aggregate waits for the retry
+	blocked := make(map[string]bool)
+	var unsent []int64
+	for i := range batch {
+		msg := &batch[i]
+		key := msg.AggregateType + "/" + msg.AggregateID
+		if blocked[key] || err != nil {
+			unsent = append(unsent, msg.ID)
+			continue
+		}
+		var ok bool
+		if ok, err = r.relayOne(ctx, msg); !ok {
+			blocked[key] = true
+			if err != nil {
+				unsent = append(unsent, msg.ID)
+			}
+		}
+	}
+
+	// Hand skipped rows back now rather than when their lease expires
+	if relErr := r.release(ctx, unsent); relErr != nil && err == nil {
+		err = relErr
+	}
+	return len(batch), err
+}
+
+// claim leases the next batch: the rows get a next_attempt_at of
+// now+Lease, which hides them (and the rest of their aggregates) from every
+// relay until the outcome is recorded.
+func (r *Relay) claim(ctx context.Context) ([]Message, error) {
+	return r.store.claim(ctx, r.cfg.BatchSize, r.now().Add(r.cfg.Lease))
+}
+
+// release makes claimed but unpublished rows due again.
+func (r *Relay) release(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.store.release(context.WithoutCancel(ctx), ids, r.now())
+}
+
+// relayOne publishes msg and records the outcome; ok reports whether it was
+// sent. An error means the outcome was not recorded and msg is still claimed.
+func (r *Relay) relayOne(ctx context.Context, msg *Message) (ok bool, err error) {
+	pubErr := r.pub.Publish(ctx, msg)
+	metrics.ObserveOutboxRelay(msg.Topic, pubErr)
+
+	// The broker already answered; record that even if we are shutting down
+	recordCtx := context.WithoutCancel(ctx)
+
+	now := r.now()
+	if pubErr == nil {
+		err := r.store.update(recordCtx, msg.ID, map[string]any{"sent_at": now, "attempts": msg.Attempts + 1, "last_error": ""})
+		return err == nil, err
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	attempts := msg.Attempts + 1
+	updates := map[string]any{"attempts": attempts, "last_error": pubErr.Error()}
+	evt := log.Warn()
+	if attempts >= r.cfg.MaxAttempts {
+		// Stop retrying; the row and everything after it in its aggregate
+		// wait for an operator to inspect and requeue it.
+		updates["failed_at"] = now
+		metrics.IncOutboxDead(msg.Topic)
+		evt = log.Error()
+	} else {
+		updates["next_attempt_at"] = now.Add(r.backoff(attempts))
+	}
+	evt.Err(pubErr).
+		Str("action", "outbox_relay").
+		Str("status", "failed").
+		Str("topic", msg.Topic).
+		Str("event_id", msg.EventID.String()).
+		Str("aggregate_id", msg.AggregateID).
+		Int("attempt", attempts).
+		Msg("Failed to relay outbox message")
+
+	return false, r.store.update(recordCtx, msg.ID, updates)
+}
+
+func (r *Relay) backoff(attempt int) time.Duration {
+	d := r.cfg.RetryBackoff
+	for i := 1; i < attempt && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.cfg.MaxBackoff)
+}
+
+// Prune deletes rows sent before the retention window.
+// Failed rows are kept until an operator deals with them.
+func (r *Relay) Prune(ctx context.Context) (int64, error) {
+	return r.store.prune(ctx, r.now().Add(-r.cfg.Retention))
+}
+
+func (r *Relay) observeBacklog(ctx context.Context) {
+	pending, oldest, err := r.store.backlog(ctx)
+	if err != nil {
+		return
+	}
+	var age time.Duration
+	if oldest != nil {
+		age = r.now().Sub(*oldest)
+	}
+	metrics.SetOutboxBacklog(pending, age)
+}
@@ -0,0 +1,331 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memStore is an in-memory outbox table with the claim rules of
+// pendingQuery, read against a clock the test moves.
+type memStore struct {
+	mu   sync.Mutex
+	now  time.Time
+	rows []Message // ID order
+}
+
+func newMemStore() *memStore {
+	return &memStore{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (s *memStore) clock() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+func (s *memStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *memStore) add(aggregateID string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := int64(len(s.rows) + 1)
+	s.rows = append(s.rows, Message{
+		ID:            id,
+		EventID:       uuid.New(),
+		AggregateType: "user",
+		AggregateID:   aggregateID,
+		Topic:         "identity.user",
+		CreatedAt:     s.now,
+		NextAttemptAt: s.now,
+	})
+	return id
+}
+
+func (s *memStore) row(id int64) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rows[id-1]
+}
+
+func (s *memStore) claim(_ context.Context, limit int, until time.Time) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var batch []Message
+	for i, m := range s.rows {
+		if len(batch) == limit {
+			break
+		}
+		if m.SentAt != nil || m.FailedAt != nil || m.NextAttemptAt.After(s.now) {
+			continue
+		}
+		if s.heldBack(i) {
+			continue
+		}
+		batch = append(batch, m)
+	}
+	for _, m := range batch {
+		s.rows[m.ID-1].NextAttemptAt = until
+	}
+	return batch, nil
+}
+
+// heldBack reports whether an earlier unsent row of the same aggregate is
+// claimed, backing off or dead.
+func (s *memStore) heldBack(i int) bool {
+	m := s.rows[i]
+	for _, e := range s.rows[:i] {
+		if e.AggregateType != m.AggregateType || e.AggregateID != m.AggregateID || e.SentAt != nil {
+			continue
+		}
+		if e.FailedAt != nil || e.NextAttemptAt.After(s.now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memStore) update(_ context.Context, id int64, fields map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := &s.rows[id-1]
+	for k, v := range fields {
+		switch k {
+		case "sent_at":
+			t := v.(time.Time)
+			m.SentAt = &t
+		case "failed_at":
+			t := v.(time.Time)
+			m.FailedAt = &t
+		case "next_attempt_at":
+			m.NextAttemptAt = v.(time.Time)
+		case "attempts":
+			m.Attempts = v.(int)
+		case "last_error":
+			m.LastError = v.(string)
+		default:
+			return fmt.Errorf("memStore: unknown column %q", k)
+		}
+	}
+	return nil
+}
+
+func (s *memStore) release(_ context.Context, ids []int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		if m := &s.rows[id-1]; m.SentAt == nil && m.FailedAt == nil {
+			m.NextAttemptAt = at
+		}
+	}
+	return nil
+}
+
+func (s *memStore) prune(context.Context, time.Time) (int64, error) { return 0, nil }
+
+func (s *memStore) backlog(context.Context) (int64, *time.Time, error) { return 0, nil, nil }
+
+// fakePublisher records every publish attempt and fails those fail picks.
+type fakePublisher struct {
+	mu       sync.Mutex
+	attempts []int64
+	eventIDs map[int64][]uuid.UUID
+	fail     func(msg *Message) error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts = append(p.attempts, msg.ID)
+	if p.eventIDs == nil {
+		p.eventIDs = make(map[int64][]uuid.UUID)
+	}
+	p.eventIDs[msg.ID] = append(p.eventIDs[msg.ID], msg.EventID)
+	if p.fail != nil {
+		return p.fail(msg)
+	}
+	return nil
+}
+
+func (p *fakePublisher) published() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.attempts)
+}
+
+func newTestRelay(s *memStore, pub Publisher, cfg RelayConfig) *Relay {
+	r := newRelay(s, pub, cfg)
+	r.now = s.clock
+	return r
+}
+
+func relayBatch(t *testing.T, r *Relay, want int) {
+	t.Helper()
+	n, err := r.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != want {
+		t.Fatalf("claimed %d rows, want %d", n, want)
+	}
+}
+
+func TestRelayClaimsInIDOrder(t *testing.T) {
+	s := newMemStore()
+	for _, agg := range []string{"a", "b", "a", "c", "b"} {
+		s.add(agg)
+	}
+	pub := &fakePublisher{}
+	r := newTestRelay(s, pub, RelayConfig{BatchSize: 3})
+
+	relayBatch(t, r, 3)
+	relayBatch(t, r, 2)
+	relayBatch(t, r, 0)
+
+	if got, want := pub.published(), []int64{1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	for id := int64(1); id <= 5; id++ {
+		if m := s.row(id); m.SentAt == nil || m.Attempts != 1 {
+			t.Fatalf("row %d: sent %v after %d attempts", id, m.SentAt, m.Attempts)
+		}
+	}
+}
+
+func TestRelayKeepsAggregateOrderOnFailure(t *testing.T) {
+	s := newMemStore()
+	a1 := s.add("a")
+	b1 := s.add("b")
+	a2 := s.add("a")
+	a3 := s.add("a")
+
+	down := true
+	pub := &fakePublisher{fail: func(msg *Message) error {
+		if down && msg.ID == a1 {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}}
+	r := newTestRelay(s, pub, RelayConfig{RetryBackoff: time.Second})
+
+	// a1 fails: b1 goes out, the rest of aggregate a waits behind it
+	relayBatch(t, r, 4)
+	if got, want := pub.published(), []int64{a1, b1}; !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	m := s.row(a1)
+	if m.Attempts != 1 || m.LastError != "broker unavailable" || !m.NextAttemptAt.Equal(s.clock().Add(time.Second)) {
+		t.Fatalf("a1 after failure: %+v", m)
+	}
+	// The skipped rows were handed back instead of keeping their lease
+	if m := s.row(a2); !m.NextAttemptAt.Equal(s.clock()) || m.Attempts != 0 {
+		t.Fatalf("a2 after skip: %+v", m)
+	}
+
+	// While a1 backs off nothing of its aggregate may overtake it
+	relayBatch(t, r, 0)
+
+	down = false
+	s.advance(time.Second)
+	relayBatch(t, r, 3)
+	if got, want := pub.published(), []int64{a1, b1, a1, a2, a3}; !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	if m := s.row(a1); m.SentAt == nil || m.Attempts != 2 || m.LastError != "" {
+		t.Fatalf("a1 after retry: %+v", m)
+	}
+}
+
+func TestRelayRepublishesAfterLeaseExpires(t *testing.T) {
+	s := newMemStore()
+	a1 := s.add("a")
+	a2 := s.add("a")
+	pub := &fakePublisher{}
+	r := newTestRelay(s, pub, RelayConfig{Lease: time.Minute})
+
+	// A relay claims the batch and dies before recording anything
+	batch, err := r.claim(context.Background())
+	if err != nil || len(batch) != 2 {
+		t.Fatalf("claim = %d rows, %v", len(batch), err)
+	}
+
+	// Until the lease runs out the rows stay hidden from other relays
+	s.advance(time.Minute - time.Second)
+	relayBatch(t, r, 0)
+
+	s.advance(time.Second)
+	relayBatch(t, r, 2)
+	if got, want := pub.published(), []int64{a1, a2}; !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	// Consumers deduplicate on the event ID, so it must not change
+	if got := pub.eventIDs[a1]; got[0] != batch[0].EventID {
+		t.Fatalf("republished with event ID %s, want %s", got[0], batch[0].EventID)
+	}
+}
+
+func TestRelayGivesUpAfterMaxAttempts(t *testing.T) {
+	s := newMemStore()
+	a1 := s.add("a")
+	a2 := s.add("a")
+	b1 := s.add("b")
+
+	pub := &fakePublisher{fail: func(msg *Message) error {
+		if msg.ID == a1 {
+			return errors.New("message too large")
+		}
+		return nil
+	}}
+	r := newTestRelay(s, pub, RelayConfig{MaxAttempts: 2, RetryBackoff: time.Second})
+
+	relayBatch(t, r, 3)
+	s.advance(time.Second)
+	relayBatch(t, r, 2)
+
+	m := s.row(a1)
+	if m.FailedAt == nil || m.Attempts != 2 || m.LastError != "message too large" {
+		t.Fatalf("a1 after last attempt: %+v", m)
+	}
+
+	// The dead row holds its aggregate until an operator requeues it
+	s.advance(time.Hour)
+	relayBatch(t, r, 0)
+	if got, want := pub.published(), []int64{a1, b1, a1}; !slices.Equal(got, want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	if m := s.row(a2); m.SentAt != nil || m.Attempts != 0 {
+		t.Fatalf("a2 overtook the dead row: %+v", m)
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := newRelay(newMemStore(), &fakePublisher{}, RelayConfig{RetryBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{40, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := r.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
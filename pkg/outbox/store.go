@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// store is the relay's access to the outbox table. pgStore is the only
+// implementation outside tests.
+type store interface {
+	// claim leases up to limit due rows, in ID order, by moving their
+	// next_attempt_at to until. Rows held back by an earlier row of their
+	// aggregate are skipped. It returns nothing when another relay is
+	// claiming.
+	claim(ctx context.Context, limit int, until time.Time) ([]Message, error)
+	// update sets columns of one row.
+	update(ctx context.Context, id int64, fields map[string]any) error
+	// release makes the rows among ids that are neither sent nor dead due at.
+	release(ctx context.Context, ids []int64, at time.Time) error
+	// prune deletes rows sent before the given time.
+	prune(ctx context.Context, before time.Time) (int64, error)
+	// backlog counts unsent, live rows and returns the oldest creation time.
+	backlog(ctx context.Context) (pending int64, oldest *time.Time, err error)
+}
+
+// relayLockKey is the advisory lock taken while claiming a batch. Claims
+// never overlap, and a claimed row holds back the rest of its aggregate,
+// which is what keeps events of an aggregate in order across replicas.
+const relayLockKey = "bitka:outbox-relay"
+
+// pendingQuery selects due rows in ID order. A row is skipped while its
+// aggregate has an earlier unsent row that is claimed, backing off or dead,
+// so nothing overtakes it; a dead row holds its aggregate until an operator
+// requeues it.
+const pendingQuery = `
+SELECT * FROM outbox_messages m
+WHERE m.sent_at IS NULL AND m.failed_at IS NULL AND m.next_attempt_at <= now()
+  AND NOT EXISTS (
+    SELECT 1 FROM outbox_messages e
+    WHERE e.aggregate_type = m.aggregate_type AND e.aggregate_id = m.aggregate_id
+      AND e.id < m.id AND e.sent_at IS NULL AND e.failed_at IS NULL
+      AND e.next_attempt_at > now()
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM outbox_messages d
+    WHERE d.aggregate_type = m.aggregate_type AND d.aggregate_id = m.aggregate_id
+      AND d.id < m.id AND d.sent_at IS NULL AND d.failed_at IS NOT NULL
+  )
+ORDER BY m.id
+LIMIT ?`
+
+// pgStore keeps the outbox in Postgres. Claims run in a short transaction
+// serialised by an advisory lock.
+type pgStore struct {
+	db *gorm.DB
+}
+
+func (s pgStore) claim(ctx context.Context, limit int, until time.Time) ([]Message, error) {
+	var batch []Message
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var leader bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", relayLockKey).Scan(&leader).Error; err != nil {
+			return err
+		}
+		if !leader {
+			return nil
+		}
+
+		if err := tx.Raw(pendingQuery, limit).Scan(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(batch))
+		for i := range batch {
+			ids[i] = batch[i].ID
+		}
+		return tx.Model(&Message{}).Where("id IN ?", ids).Update("next_attempt_at", until).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s pgStore) update(ctx context.Context, id int64, fields map[string]any) error {
+	return s.db.WithContext(ctx).Model(&Message{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (s pgStore) release(ctx context.Context, ids []int64, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&Message{}).
+		Where("id IN ? AND sent_at IS NULL AND failed_at IS NULL", ids).
+		Update("next_attempt_at", at).Error
+}
+
+func (s pgStore) prune(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("sent_at < ?", before).Delete(&Message{})
+	return res.RowsAffected, res.Error
+}
+
+func (s pgStore) backlog(ctx context.Context) (int64, *time.Time, error) {
+	var row struct {
+		Pending int64
+		Oldest  *time.Time
+	}
+	err := s.db.WithContext(ctx).Raw(
+		"SELECT count(*) AS pending, min(created_at) AS oldest FROM outbox_messages WHERE sent_at IS NULL AND failed_at IS NULL",
+	).Scan(&row).Error
+	return row.Pending, row.Oldest, err
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"bitka/pkg/database"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+)
+
+// ErrNoTransaction is returned when Enqueue is called outside TxManager.Do.
+// Writing the event outside the domain transaction would bring back the
+// dual-write problem the outbox exists to solve.
+var ErrNoTransaction = errors.New("outbox: Enqueue must run inside a transaction")
+
+// Event describes what to publish.
+type Event struct {
+	EventID       uuid.UUID // optional: reuse the envelope ID; a new one otherwise
+	AggregateType string    // e.g. "user"
+	AggregateID   string    // events of one aggregate are delivered in order
+	Topic         string
+	EventType     string
+	Payload       any // JSON encoded
+	Headers       Headers
+}
+
+// Writer stores events in the outbox table of the service database.
+type Writer struct {
+	db *gorm.DB
+}
+
+func NewWriter(db *gorm.DB) *Writer {
+	return &Writer{db: db}
+}
+
+// Enqueue inserts the event in the transaction carried by ctx and returns
+// its event ID. Nothing is published until that transaction commits.
+func (w *Writer) Enqueue(ctx context.Context, evt Event) (uuid.UUID, error) {
+	if !database.InTx(ctx) {
+		return uuid.Nil, ErrNoTransaction
+	}
+
+	payload, err := json.Marshal(evt.Payload)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("outbox: encode payload: %w", err)
+	}
+
+	headers := Headers{}
+	for k, v := range evt.Headers {
+		headers[k] = v
+	}
+	// Keep the caller's trace so the relay's publish joins it (traceparent)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	eventID := evt.EventID
+	if eventID == uuid.Nil {
+		eventID = uuid.New()
+	}
+
+	msg := Message{
+		EventID:       eventID,
+		AggregateType: evt.AggregateType,
+		AggregateID:   evt.AggregateID,
+		Topic:         evt.Topic,
+		EventType:     evt.EventType,
+		Payload:       payload,
+		Headers:       headers,
+		CreatedAt:     time.Now(),
+		NextAttemptAt: time.Now(),
+	}
+	msg.Headers[HeaderEventID] = msg.EventID.String()
+	msg.Headers[HeaderEventType] = msg.EventType
+
+	tx := database.Conn(ctx, w.db)
+	if err := tx.Create(&msg).Error; err != nil {
+		return uuid.Nil, err
+	}
+
+	// Delivered by Postgres only when the transaction commits
+	if err := tx.Exec("SELECT pg_notify(?, '')", NotifyChannel).Error; err != nil {
+		return uuid.Nil, err
+	}
+
+	return msg.EventID, nil
+}
@@ -1,6 +1,10 @@
 package response
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"bitka/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 type APIResponse struct {
 	Success bool   `json:"success"`
@@ -22,3 +26,15 @@ func Error(c *fiber.Ctx, status int, msg string) error {
 		Error:   msg,
 	})
 }
+
+// InternalError logs err against the request and answers 500 with a
+// generic message, so storage or driver details never reach the client.
+func InternalError(c *fiber.Ctx, err error) error {
+	logger.From(c.UserContext()).Error().Err(err).
+		Str("action", "http_request").
+		Str("status", "failed").
+		Str("method", c.Method()).
+		Str("path", c.Path()).
+		Msg("Request failed")
+	return Error(c, fiber.StatusInternalServerError, "Internal server error")
+}
@@ -0,0 +1,86 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Hook releases one resource. It must return once ctx is done.
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Manager runs shutdown hooks in registration order when the process
+// receives SIGINT/SIGTERM, bounded by a single overall timeout.
+//
+// Register in the order things must stop: HTTP first (stop accepting,
+// drain), then consumers, then producers, then the database.
+type Manager struct {
+	hooks   []Hook
+	timeout time.Duration
+}
+
+// New creates a Manager with the overall shutdown deadline.
+func New(timeout time.Duration) *Manager {
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &Manager{timeout: timeout}
+}
+
+// Add appends a hook. Hooks run sequentially in the order they were added.
+func (m *Manager) Add(name string, fn func(ctx context.Context) error) {
+	m.hooks = append(m.hooks, Hook{Name: name, Fn: fn})
+}
+
+// Wait blocks until a termination signal arrives (or ctx is cancelled),
+// then runs every hook. The returned error joins all hook failures.
+func (m *Manager) Wait(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop() // a second signal now kills the process immediately
+
+	log.Info().
+		Str("action", "shutdown").
+		Dur("timeout", m.timeout).
+		Msg("Shutdown signal received")
+
+	return m.Run()
+}
+
+// Run executes the hooks now. Exposed for callers that detect a fatal error
+// (e.g. the HTTP listener failed) and need to release resources themselves.
+func (m *Manager) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, h := range m.hooks {
+		start := time.Now()
+		err := h.Fn(ctx)
+
+		event := log.Info()
+		status := "success"
+		if err != nil {
+			event = log.Error().Err(err)
+			status = "error"
+			errs = append(errs, fmt.Errorf("%s: %w", h.Name, err))
+		}
+		event.
+			Str("action", "shutdown").
+			Str("component", h.Name).
+			Str("status", status).
+			Dur("latency", time.Since(start)).
+			Msg("Component stopped")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local stores objects as files under a root directory. Meant for
+// development and single-node deployments; it cannot pre-sign uploads.
+type Local struct {
+	root      string
+	publicURL string
+}
+
+func NewLocal(root, publicURL string) (*Local, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, err
+	}
+	return &Local{root: abs, publicURL: publicURL}, nil
+}
+
+// Root is the directory objects are stored in, e.g. to serve a public
+// prefix with a static file handler.
+func (l *Local) Root() string { return l.root }
+
+func (l *Local) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+func (l *Local) Put(_ context.Context, key string, data []byte, _ string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+
+	// Write then rename, so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, ObjectInfo{}, mapFSError(err)
+	}
+	info, err := l.info(key, f)
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return f, info, nil
+}
+
+func (l *Local) Stat(_ context.Context, key string) (ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return ObjectInfo{}, mapFSError(err)
+	}
+	defer f.Close()
+	return l.info(key, f)
+}
+
+// info reads size and sniffs the content type (the filesystem keeps none).
+func (l *Local) info(key string, f *os.File) (ObjectInfo, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	head := make([]byte, 512)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return ObjectInfo{}, err
+	}
+
+	return ObjectInfo{
+		Key:         key,
+		Size:        st.Size(),
+		ContentType: http.DetectContentType(head[:n]),
+		ModTime:     st.ModTime(),
+	}, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) PresignPut(context.Context, string, string, time.Duration) (PresignedRequest, error) {
+	return PresignedRequest{}, ErrUnsupported
+}
+
+func (l *Local) URL(_ context.Context, key string, _ time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if l.publicURL == "" {
+		return "", ErrUnsupported
+	}
+	return joinURL(l.publicURL, key), nil
+}
+
+func mapFSError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
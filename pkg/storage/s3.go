@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigAlgorithm    = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	maxPresignTTL   = 7 * 24 * time.Hour // SigV4 limit
+)
+
+// S3 talks to an S3-compatible bucket with Signature V4, using only
+// net/http so every vendor's "S3 API" endpoint works the same way.
+type S3 struct {
+	endpoint  *url.URL
+	region    string
+	service   string // always "s3"; the SigV4 test suite signs for "service"
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	publicURL string
+	client    *http.Client
+}
+
+func NewS3(cfg Config) (*S3, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("storage: s3 needs a bucket, access key and secret key")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", endpoint)
+	}
+
+	return &S3{
+		endpoint:  u,
+		region:    cfg.Region,
+		service:   "s3",
+		bucket:    cfg.Bucket,
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		pathStyle: cfg.PathStyle,
+		publicURL: cfg.PublicURL,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// objectURL addresses key in path style (endpoint/bucket/key) or virtual
+// host style (bucket.endpoint/key).
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = strings.TrimSuffix(u.Path, key) + escapePath(key)
+	return &u
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	resp, err := s.do(req, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	resp, err := s.request(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return resp.Body, objectInfo(key, resp), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := s.request(ctx, http.MethodHead, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	return objectInfo(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.request(ctx, http.MethodDelete, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) PresignPut(_ context.Context, key, contentType string, ttl time.Duration) (PresignedRequest, error) {
+	if err := ValidateKey(key); err != nil {
+		return PresignedRequest{}, err
+	}
+
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	u, expiresAt := s.presign(http.MethodPut, key, headers, ttl, time.Now())
+	return PresignedRequest{
+		Method:    http.MethodPut,
+		URL:       u,
+		Headers:   headers,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *S3) URL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if s.publicURL != "" {
+		return joinURL(s.publicURL, key), nil
+	}
+	u, _ := s.presign(http.MethodGet, key, nil, ttl, time.Now())
+	return u, nil
+}
+
+func (s *S3) request(ctx context.Context, method, key string) (*http.Response, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req, emptySHA256)
+}
+
+// do signs req with the Authorization header and maps error statuses.
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signRequest(req, payloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("storage: s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// signRequest sets the SigV4 headers and the Authorization header on req.
+func (s *S3) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Content-Type") != "" {
+		signed = append(signed, "content-type")
+	}
+	sort.Strings(signed)
+
+	headers := func(name string) string {
+		if name == "host" {
+			return req.URL.Host
+		}
+		return req.Header.Get(name)
+	}
+	canonical := canonicalRequest(req.Method, req.URL.EscapedPath(), req.URL.Query(), signed, headers, payloadHash)
+	scope := s.scope(now)
+	signature := s.sign(now, scope, canonical)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigAlgorithm, s.accessKey, scope, strings.Join(signed, ";"), signature))
+}
+
+// presign returns a query-signed URL for method on key. headers are
+// signed too and must be sent unchanged by the client.
+func (s *S3) presign(method, key string, headers map[string]string, ttl time.Duration, now time.Time) (string, time.Time) {
+	ttl = min(max(ttl, time.Second), maxPresignTTL)
+	now = now.UTC()
+
+	signed := []string{"host"}
+	lower := map[string]string{}
+	for name, value := range headers {
+		n := strings.ToLower(name)
+		signed = append(signed, n)
+		lower[n] = value
+	}
+	sort.Strings(signed)
+
+	u := s.objectURL(key)
+	scope := s.scope(now)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", strings.Join(signed, ";"))
+
+	headerValue := func(name string) string {
+		if name == "host" {
+			return u.Host
+		}
+		return lower[name]
+	}
+	canonical := canonicalRequest(method, u.EscapedPath(), query, signed, headerValue, unsignedPayload)
+	query.Set("X-Amz-Signature", s.sign(now, scope, canonical))
+
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), now.Add(ttl)
+}
+
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+func (s *S3) sign(t time.Time, scope, canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	stringToSign := sigAlgorithm + "\n" + t.Format(amzDateFormat) + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalRequest(method, path string, query url.Values, signed []string, header func(string) string, payloadHash string) string {
+	var b strings.Builder
+	b.WriteString(method + "\n")
+	b.WriteString(path + "\n")
+	b.WriteString(canonicalQuery(query) + "\n")
+	for _, name := range signed {
+		b.WriteString(name + ":" + strings.TrimSpace(header(name)) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(signed, ";") + "\n")
+	b.WriteString(payloadHash)
+	return b.String()
+}
+
+// canonicalQuery sorts and strictly encodes the query, as SigV4 requires.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath encodes each segment of key, keeping the slashes.
+func escapePath(key string) string {
+	return uriEncode(key, false)
+}
+
+// uriEncode is the SigV4 encoding: everything except unreserved characters
+// (and '/' unless encodeSlash) is percent-encoded.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// emptySHA256 is the payload hash of requests without a body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func objectInfo(key string, resp *http.Response) ObjectInfo {
+	info := ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return info
+}
@@ -0,0 +1,115 @@
+// Package storage is the object store behind user uploads (avatars, KYC
+// documents). Services depend on the Storage interface; New picks the
+// local filesystem or an S3-compatible bucket (AWS S3, R2, GCS interop,
+// MinIO) from configuration.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("storage: object not found")
+	ErrInvalidKey  = errors.New("storage: invalid object key")
+	ErrUnsupported = errors.New("storage: operation not supported by this driver")
+)
+
+// Drivers
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// Config is loaded by pkg/config. Services prefix the keys, e.g.
+// ACCOUNT_STORAGE_DRIVER takes precedence over STORAGE_DRIVER.
+type Config struct {
+	Driver string `env:"STORAGE_DRIVER" default:"local" yaml:"driver"` // "local" or "s3"
+
+	// Base URL under which public objects (avatars) are served.
+	// Empty with s3 means public objects get pre-signed GET URLs instead.
+	PublicURL string `env:"STORAGE_PUBLIC_URL" yaml:"public_url"`
+	// Lifetime of pre-signed URLs
+	PresignTTL time.Duration `env:"STORAGE_PRESIGN_TTL" default:"15m" yaml:"presign_ttl"`
+
+	// local
+	LocalDir string `env:"STORAGE_LOCAL_DIR" default:"./data/objects" yaml:"local_dir"`
+
+	// s3
+	Endpoint  string `env:"STORAGE_S3_ENDPOINT" yaml:"s3_endpoint"` // empty = AWS for Region
+	Region    string `env:"STORAGE_S3_REGION" default:"us-east-1" yaml:"s3_region"`
+	Bucket    string `env:"STORAGE_S3_BUCKET" yaml:"s3_bucket"`
+	AccessKey string `env:"STORAGE_S3_ACCESS_KEY" secret:"true" yaml:"s3_access_key"`
+	SecretKey string `env:"STORAGE_S3_SECRET_KEY" secret:"true" yaml:"s3_secret_key"`
+	PathStyle bool   `env:"STORAGE_S3_PATH_STYLE" default:"false" yaml:"s3_path_style"` // MinIO needs true
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// PresignedRequest is what a client needs to upload directly to the store.
+type PresignedRequest struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"` // must be sent as-is
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Storage is an object store addressed by slash-separated keys.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns the object content; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete is idempotent: a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// PresignPut lets a client upload key without going through the service.
+	// Returns ErrUnsupported where the driver cannot sign URLs.
+	PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (PresignedRequest, error)
+	// URL is a link a browser can load key from: PublicURL/key when set,
+	// otherwise a pre-signed GET valid for ttl.
+	URL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New builds the Storage selected by cfg.Driver.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverLocal:
+		return NewLocal(cfg.LocalDir, cfg.PublicURL)
+	case DriverS3:
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
+	}
+}
+
+// ValidateKey rejects keys that could escape a prefix or a directory.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > 1024 || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	if strings.ContainsAny(key, "\\\x00") {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// joinURL appends key to a base URL.
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + escapePath(key)
+}
@@ -0,0 +1,59 @@
+package token
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// Audiences of the tokens issued by auth
+const (
+	AudienceAccess  = "api:access"
+	AudienceRefresh = "api:refresh"
+)
+
+// Private claims
+const (
+	// ClaimSubAccount is the sub-account an access token acts as. The
+	// subject stays the owning user.
+	ClaimSubAccount = "sub_account"
+	// ClaimScope lists the permissions of a sub-account token, space
+	// separated as in OAuth 2.0.
+	ClaimScope = "scope"
+)
+
+// Verifier checks a token's signature and validity. Validator (keys from
+// a JWKS URL) and Manager (the signing key itself) implement it.
+type Verifier interface {
+	Validate(ctx context.Context, tokenString string) (jwt.Token, error)
+}
+
+// SubAccountOf returns the sub-account tok acts as, if any.
+func SubAccountOf(tok jwt.Token) (string, bool) {
+	var id string
+	if err := tok.Get(ClaimSubAccount, &id); err != nil || id == "" {
+		return "", false
+	}
+	return id, true
+}
+
+// ScopeOf returns the permissions in the scope claim of tok.
+func ScopeOf(tok jwt.Token) []string {
+	var scope string
+	if err := tok.Get(ClaimScope, &scope); err != nil {
+		return nil
+	}
+	return strings.Fields(scope)
+}
+
+// HasAudience reports whether tok was issued for aud.
+func HasAudience(tok jwt.Token, aud string) bool {
+	auds, _ := tok.Audience()
+	for _, a := range auds {
+		if a == aud {
+			return true
+		}
+	}
+	return false
+}
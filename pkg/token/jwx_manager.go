@@ -1,12 +1,14 @@
 package token
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -29,17 +31,13 @@ type Manager struct {
 }
 
 // NewManager initializes the manager and syncs with the Database.
+// The rsa_keys table must already exist (see the service's SQL migrations).
 // TODO: Use KMS for production use.
 // TODO: Look like the key ID isn't used correctly for key set.
 func NewManager(db *gorm.DB) (*Manager, error) {
-	// 1. Ensure the keys table exists
-	if err := db.AutoMigrate(&RSAKey{}); err != nil {
-		return nil, err
-	}
-
 	m := &Manager{db: db}
 
-	// 2. Try to load the most recent key from DB
+	// 1. Try to load the most recent key from DB
 	var latestKey RSAKey
 	err := db.Order("created_at desc").First(&latestKey).Error
 
@@ -150,6 +148,11 @@ func (m *Manager) loadKeyFromStruct(k RSAKey) error {
 
 // Generate creates a signed JWT string.
 func (m *Manager) Generate(userID string, duration time.Duration, audience string, jti string) (string, error) {
+	return m.GenerateWithClaims(userID, duration, audience, jti, nil)
+}
+
+// GenerateWithClaims is Generate with private claims added (see claims.go).
+func (m *Manager) GenerateWithClaims(userID string, duration time.Duration, audience string, jti string, claims map[string]any) (string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -163,6 +166,9 @@ func (m *Manager) Generate(userID string, duration time.Duration, audience strin
 	if jti != "" {
 		builder.JwtID(jti)
 	}
+	for name, value := range claims {
+		builder.Claim(name, value)
+	}
 
 	token, err := builder.Build()
 	if err != nil {
@@ -183,6 +189,20 @@ func (m *Manager) Generate(userID string, duration time.Duration, audience strin
 	return string(signed), nil
 }
 
+// Validate verifies a token signed with the current key, for the auth
+// service's own endpoints; other services use a Validator.
+func (m *Manager) Validate(_ context.Context, tokenString string) (jwt.Token, error) {
+	m.mu.RLock()
+	key := m.publicKey
+	m.mu.RUnlock()
+
+	tok, err := jwt.Parse([]byte(tokenString), jwt.WithKey(jwa.RS256(), key), jwt.WithValidate(true))
+	if err != nil {
+		return nil, fmt.Errorf("token validation failed: %w", err)
+	}
+	return tok, nil
+}
+
 // GetJWKS returns the JSON Web Key Set.
 // Note: In a real system, you might want to query ALL valid keys from the DB
 // to populate the JWKS, so clients can verify tokens signed by older (but valid) keys.
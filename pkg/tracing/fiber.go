@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FiberMiddleware starts a server span per request.
+// It must run BEFORE logger.FiberMiddleware so the logger can pick up the trace ID.
+func FiberMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 1. Continue the caller's trace if a traceparent header is present
+		carrier := propagation.HeaderCarrier{}
+		c.Request().Header.VisitAll(func(k, v []byte) {
+			carrier.Set(string(k), string(v))
+		})
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), carrier)
+
+		// 2. Start span (named after the path for now, renamed to the route template below)
+		ctx, span := Tracer().Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		// 3. Process Request
+		err := c.Next()
+
+		// Route template is only known after routing ("/api/v1/users/:id")
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			span.RecordError(err)
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			} else if status == http.StatusOK {
+				status = http.StatusInternalServerError
+			}
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		// Let clients correlate with the trace
+		c.Set("traceparent", traceparent(span.SpanContext()))
+
+		return err
+	}
+}
+
+func traceparent(sc trace.SpanContext) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpanContext(context.Background(), sc), carrier)
+	return carrier.Get("traceparent")
+}
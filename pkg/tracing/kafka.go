@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// producerCarrier adapts sarama.ProducerMessage headers to a TextMapCarrier.
+type producerCarrier struct{ msg *sarama.ProducerMessage }
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	// Replace instead of append so retries do not stack traceparents
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// consumerCarrier adapts sarama.ConsumerMessage headers to a TextMapCarrier.
+type consumerCarrier struct{ msg *sarama.ConsumerMessage }
+
+func (c consumerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerCarrier) Set(string, string) {} // read-only
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
+
+// StartProducerSpan starts a producer span and writes the W3C traceparent
+// into the message headers. The caller must End() the span after sending.
+func StartProducerSpan(ctx context.Context, msg *sarama.ProducerMessage) (context.Context, trace.Span) {
+	ctx, span := Tracer().Start(ctx, msg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(msg.Topic),
+			semconv.MessagingOperationTypePublish,
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, producerCarrier{msg: msg})
+	return ctx, span
+}
+
+// EndProducerSpan records the send result on a span from StartProducerSpan.
+func EndProducerSpan(span trace.Span, partition int32, offset int64, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(
+			semconv.MessagingDestinationPartitionID(itoa(partition)),
+			semconv.MessagingKafkaMessageOffset(int(offset)),
+		)
+	}
+	span.End()
+}
+
+// StartConsumerSpan continues the trace carried in the message headers.
+// The caller must End() the span once the message is handled.
+func StartConsumerSpan(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, consumerCarrier{msg: msg})
+	return startConsumerSpan(ctx, msg.Topic, msg.Partition, msg.Offset, len(msg.Value))
+}
+
+// StartConsumerSpanFromHeaders is StartConsumerSpan for messages whose
+// headers were already copied into a map (pkg/kafka.Message).
+func StartConsumerSpanFromHeaders(ctx context.Context, topic string, partition int32, offset int64, headers map[string]string, size int) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+	return startConsumerSpan(ctx, topic, partition, offset, size)
+}
+
+func startConsumerSpan(ctx context.Context, topic string, partition int32, offset int64, size int) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationPartitionID(itoa(partition)),
+			semconv.MessagingKafkaMessageOffset(int(offset)),
+			attribute.Int("messaging.message.body.size", size),
+		),
+	)
+}
+
+func itoa(i int32) string {
+	return strconv.Itoa(int(i))
+}
@@ -0,0 +1,80 @@
+// Command reconcile compares auth users with account profiles once,
+// creates the missing profiles and prints the drift report as JSON.
+//
+//	go run ./services/account/cmd/reconcile [-dry-run]
+//
+// It reads the account service configuration (DB, AUTH_INTERNAL_URL,
+// INTERNAL_API_TOKEN). The account service also runs it on a schedule
+// (ACCOUNT_RECONCILE_INTERVAL) and exports the results as metrics.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/encryption"
+	"bitka/pkg/logger"
+	"bitka/services/account/internal/config"
+	"bitka/services/account/internal/repository"
+	"bitka/services/account/internal/repository/authclient"
+	"bitka/services/account/internal/usecase"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report drift, do not create missing profiles")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+
+	logger.Init(logger.Config{
+		Environment: cfg.AppEnv,
+		LogLevel:    cfg.LogLevel,
+		ServiceName: cfg.ServiceName,
+		InstanceID:  cfg.InstanceID,
+	})
+
+	keyring, err := encryption.Load(cfg.Encryption, cfg.IsProduction())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid encryption keys")
+	}
+	encryption.RegisterSerializer(keyring)
+
+	db, err := database.Connect(cfg.DB)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to the database")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	uc := usecase.NewReconcileUsecase(
+		repository.NewAccountRepo(db),
+		usecase.NewReferralUsecase(repository.NewReferralRepo(db), usecase.ReferralOptions{
+			MaxTreeDepth: cfg.Referral.MaxTreeDepth,
+			MaxTreeSize:  cfg.Referral.MaxTreeSize,
+		}),
+		authclient.New(cfg.AuthInternalURL, cfg.InternalAPIToken, 30*time.Second),
+		usecase.ReconcileOptions{PageSize: cfg.Reconcile.PageSize, Grace: cfg.Reconcile.Grace},
+	)
+	report, err := uc.Reconcile(ctx, *dryRun)
+	if err != nil {
+		log.Fatal().Err(err).Str("action", "profile_reconcile").Msg("Reconciliation failed")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatal().Err(err).Msg("Failed to write the report")
+	}
+}
@@ -1,24 +1,77 @@
 package main
 
 import (
-	"bitka/pkg/config"
+	"context"
+	"os"
+	_ "time/tzdata" // the runtime image has no zoneinfo; preferences validate time zones
+
+	"bitka/pkg/logger"
+	"bitka/pkg/shutdown"
+	"bitka/pkg/tracing"
 	"bitka/services/account/internal/app"
+	"bitka/services/account/internal/config"
 
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
 	// 1. Load Configuration
-	// We pass "ACCOUNT_DB_NAME" to look for that specific env var override
-	cfg := config.Load("ACCOUNT_DB_NAME")
+	// ACCOUNT_<KEY> overrides <KEY>, e.g. ACCOUNT_DB_NAME over DB_NAME
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+
+	logger.Init(logger.Config{
+		Environment: cfg.AppEnv,
+		LogLevel:    cfg.LogLevel,
+		ServiceName: cfg.ServiceName,
+		InstanceID:  cfg.InstanceID,
+
+		SuccessSampleEvery: cfg.LogSuccessSampleEvery,
+	})
+
+	log.Info().Msg("Application starting...")
+
+	logger.LogConfigSafe(cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  cfg.ServiceName,
+		InstanceID:   cfg.InstanceID,
+		Environment:  cfg.AppEnv,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.Endpoint,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
 
-	// Override DB Name for Account Service if not set in env specific to service
-	cfg.DBName = config.GetEnv("DB_NAME", "bitka_account")
 	server, err := app.NewServer(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize server")
 	}
 
-	log.Printf("Starting Account Service on :%s", cfg.HTTPPort)
-	server.Listen(":" + cfg.HTTPPort)
+	log.Info().
+		Str("port", cfg.HTTPPort).
+		Msg("Starting Account Service")
+
+	// Graceful Shutdown (HTTP -> consumer -> DB -> tracing)
+	stopper := shutdown.New(cfg.ShutdownTimeout)
+	server.RegisterShutdown(stopper)
+	stopper.Add("tracing", shutdownTracing)
+
+	go func() {
+		if err := server.Listen(":" + cfg.HTTPPort); err != nil {
+			log.Error().Err(err).Msg("Server failed to start")
+			stopper.Run()
+			os.Exit(1)
+		}
+	}()
+
+	if err := stopper.Wait(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Shutdown finished with errors")
+		os.Exit(1)
+	}
+	log.Info().Msg("Account Service stopped")
 }
@@ -1,61 +1,381 @@
 package app
 
 import (
-	"bitka/pkg/config"
 	"bitka/pkg/database"
+	"bitka/pkg/database/migrate"
+	"bitka/pkg/encryption"
+	"bitka/pkg/health"
+	"bitka/pkg/kafka"
+	"bitka/pkg/kafka/transport"
+	"bitka/pkg/metrics"
+	"bitka/pkg/shutdown"
+	"bitka/pkg/storage"
 	"bitka/pkg/token"
+	"bitka/pkg/tracing"
+	"bitka/services/account/internal/config"
 	"bitka/services/account/internal/delivery/event"
 	"bitka/services/account/internal/delivery/http"
 	"bitka/services/account/internal/domain"
 	"bitka/services/account/internal/repository"
+	"bitka/services/account/internal/repository/authclient"
+	"bitka/services/account/internal/repository/kycprovider"
+	"bitka/services/account/internal/repository/outbox"
+	"bitka/services/account/internal/repository/sms"
 	"bitka/services/account/internal/usecase"
+	"bitka/services/account/migrations"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
 )
 
 type Server struct {
 	FiberServer *fiber.App
 	KafkaServer *event.KafkaServer
+	Health      *health.Checker
+	Kyc         domain.KycUsecase
+	Privacy     domain.PrivacyUsecase
+
+	db   *gorm.DB
+	jobs []job
+
+	mu      sync.Mutex // guards cancel and stopped: Listen runs in its own goroutine
+	cancel  context.CancelFunc
+	stopped bool // shutdown ran; a late Listen must not start the jobs
+	wg      sync.WaitGroup
+}
+
+// job is a sweep run every interval until shutdown. run reports how many
+// items it handled.
+type job struct {
+	action   string
+	interval time.Duration
+	run      func(context.Context) (int, error)
 }
 
 func NewServer(cfg *config.Config) (*Server, error) {
 
+	// Personal details are sealed by the "encrypted" GORM serializer
+	keyring, err := encryption.Load(cfg.Encryption, cfg.IsProduction())
+	if err != nil {
+		return nil, err
+	}
+	encryption.RegisterSerializer(keyring)
+
 	// 1. Connect DB
-	db, err := database.Connect(database.Config{
-		Host:     cfg.DBHost,
-		Port:     cfg.DBPort,
-		User:     cfg.DBUser,
-		Password: cfg.DBPass,
-		DBName:   cfg.DBName,
-		SSLMode:  "disable",
-	})
+	db, err := database.Connect(cfg.DB)
 	if err != nil {
 		return nil, err
 	}
 
-	db.AutoMigrate(&domain.Profile{})
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := metrics.RegisterDBStats(db, cfg.DB.DBName); err != nil {
+		return nil, err
+	}
 
-	// 2. Token validator
+	// Versioned SQL migrations (see services/account/migrations)
+	if cfg.DB.MigrateOnStart {
+		if err := runMigrations(db); err != nil {
+			return nil, err
+		}
+	}
 
-	jwksURL := config.GetEnv("AUTH_JWKS_URL", "http://localhost:3000/.well-known/jwks.json")
+	if err := transport.Validate(cfg.Kafka, cfg.IsProduction()); err != nil {
+		return nil, err
+	}
+
+	// 2. Token validator
 
-	validator := token.NewValidator(jwksURL)
+	validator := token.NewValidator(cfg.AuthJWKSURL)
 
-	// 3. Construct usecase
+	// 3. Construct usecases
+	txManager := database.NewTxManager(db)
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
 	repo := repository.NewAccountRepo(db)
-	uc := usecase.NewAccountUsecase(repo)
+	prefsRepo := repository.NewPreferencesRepo(db)
+	kycRepo := repository.NewKycRepo(db)
+	subRepo := repository.NewSubAccountRepo(db)
+	referralRepo := repository.NewReferralRepo(db)
+	uc := usecase.NewAccountUsecase(repo, txManager, store, usecase.AvatarOptions{
+		MaxSize:       cfg.Avatar.MaxSize,
+		MaxDimension:  cfg.Avatar.MaxDimension,
+		ThumbnailSize: cfg.Avatar.ThumbnailSize,
+		PresignTTL:    cfg.Storage.PresignTTL,
+	})
+
+	publisher := outbox.NewPublisher(db)
+	prefsUC := usecase.NewPreferencesUsecase(prefsRepo, txManager, publisher)
+
+	provider, err := newKycProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	kycUC := usecase.NewKycUsecase(
+		kycRepo,
+		txManager,
+		provider,
+		publisher,
+		store,
+		cfg.Kyc.Validity,
+		cfg.Kyc.MaxDocumentSize,
+	)
+
+	authDirectory := authclient.New(cfg.AuthInternalURL, cfg.InternalAPIToken, 10*time.Second)
+	privacyUC := usecase.NewPrivacyUsecase(
+		repository.NewPrivacyRepo(db),
+		repo,
+		prefsRepo,
+		kycRepo,
+		subRepo,
+		referralRepo,
+		authDirectory,
+		txManager,
+		store,
+		publisher,
+		usecase.PrivacyOptions{
+			CoolingOff: cfg.Privacy.CoolingOff,
+			ExportTTL:  cfg.Privacy.ExportTTL,
+		},
+	)
+
+	smsSender, err := newSmsSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	codeSecret, err := phoneCodeSecret(cfg)
+	if err != nil {
+		return nil, err
+	}
+	phoneUC := usecase.NewPhoneUsecase(repository.NewPhoneRepo(db), repo, txManager, smsSender, usecase.PhoneOptions{
+		CodeTTL:        cfg.Phone.CodeTTL,
+		MaxAttempts:    cfg.Phone.MaxAttempts,
+		ResendInterval: cfg.Phone.ResendInterval,
+		MaxPerDay:      cfg.Phone.MaxPerDay,
+		CodeSecret:     codeSecret,
+	})
+
+	subUC := usecase.NewSubAccountUsecase(subRepo, repo, txManager, usecase.SubAccountOptions{
+		MaxPerUser: cfg.SubAccount.Max,
+		MaxAPIKeys: cfg.SubAccount.MaxAPIKeys,
+	})
+	referralUC := usecase.NewReferralUsecase(referralRepo, usecase.ReferralOptions{
+		MaxTreeDepth: cfg.Referral.MaxTreeDepth,
+		MaxTreeSize:  cfg.Referral.MaxTreeSize,
+	})
+	reconcileUC := usecase.NewReconcileUsecase(repo, referralUC, authDirectory, usecase.ReconcileOptions{
+		PageSize: cfg.Reconcile.PageSize,
+		Grace:    cfg.Reconcile.Grace,
+	})
+
+	// 4. Health Checks
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("postgres", health.Postgres(db))
+	if !cfg.Kafka.InMemory() {
+		checker.Add("kafka", health.Kafka(cfg.Kafka.Brokers))
+	}
+	checker.Add("jwks", health.HTTP(cfg.AuthJWKSURL))
+
+	// 5. Initialize Fiber
+	httpServer := http.NewFiberServer(uc, kycUC, prefsUC, privacyUC, phoneUC, subUC, referralUC, validator, cfg.AdminUserIDs, cfg.InternalAPIToken, max(cfg.Kyc.MaxDocumentSize, cfg.Avatar.MaxSize))
+	// Operational endpoints (not under /api, not authenticated)
+	httpServer.Get("/metrics", metrics.Handler())
+	if local, ok := store.(*storage.Local); ok {
+		// Only avatars are public; KYC documents go through the admin API
+		httpServer.Static("/media/"+domain.AvatarPrefix, filepath.Join(local.Root(), domain.AvatarPrefix))
+	}
+	checker.Register(httpServer)
 
-	// 4. Initialize Fiber
-	httpServer := http.NewFiberServer(uc, validator)
-	// 5. Initialize Kafka consumer (runs in background)
-	kafkaconsumer := event.NewKafkaServer(uc)
+	// 6. Initialize Kafka consumer (started by Listen)
+	inbox := kafka.NewInbox(db, txManager, cfg.Kafka.GroupID, cfg.Kafka.InboxRetention)
+	kafkaconsumer := event.NewKafkaServer(uc, privacyUC, referralUC, cfg.Kafka, inbox)
 
 	return &Server{
 		FiberServer: httpServer,
 		KafkaServer: kafkaconsumer,
+		Health:      checker,
+		Kyc:         kycUC,
+		Privacy:     privacyUC,
+
+		db:   db,
+		jobs: backgroundJobs(cfg, kycUC, privacyUC, reconcileUC),
 	}, nil
 }
 
-func (s *Server) Listen(HTTP_PORT string) error {
+func backgroundJobs(cfg *config.Config, kyc domain.KycUsecase, privacy domain.PrivacyUsecase, reconcile domain.ReconcileUsecase) []job {
+	jobs := []job{
+		{action: "kyc_expiry", interval: cfg.Kyc.ExpiryInterval, run: kyc.ExpireDue},
+		{action: "export_build", interval: cfg.Privacy.JobInterval, run: privacy.ProcessExports},
+		{action: "export_expiry", interval: cfg.Privacy.JobInterval, run: privacy.ExpireExports},
+		{action: "account_erasure", interval: cfg.Privacy.JobInterval, run: privacy.ProcessDeletions},
+	}
+	if cfg.Reconcile.Interval > 0 {
+		jobs = append(jobs, job{
+			action:   "profile_reconcile",
+			interval: cfg.Reconcile.Interval,
+			run: func(ctx context.Context) (int, error) {
+				report, err := reconcile.Reconcile(ctx, cfg.Reconcile.DryRun)
+				if err != nil {
+					return 0, err
+				}
+				return report.Backfilled, nil
+			},
+		})
+	}
+	return jobs
+}
+
+// Listen starts the Kafka consumer and the background jobs and serves
+// HTTP on addr (e.g. ":3001").
+func (s *Server) Listen(addr string) error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	// Counted before any job starts, so shutdown waits for all of them
+	s.wg.Add(len(s.jobs))
+	s.mu.Unlock()
+
 	go s.KafkaServer.Start()
-	return s.FiberServer.Listen(":8080")
+	for _, j := range s.jobs {
+		go func() {
+			defer s.wg.Done()
+			runJob(ctx, j)
+		}()
+	}
+	return s.FiberServer.Listen(addr)
+}
+
+// RegisterShutdown adds the server's resources to m in the order they must stop:
+// stop accepting + drain HTTP, stop the consumer and the background jobs, then close the DB.
+func (s *Server) RegisterShutdown(m *shutdown.Manager) {
+	m.Add("http", func(ctx context.Context) error {
+		s.Health.SetShuttingDown()
+		return s.FiberServer.ShutdownWithContext(ctx)
+	})
+	m.Add("kafka-consumer", s.KafkaServer.Shutdown)
+	m.Add("background-jobs", func(ctx context.Context) error {
+		s.mu.Lock()
+		s.stopped = true
+		cancel := s.cancel
+		s.mu.Unlock()
+		if cancel == nil {
+			return nil // never started
+		}
+		cancel()
+		done := make(chan struct{})
+		go func() {
+			s.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	m.Add("postgres", func(context.Context) error {
+		sqlDB, err := s.db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+}
+
+func runMigrations(db *gorm.DB) error {
+	m, err := migrate.New(db, migrations.FS, "account")
+	if err != nil {
+		return err
+	}
+	return m.Up(context.Background())
+}
+
+// runJob runs j every interval until ctx is cancelled.
+func runJob(ctx context.Context, j job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n, err := j.run(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).
+				Str("action", j.action).
+				Str("status", "error").
+				Msg("Background job failed")
+			continue
+		}
+		if n > 0 {
+			log.Info().
+				Str("action", j.action).
+				Str("status", "success").
+				Int("processed", n).
+				Msg("Background job completed")
+		}
+	}
+}
+
+// newKycProvider selects the verification vendor named by ACCOUNT_KYC_PROVIDER.
+func newKycProvider(cfg *config.Config) (domain.KycProvider, error) {
+	switch cfg.Kyc.Provider {
+	case "fake":
+		if cfg.IsProduction() {
+			log.Warn().Msg("KYC provider is the local fake; decisions are not verified")
+		}
+		return kycprovider.NewFake(cfg.Kyc.FakeDecision)
+	default:
+		return nil, fmt.Errorf("unknown KYC provider %q", cfg.Kyc.Provider)
+	}
+}
+
+// newSmsSender selects the gateway named by ACCOUNT_PHONE_SMS_PROVIDER.
+func newSmsSender(cfg *config.Config) (domain.SmsSender, error) {
+	switch cfg.Phone.SmsProvider {
+	case "log":
+		if cfg.IsProduction() {
+			log.Warn().Msg("SMS provider is the log sender; verification codes are not delivered")
+		}
+		return sms.NewLog(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.Phone.SmsProvider)
+	}
+}
+
+// phoneCodeSecret returns ACCOUNT_PHONE_CODE_SECRET. Outside production a
+// missing secret is replaced by a random one, which invalidates pending
+// codes on every restart.
+func phoneCodeSecret(cfg *config.Config) ([]byte, error) {
+	if cfg.Phone.CodeSecret != "" {
+		return []byte(cfg.Phone.CodeSecret), nil
+	}
+	if cfg.IsProduction() {
+		return nil, errors.New("ACCOUNT_PHONE_CODE_SECRET is required in production")
+	}
+	log.Warn().Msg("No phone code secret configured; using a random one for this process")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
 }
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"time"
+
+	"bitka/pkg/config"
+	"bitka/pkg/database"
+	"bitka/pkg/encryption"
+	"bitka/pkg/storage"
+)
+
+// Config is everything the account service reads at boot.
+// Keys are looked up as ACCOUNT_<KEY> first, then <KEY> (see Load).
+type Config struct {
+	config.Base `yaml:",inline"`
+
+	DB    database.Config `yaml:"db"`
+	Kafka config.Kafka    `yaml:"kafka"`
+
+	// Auth service key set used to validate access tokens
+	AuthJWKSURL string `env:"AUTH_JWKS_URL" default:"http://localhost:3000/.well-known/jwks.json" required:"true" yaml:"auth_jwks_url"`
+
+	// User IDs allowed on the /admin endpoints (e.g. KYC review)
+	AdminUserIDs []string `env:"ADMIN_USER_IDS" yaml:"admin_user_ids"`
+
+	// Auth service internal API (data exports), and the shared secret of
+	// both directions of the internal API
+	AuthInternalURL  string `env:"AUTH_INTERNAL_URL" default:"http://localhost:3000" yaml:"auth_internal_url"`
+	InternalAPIToken string `env:"INTERNAL_API_TOKEN" yaml:"internal_api_token"`
+
+	Kyc        Kyc            `yaml:"kyc"`
+	Storage    storage.Config `yaml:"storage"`
+	Avatar     Avatar         `yaml:"avatar"`
+	Privacy    Privacy        `yaml:"privacy"`
+	Reconcile  Reconcile      `yaml:"reconcile"`
+	Phone      Phone          `yaml:"phone"`
+	SubAccount SubAccount     `yaml:"sub_account"`
+	Referral   Referral       `yaml:"referral"`
+
+	// Keys sealing personal details at rest
+	Encryption encryption.Config `yaml:"encryption"`
+}
+
+// Referral bounds the referral tree a user can query.
+type Referral struct {
+	MaxTreeDepth int `env:"REFERRAL_MAX_TREE_DEPTH" default:"3" yaml:"max_tree_depth"`  // levels below the user
+	MaxTreeSize  int `env:"REFERRAL_MAX_TREE_SIZE" default:"1000" yaml:"max_tree_size"` // users in one response
+}
+
+// SubAccount bounds the sub-accounts of a user and their API keys.
+type SubAccount struct {
+	Max        int `env:"SUBACCOUNT_MAX" default:"20" yaml:"max"`                   // per user, disabled ones included
+	MaxAPIKeys int `env:"SUBACCOUNT_MAX_API_KEYS" default:"10" yaml:"max_api_keys"` // unrevoked, per sub-account
+}
+
+// Phone configures phone number verification.
+type Phone struct {
+	SmsProvider    string        `env:"PHONE_SMS_PROVIDER" default:"log" yaml:"sms_provider"`
+	CodeTTL        time.Duration `env:"PHONE_CODE_TTL" default:"10m" yaml:"code_ttl"`
+	MaxAttempts    int           `env:"PHONE_MAX_ATTEMPTS" default:"5" yaml:"max_attempts"` // wrong codes per verification
+	ResendInterval time.Duration `env:"PHONE_RESEND_INTERVAL" default:"1m" yaml:"resend_interval"`
+	MaxPerDay      int           `env:"PHONE_MAX_PER_DAY" default:"10" yaml:"max_per_day"`  // codes per user
+	CodeSecret     string        `env:"PHONE_CODE_SECRET" secret:"true" yaml:"code_secret"` // HMAC key of stored codes
+}
+
+// Reconcile configures the comparison of auth users with profiles.
+type Reconcile struct {
+	Interval time.Duration `env:"RECONCILE_INTERVAL" default:"1h" yaml:"interval"` // 0 disables the scheduled run
+	PageSize int           `env:"RECONCILE_PAGE_SIZE" default:"500" yaml:"page_size"`
+	Grace    time.Duration `env:"RECONCILE_GRACE" default:"5m" yaml:"grace"` // skip users registered this recently
+	DryRun   bool          `env:"RECONCILE_DRY_RUN" default:"false" yaml:"dry_run"`
+}
+
+// Privacy configures personal data exports and account deletion.
+type Privacy struct {
+	CoolingOff  time.Duration `env:"PRIVACY_DELETION_COOLING_OFF" default:"336h" yaml:"deletion_cooling_off"` // 14 days to cancel
+	ExportTTL   time.Duration `env:"PRIVACY_EXPORT_TTL" default:"168h" yaml:"export_ttl"`                     // archives are deleted after this
+	JobInterval time.Duration `env:"PRIVACY_JOB_INTERVAL" default:"1m" yaml:"job_interval"`
+}
+
+// Avatar bounds profile picture uploads.
+type Avatar struct {
+	MaxSize       int64 `env:"AVATAR_MAX_SIZE" default:"5242880" yaml:"max_size"` // bytes
+	MaxDimension  int   `env:"AVATAR_MAX_DIMENSION" default:"1024" yaml:"max_dimension"`
+	ThumbnailSize int   `env:"AVATAR_THUMBNAIL_SIZE" default:"128" yaml:"thumbnail_size"`
+}
+
+// Kyc configures the verification workflow.
+type Kyc struct {
+	Provider        string        `env:"KYC_PROVIDER" default:"fake" yaml:"provider"`
+	FakeDecision    string        `env:"KYC_FAKE_DECISION" default:"manual" yaml:"fake_decision"` // approve, reject, manual
+	Validity        time.Duration `env:"KYC_VALIDITY" default:"8760h" yaml:"validity"`            // approvals expire after this
+	ExpiryInterval  time.Duration `env:"KYC_EXPIRY_INTERVAL" default:"1h" yaml:"expiry_interval"`
+	MaxDocumentSize int64         `env:"KYC_MAX_DOCUMENT_SIZE" default:"10485760" yaml:"max_document_size"` // bytes
+}
+
+// Load reads the account service configuration and validates it.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Base:  config.Base{ServiceName: "account-service", HTTPPort: "3001"},
+		DB:    database.Config{DBName: "bitka_account"},
+		Kafka: config.Kafka{GroupID: "account-service"},
+	}
+	if err := config.Load(cfg, config.WithPrefix("ACCOUNT")); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -1,42 +1,120 @@
 package event
 
 import (
-	"bitka/services/account/internal/delivery/event/dto"
+	"bitka/pkg/events"
+	"bitka/pkg/kafka"
+	"bitka/pkg/logger"
 	"bitka/services/account/internal/domain"
-	"encoding/json"
-	"log"
-	"strings"
+	"context"
+	"errors"
 )
 
 type Handler struct {
-	uc domain.AccountUsecase
+	uc        domain.AccountUsecase
+	privacy   domain.PrivacyUsecase
+	referrals domain.ReferralUsecase
 }
 
-func NewHandler(uc domain.AccountUsecase) *Handler {
-	return &Handler{uc: uc}
+func NewHandler(uc domain.AccountUsecase, privacy domain.PrivacyUsecase, referrals domain.ReferralUsecase) *Handler {
+	return &Handler{uc: uc, privacy: privacy, referrals: referrals}
 }
 
-func (h *Handler) HandleUserRegistered(msg []byte) {
-	var evt dto.UserRegisteredEvent
-	if err := json.Unmarshal(msg, &evt); err != nil {
-		log.Println("Failed to unmarshal Kafka message:", err)
-		return
+func (h *Handler) HandleUserRegistered(ctx context.Context, msg *kafka.Message) error {
+	l := logger.From(ctx)
+
+	_, evt, err := events.Decode[events.UserRegistered](msg.Value)
+	if err != nil {
+		l.Error().Err(err).
+			Str("action", "user_registered").
+			Str("status", "error").
+			Msg("Failed to decode Kafka message")
+		// Redelivery cannot fix a malformed payload
+		return kafka.Permanent(err)
 	}
 
-	if err := h.uc.CreateUserProfile(evt.UserID, evt.Email, evt.Username); err != nil {
-		if isDuplicateError(err) {
-			log.Println("Duplicate user profile, skipping:", evt.UserID)
-		} else {
-			log.Println("Failed to create user profile:", err)
-		}
-	} else {
-		log.Println("User profile created:", evt.UserID)
+	if err := h.uc.CreateUserProfile(ctx, evt.UserID, evt.Email, evt.Username); err != nil {
+		l.Error().Err(err).
+			Str("action", "user_registered").
+			Str("status", "error").
+			Str("user_id", evt.UserID.String()).
+			Msg("Failed to create user profile")
+		return err
+	}
+
+	l.Info().
+		Str("action", "user_registered").
+		Str("status", "success").
+		Str("user_id", evt.UserID.String()).
+		Msg("User profile created")
+
+	if evt.ReferralCode != "" {
+		return h.attributeReferral(ctx, evt)
+	}
+	return nil
+}
+
+// attributeReferral records who invited the new user. A code that does
+// not resolve is dropped: the registration itself stands.
+func (h *Handler) attributeReferral(ctx context.Context, evt events.UserRegistered) error {
+	l := logger.From(ctx)
+
+	err := h.referrals.Attribute(ctx, evt.UserID, evt.ReferralCode)
+	if errors.Is(err, domain.ErrReferralCodeNotFound) {
+		l.Warn().
+			Str("action", "referral_attribution").
+			Str("status", "skipped").
+			Str("user_id", evt.UserID.String()).
+			Msg("Unknown referral code")
+		return nil
+	}
+	if err != nil {
+		l.Error().Err(err).
+			Str("action", "referral_attribution").
+			Str("status", "error").
+			Str("user_id", evt.UserID.String()).
+			Msg("Failed to attribute referral")
+		return err
 	}
+
+	l.Info().
+		Str("action", "referral_attribution").
+		Str("status", "success").
+		Str("user_id", evt.UserID.String()).
+		Msg("Referral attributed")
+	return nil
 }
 
-func isDuplicateError(err error) bool {
-	if err == nil {
-		return false
+func (h *Handler) HandleUserErased(ctx context.Context, msg *kafka.Message) error {
+	l := logger.From(ctx)
+
+	_, evt, err := events.Decode[events.UserErased](msg.Value)
+	if err != nil {
+		l.Error().Err(err).
+			Str("action", "user_erased").
+			Str("status", "error").
+			Msg("Failed to decode Kafka message")
+		return kafka.Permanent(err)
 	}
-	return strings.Contains(err.Error(), "duplicate key")
+
+	if err := h.privacy.ConfirmErasure(ctx, evt); err != nil {
+		l.Error().Err(err).
+			Str("action", "user_erased").
+			Str("status", "error").
+			Str("user_id", evt.UserID).
+			Str("service", evt.Service).
+			Msg("Failed to record erasure confirmation")
+		if errors.Is(err, domain.ErrDeletionNotFound) {
+			// Not one of ours; retrying will not make it appear
+			return kafka.Permanent(err)
+		}
+		return err
+	}
+
+	l.Info().
+		Str("action", "user_erased").
+		Str("status", "success").
+		Str("user_id", evt.UserID).
+		Str("service", evt.Service).
+		Msg("Erasure confirmed")
+	return nil
 }
@@ -1,24 +1,49 @@
 package http
 
 import (
+	"bitka/pkg/logger"
+	"bitka/pkg/metrics"
 	"bitka/pkg/middleware"
 	"bitka/pkg/token"
+	"bitka/pkg/tracing"
 	"bitka/services/account/internal/domain"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
-func NewFiberServer(uc domain.AccountUsecase, validator *token.Validator) *fiber.App {
-	FiberServer := fiber.New()
+// multipartOverhead leaves room for form fields around an uploaded file.
+const multipartOverhead = 1 << 20
+
+// uploadRoutes are the only routes allowed bodies beyond fiber.DefaultBodyLimit.
+var uploadRoutes = []string{
+	fiber.MethodPost + " /api/v1/users/me/avatar",
+	fiber.MethodPost + " /api/v1/kyc/documents",
+}
+
+func NewFiberServer(uc domain.AccountUsecase, kyc domain.KycUsecase, prefs domain.PreferencesUsecase, privacy domain.PrivacyUsecase, phone domain.PhoneUsecase, subs domain.SubAccountUsecase, referrals domain.ReferralUsecase, validator *token.Validator, adminIDs []string, internalToken string, maxUploadSize int64) *fiber.App {
+	FiberServer := fiber.New(fiber.Config{
+		AppName:   "Bitka Account Service",
+		BodyLimit: int(maxUploadSize) + multipartOverhead, // upload routes only, see uploadRoutes
+	})
 
-	FiberServer.Use(logger.New())
 	FiberServer.Use(recover.New())
+	FiberServer.Use(tracing.FiberMiddleware()) // must run before the logger to share the trace ID
+	FiberServer.Use(logger.FiberMiddleware())
+	FiberServer.Use(metrics.FiberMiddleware())
+	FiberServer.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, uploadRoutes...))
 
 	authMW := middleware.Protected(validator)
+	adminMW := middleware.AdminOnly(adminIDs)
+	internalMW := middleware.InternalOnly(internalToken)
 	handler := NewAccountHandler(uc)
+	kycHandler := NewKycHandler(kyc)
+	prefsHandler := NewPreferencesHandler(prefs)
+	privacyHandler := NewPrivacyHandler(privacy)
+	phoneHandler := NewPhoneHandler(phone)
+	subHandler := NewSubAccountHandler(subs)
+	referralHandler := NewReferralHandler(referrals)
 
-	MapRoutes(FiberServer, handler, authMW)
+	MapRoutes(FiberServer, handler, kycHandler, prefsHandler, privacyHandler, phoneHandler, subHandler, referralHandler, authMW, adminMW, internalMW)
 
 	return FiberServer
 }
@@ -0,0 +1,22 @@
+package dto
+
+import "bitka/services/account/internal/domain"
+
+type KycSubmitRequest struct {
+	Level       int    `json:"level"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+	Country     string `json:"country"`
+}
+
+type KycRejectRequest struct {
+	Reason string `json:"reason"`
+}
+
+type KycApplicationList struct {
+	Items  []domain.KycApplication `json:"items"`
+	Total  int64                   `json:"total"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
@@ -1,6 +1,40 @@
 package dto
 
-type UpdateProfileRequest struct {
-	FullName  string `json:"full_name"`
-	AvatarURL string `json:"avatar_url"`
+import "bitka/services/account/internal/domain"
+
+type AvatarUploadRequest struct {
+	ContentType string `json:"content_type"`
+}
+
+type AvatarCompleteRequest struct {
+	Key string `json:"key"`
+}
+
+type ProfileChangeList struct {
+	Items  []domain.ProfileChange `json:"items"`
+	Total  int64                  `json:"total"`
+	Limit  int                    `json:"limit"`
+	Offset int                    `json:"offset"`
+}
+
+type ProfileList struct {
+	Items  []domain.Profile `json:"items"`
+	Total  int64            `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+type PhoneVerificationRequest struct {
+	Phone string `json:"phone"`
+}
+
+type PhoneConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+type ReferredUserList struct {
+	Items  []domain.ReferredUser `json:"items"`
+	Total  int64                 `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
 }
@@ -0,0 +1,28 @@
+package dto
+
+import "bitka/services/account/internal/domain"
+
+type SubAccountCreateRequest struct {
+	Label       string   `json:"label"`
+	Permissions []string `json:"permissions"` // omitted means read only
+}
+
+type SubAccountUpdateRequest struct {
+	Label       *string   `json:"label"`
+	Permissions *[]string `json:"permissions"`
+}
+
+type APIKeyCreateRequest struct {
+	Label       string   `json:"label"`
+	Permissions []string `json:"permissions"` // omitted means read only
+}
+
+// APIKeyCreated is the only response that carries the secret.
+type APIKeyCreated struct {
+	*domain.APIKey
+	Secret string `json:"secret"`
+}
+
+type APIKeyVerifyRequest struct {
+	Secret string `json:"secret"`
+}
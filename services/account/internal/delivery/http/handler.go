@@ -1,6 +1,10 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+
 	"bitka/pkg/response"
 	"bitka/services/account/internal/delivery/http/dto"
 	"bitka/services/account/internal/domain"
@@ -25,30 +29,191 @@ func (h *AccountHandler) GetProfile(c *fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
 	}
 
-	profile, err := h.uc.GetMyProfile(userID)
+	profile, err := h.uc.GetMyProfile(c.UserContext(), userID)
 	if err != nil {
-		return response.Error(c, fiber.StatusInternalServerError, err.Error())
+		return response.InternalError(c, err)
 	}
 
+	if !profile.UpdatedAt.IsZero() {
+		c.Set(fiber.HeaderETag, profile.ETag())
+	}
 	return response.Success(c, profile)
 }
 
-func (h *AccountHandler) UpdateProfile(c *fiber.Ctx) error {
-	var req dto.UpdateProfileRequest
-	userIDStr := c.Locals("user_id").(string)
-	userID, err := uuid.Parse(userIDStr)
+// GetPublicProfile looks another user up by ID or username. Contact
+// details are never included.
+func (h *AccountHandler) GetPublicProfile(c *fiber.Ctx) error {
+	profile, err := h.uc.GetPublicProfile(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return profileError(c, err)
+	}
+	return response.Success(c, profile)
+}
+
+// SearchUsers is the admin search across email, username and name.
+func (h *AccountHandler) SearchUsers(c *fiber.Ctx) error {
+	page := pageParams(c)
+	profiles, total, err := h.uc.SearchProfiles(c.UserContext(), c.Query("q"), page)
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, dto.ProfileList{Items: profiles, Total: total, Limit: page.Limit, Offset: page.Offset})
+}
+
+// PatchProfile applies a JSON merge patch (RFC 7396). Send the ETag of the
+// last read as If-Match to avoid overwriting a concurrent edit.
+func (h *AccountHandler) PatchProfile(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
 	if err != nil {
 		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
 	}
 
+	var patch domain.ProfilePatch
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return response.Error(c, fiber.StatusBadRequest, "Body must be a JSON object")
+	}
+
+	profile, err := h.uc.PatchMyProfile(c.UserContext(), userID, patch, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return profileError(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, profile.ETag())
+	return response.Success(c, profile)
+}
+
+// ListMyChanges returns the caller's profile change log, newest first.
+func (h *AccountHandler) ListMyChanges(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+	return h.listChanges(c, userID)
+}
+
+// ListUserChanges is the admin view of any user's change log.
+func (h *AccountHandler) ListUserChanges(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+	return h.listChanges(c, userID)
+}
+
+func (h *AccountHandler) listChanges(c *fiber.Ctx, userID uuid.UUID) error {
+	page := pageParams(c)
+	changes, total, err := h.uc.ListChanges(c.UserContext(), userID, page)
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, dto.ProfileChangeList{Items: changes, Total: total, Limit: page.Limit, Offset: page.Offset})
+}
+
+// UploadAvatar takes a multipart form with "file".
+func (h *AccountHandler) UploadAvatar(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Missing file")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid file")
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid file")
+	}
+
+	profile, err := h.uc.UploadAvatar(c.UserContext(), userID, data)
+	if err != nil {
+		return mediaError(c, err)
+	}
+	return response.Success(c, profile)
+}
+
+// CreateAvatarUpload returns a pre-signed URL to upload the avatar directly.
+func (h *AccountHandler) CreateAvatarUpload(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.AvatarUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request")
+	}
+
+	upload, err := h.uc.CreateAvatarUpload(c.UserContext(), userID, req.ContentType)
+	if err != nil {
+		return mediaError(c, err)
+	}
+	return response.Success(c, upload)
+}
+
+// CompleteAvatarUpload processes a file uploaded with a pre-signed URL.
+func (h *AccountHandler) CompleteAvatarUpload(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.AvatarCompleteRequest
 	if err := c.BodyParser(&req); err != nil {
 		return response.Error(c, fiber.StatusBadRequest, "Invalid request")
 	}
 
-	err = h.uc.UpdateMyProfile(userID, req.FullName, req.AvatarURL)
+	profile, err := h.uc.CompleteAvatarUpload(c.UserContext(), userID, req.Key)
+	if err != nil {
+		return mediaError(c, err)
+	}
+	return response.Success(c, profile)
+}
+
+func (h *AccountHandler) DeleteAvatar(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
 	if err != nil {
-		return response.Error(c, fiber.StatusInternalServerError, err.Error())
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.uc.DeleteAvatar(c.UserContext(), userID); err != nil {
+		return mediaError(c, err)
 	}
+	return response.Success(c, "Avatar removed")
+}
 
-	return response.Success(c, "Profile updated")
+// profileError maps patch errors to HTTP statuses.
+func profileError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrProfileNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return response.Error(c, fiber.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrPatchInvalid):
+		return response.Error(c, fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return response.InternalError(c, err)
+	}
+}
+
+// mediaError maps upload errors to HTTP statuses.
+func mediaError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrMediaTooLarge):
+		return response.Error(c, fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, domain.ErrMediaType):
+		return response.Error(c, fiber.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, domain.ErrUploadNotFound), errors.Is(err, domain.ErrProfileNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrPresignDisabled):
+		return response.Error(c, fiber.StatusNotImplemented, err.Error())
+	default:
+		return response.InternalError(c, err)
+	}
 }
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"bitka/pkg/response"
+	"bitka/services/account/internal/delivery/http/dto"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type KycHandler struct {
+	uc domain.KycUsecase
+}
+
+func NewKycHandler(uc domain.KycUsecase) *KycHandler {
+	return &KycHandler{uc: uc}
+}
+
+func (h *KycHandler) GetState(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	state, err := h.uc.GetState(c.UserContext(), userID)
+	if err != nil {
+		return kycError(c, err)
+	}
+	return response.Success(c, state)
+}
+
+// UploadDocument takes a multipart form with "type" and "file".
+func (h *KycHandler) UploadDocument(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Missing file")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid file")
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid file")
+	}
+
+	doc := &domain.KycDocument{
+		Type:     domain.KycDocumentType(c.FormValue("type")),
+		FileName: fh.Filename,
+		Content:  content,
+	}
+	if err := h.uc.UploadDocument(c.UserContext(), userID, doc); err != nil {
+		return kycError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.APIResponse{Success: true, Data: doc})
+}
+
+func (h *KycHandler) Submit(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.KycSubmitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request")
+	}
+	dob, err := time.Parse(time.DateOnly, req.DateOfBirth)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "date_of_birth must be YYYY-MM-DD")
+	}
+
+	app, err := h.uc.Submit(c.UserContext(), userID, domain.KycSubmission{
+		Level:       domain.KycLevel(req.Level),
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		DateOfBirth: dob,
+		Country:     req.Country,
+	})
+	if err != nil {
+		return kycError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.APIResponse{Success: true, Data: app})
+}
+
+// Reviewer endpoints (admin only)
+
+func (h *KycHandler) ListApplications(c *fiber.Ctx) error {
+	page := pageParams(c)
+	filter := domain.KycFilter{
+		Status: domain.KycStatus(c.Query("status", string(domain.KycPending))),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+	apps, total, err := h.uc.ListApplications(c.UserContext(), filter)
+	if err != nil {
+		return kycError(c, err)
+	}
+	return response.Success(c, dto.KycApplicationList{Items: apps, Total: total, Limit: page.Limit, Offset: page.Offset})
+}
+
+func (h *KycHandler) GetApplication(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid application ID")
+	}
+
+	app, err := h.uc.GetApplication(c.UserContext(), id)
+	if err != nil {
+		return kycError(c, err)
+	}
+	return response.Success(c, app)
+}
+
+func (h *KycHandler) GetDocument(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid document ID")
+	}
+
+	doc, err := h.uc.GetDocument(c.UserContext(), id)
+	if err != nil {
+		return kycError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, doc.ContentType)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(doc.Content)
+}
+
+func (h *KycHandler) Approve(c *fiber.Ctx) error {
+	reviewerID, id, err := reviewTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	app, err := h.uc.Approve(c.UserContext(), id, reviewerID)
+	if err != nil {
+		return kycError(c, err)
+	}
+	return response.Success(c, app)
+}
+
+func (h *KycHandler) Reject(c *fiber.Ctx) error {
+	reviewerID, id, err := reviewTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var req dto.KycRejectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request")
+	}
+
+	app, err := h.uc.Reject(c.UserContext(), id, reviewerID, req.Reason)
+	if err != nil {
+		return kycError(c, err)
+	}
+	return response.Success(c, app)
+}
+
+func reviewTarget(c *fiber.Ctx) (reviewerID, id uuid.UUID, err error) {
+	if reviewerID, err = currentUser(c); err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid user ID")
+	}
+	if id, err = uuid.Parse(c.Params("id")); err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid application ID")
+	}
+	return reviewerID, id, nil
+}
+
+// kycError maps workflow errors to HTTP statuses.
+func kycError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrKycNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrKycSelfReview):
+		return response.Error(c, fiber.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrKycTransition):
+		return response.Error(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrKycDocumentTooLarge):
+		return response.Error(c, fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, domain.ErrKycInvalidLevel),
+		errors.Is(err, domain.ErrKycIncomplete),
+		errors.Is(err, domain.ErrKycDocumentType):
+		return response.Error(c, fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return response.InternalError(c, err)
+	}
+}
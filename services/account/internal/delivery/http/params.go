@@ -0,0 +1,29 @@
+package http
+
+import (
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// List page size
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// pageParams reads ?limit= and ?offset=, falling back to the defaults.
+func pageParams(c *fiber.Ctx) domain.Page {
+	limit := c.QueryInt("limit", defaultPageSize)
+	if limit <= 0 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+	return domain.Page{Limit: limit, Offset: max(c.QueryInt("offset", 0), 0)}
+}
+
+// currentUser is the user ID set by middleware.Protected.
+func currentUser(c *fiber.Ctx) (uuid.UUID, error) {
+	userIDStr, _ := c.Locals("user_id").(string)
+	return uuid.Parse(userIDStr)
+}
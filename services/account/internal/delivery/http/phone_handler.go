@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+
+	"bitka/pkg/response"
+	"bitka/services/account/internal/delivery/http/dto"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PhoneHandler struct {
+	uc domain.PhoneUsecase
+}
+
+func NewPhoneHandler(uc domain.PhoneUsecase) *PhoneHandler {
+	return &PhoneHandler{uc: uc}
+}
+
+// StartVerification texts a code to the number in the body.
+func (h *PhoneHandler) StartVerification(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.PhoneVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	v, err := h.uc.StartVerification(c.UserContext(), userID, req.Phone)
+	if err != nil {
+		return phoneError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(response.APIResponse{Success: true, Data: v})
+}
+
+// ConfirmVerification sets the number once the code matches.
+func (h *PhoneHandler) ConfirmVerification(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.PhoneConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	v, err := h.uc.ConfirmVerification(c.UserContext(), userID, req.Code)
+	if err != nil {
+		return phoneError(c, err)
+	}
+	return response.Success(c, v)
+}
+
+func (h *PhoneHandler) RemovePhone(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.uc.RemovePhone(c.UserContext(), userID); err != nil {
+		return phoneError(c, err)
+	}
+	return response.Success(c, "Phone number removed")
+}
+
+// phoneError maps verification errors to HTTP statuses.
+func phoneError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrProfileNotFound), errors.Is(err, domain.ErrVerificationNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrPhoneInvalid), errors.Is(err, domain.ErrCodeInvalid):
+		return response.Error(c, fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, domain.ErrVerificationLocked):
+		return response.Error(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrPhoneRateLimited):
+		return response.Error(c, fiber.StatusTooManyRequests, err.Error())
+	default:
+		return response.InternalError(c, err)
+	}
+}
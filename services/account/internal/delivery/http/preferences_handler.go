@@ -0,0 +1,49 @@
+package http
+
+import (
+	"errors"
+
+	"bitka/pkg/response"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PreferencesHandler struct {
+	uc domain.PreferencesUsecase
+}
+
+func NewPreferencesHandler(uc domain.PreferencesUsecase) *PreferencesHandler {
+	return &PreferencesHandler{uc: uc}
+}
+
+// GetPreferences returns the stored preferences, or the defaults.
+func (h *PreferencesHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	prefs, err := h.uc.GetPreferences(c.UserContext(), userID)
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, prefs)
+}
+
+// PatchPreferences applies a JSON merge patch (RFC 7396).
+func (h *PreferencesHandler) PatchPreferences(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	prefs, err := h.uc.PatchPreferences(c.UserContext(), userID, c.Body())
+	if errors.Is(err, domain.ErrPreferencesInvalid) {
+		return response.Error(c, fiber.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, prefs)
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"bitka/pkg/response"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PrivacyHandler struct {
+	uc domain.PrivacyUsecase
+}
+
+func NewPrivacyHandler(uc domain.PrivacyUsecase) *PrivacyHandler {
+	return &PrivacyHandler{uc: uc}
+}
+
+// RequestExport queues a personal data export. Poll ListExports until it
+// is ready.
+func (h *PrivacyHandler) RequestExport(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	export, err := h.uc.RequestExport(c.UserContext(), userID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(response.APIResponse{Success: true, Data: export})
+}
+
+func (h *PrivacyHandler) ListExports(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	exports, err := h.uc.ListExports(c.UserContext(), userID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return response.Success(c, exports)
+}
+
+// DownloadExport streams the zip archive of a ready export.
+func (h *PrivacyHandler) DownloadExport(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+	exportID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid export ID")
+	}
+
+	export, body, err := h.uc.OpenExport(c.UserContext(), userID, exportID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="bitka-export-%s.zip"`, export.ID))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	// fasthttp closes the body once it has been sent
+	return c.SendStream(body, int(export.Size))
+}
+
+// RequestDeletion schedules the erasure of the caller's personal data
+// after the cooling-off period.
+func (h *PrivacyHandler) RequestDeletion(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	req, err := h.uc.RequestDeletion(c.UserContext(), userID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(response.APIResponse{Success: true, Data: req})
+}
+
+func (h *PrivacyHandler) GetDeletion(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	req, err := h.uc.GetDeletion(c.UserContext(), userID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return response.Success(c, req)
+}
+
+// CancelDeletion withdraws a request that is still cooling off.
+func (h *PrivacyHandler) CancelDeletion(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	req, err := h.uc.CancelDeletion(c.UserContext(), userID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return response.Success(c, req)
+}
+
+// privacyError maps export and deletion errors to HTTP statuses.
+func privacyError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrExportNotFound), errors.Is(err, domain.ErrDeletionNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrExportNotReady),
+		errors.Is(err, domain.ErrDeletionScheduled),
+		errors.Is(err, domain.ErrDeletionNotPending):
+		return response.Error(c, fiber.StatusConflict, err.Error())
+	default:
+		return response.InternalError(c, err)
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"bitka/pkg/response"
+	"bitka/services/account/internal/delivery/http/dto"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReferralHandler struct {
+	uc domain.ReferralUsecase
+}
+
+func NewReferralHandler(uc domain.ReferralUsecase) *ReferralHandler {
+	return &ReferralHandler{uc: uc}
+}
+
+// GetSummary returns the user's referral code and invite counts.
+func (h *ReferralHandler) GetSummary(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	summary, err := h.uc.GetSummary(c.UserContext(), userID)
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, summary)
+}
+
+// ListInvitees lists the users the current user invited directly.
+func (h *ReferralHandler) ListInvitees(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	page := pageParams(c)
+	users, total, err := h.uc.ListReferred(c.UserContext(), userID, page)
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, dto.ReferredUserList{
+		Items:  users,
+		Total:  total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+}
+
+// GetTree returns the invitees and theirs, ?depth=N levels down.
+func (h *ReferralHandler) GetTree(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	tree, err := h.uc.GetTree(c.UserContext(), userID, c.QueryInt("depth", 0))
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, tree)
+}
@@ -1,14 +1,87 @@
 package http
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"bitka/pkg/middleware"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 // MapRoutes now requires the JWT Middleware
-func MapRoutes(app *fiber.App, h *AccountHandler, authMiddleware fiber.Handler) {
+func MapRoutes(app *fiber.App, h *AccountHandler, kyc *KycHandler, prefs *PreferencesHandler, privacy *PrivacyHandler, phone *PhoneHandler, subs *SubAccountHandler, referrals *ReferralHandler, authMiddleware, adminMiddleware, internalMiddleware fiber.Handler) {
 	api := app.Group("/api/v1")
 
+	// Sub-account tokens (see middleware.RequireScope) are kept off every
+	// route that manages the user; elsewhere their scope is checked
+	parentOnly := middleware.ParentOnly()
+	readScope := middleware.RequireScope(domain.PermissionRead)
+
 	// Apply middleware to this group
 	userGroup := api.Group("/users", authMiddleware)
+	me := userGroup.Group("/me", parentOnly)
+
+	me.Get("/", h.GetProfile)
+	me.Patch("/", h.PatchProfile)
+	me.Get("/changes", h.ListMyChanges)
+	me.Post("/avatar", h.UploadAvatar)
+	me.Delete("/avatar", h.DeleteAvatar)
+	me.Post("/avatar/upload-url", h.CreateAvatarUpload)
+	me.Post("/avatar/complete", h.CompleteAvatarUpload)
+	me.Get("/preferences", prefs.GetPreferences)
+	me.Patch("/preferences", prefs.PatchPreferences)
+	me.Post("/phone", phone.StartVerification)
+	me.Post("/phone/verify", phone.ConfirmVerification)
+	me.Delete("/phone", phone.RemovePhone)
+	me.Post("/exports", privacy.RequestExport)
+	me.Get("/exports", privacy.ListExports)
+	me.Get("/exports/:id/download", privacy.DownloadExport)
+	me.Post("/deletion", privacy.RequestDeletion)
+	me.Get("/deletion", privacy.GetDeletion)
+	me.Delete("/deletion", privacy.CancelDeletion)
+	// Registered after /me so it never shadows it
+	userGroup.Get("/:id", readScope, h.GetPublicProfile)
+
+	// Sub-accounts of the current user, and their API keys
+	subGroup := api.Group("/subaccounts", authMiddleware, parentOnly)
+
+	subGroup.Post("/", subs.Create)
+	subGroup.Get("/", subs.List)
+	subGroup.Get("/:id", subs.Get)
+	subGroup.Patch("/:id", subs.Update)
+	subGroup.Post("/:id/disable", subs.Disable)
+	subGroup.Post("/:id/enable", subs.Enable)
+	subGroup.Post("/:id/api-keys", subs.CreateAPIKey)
+	subGroup.Get("/:id/api-keys", subs.ListAPIKeys)
+	subGroup.Delete("/:id/api-keys/:keyId", subs.RevokeAPIKey)
+
+	// Referral code and the users it brought in
+	referralGroup := api.Group("/referrals", authMiddleware, readScope)
+
+	referralGroup.Get("/", referrals.GetSummary)
+	referralGroup.Get("/invitees", referrals.ListInvitees)
+	referralGroup.Get("/tree", referrals.GetTree)
+
+	// Identity verification of the current user
+	kycGroup := api.Group("/kyc", authMiddleware, parentOnly)
+
+	kycGroup.Get("/", kyc.GetState)
+	kycGroup.Post("/documents", kyc.UploadDocument)
+	kycGroup.Post("/applications", kyc.Submit)
+
+	// Reviewer endpoints
+	admin := api.Group("/admin", authMiddleware, parentOnly, adminMiddleware)
+
+	admin.Get("/users", h.SearchUsers)
+	admin.Get("/users/:id/changes", h.ListUserChanges)
+	admin.Get("/kyc/applications", kyc.ListApplications)
+	admin.Get("/kyc/applications/:id", kyc.GetApplication)
+	admin.Post("/kyc/applications/:id/approve", kyc.Approve)
+	admin.Post("/kyc/applications/:id/reject", kyc.Reject)
+	admin.Get("/kyc/documents/:id", kyc.GetDocument)
+
+	// Service-to-service API (see middleware.InternalOnly)
+	internal := app.Group("/internal/v1", internalMiddleware)
 
-	userGroup.Get("/me", h.GetProfile)
-	userGroup.Put("/me", h.UpdateProfile)
+	internal.Get("/subaccounts/:id", subs.Resolve)
+	internal.Post("/api-keys/verify", subs.VerifyAPIKey)
 }
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// subAccountReadable are the only routes a sub-account token may reach;
+// each needs the read permission.
+var subAccountReadable = map[string]bool{
+	"GET /api/v1/users/:id":          true,
+	"GET /api/v1/referrals/":         true,
+	"GET /api/v1/referrals/invitees": true,
+	"GET /api/v1/referrals/tree":     true,
+}
+
+// TestSubAccountTokensStayOffManagementRoutes walks every public route.
+// Handlers run with nil usecases, so a request that passes the guards
+// panics into a 500; a guard answers 403.
+func TestSubAccountTokensStayOffManagementRoutes(t *testing.T) {
+	// token stands in for Protected
+	token := func(subAccount bool, scope ...string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", "00000000-0000-0000-0000-000000000001")
+			if subAccount {
+				c.Locals("sub_account_id", "00000000-0000-0000-0000-000000000002")
+				c.Locals("scope", scope)
+			}
+			return c.Next()
+		}
+	}
+	pass := func(c *fiber.Ctx) error { return c.Next() }
+
+	newApp := func(auth fiber.Handler) *fiber.App {
+		app := fiber.New()
+		app.Use(recover.New())
+		MapRoutes(app, &AccountHandler{}, &KycHandler{}, &PreferencesHandler{}, &PrivacyHandler{}, &PhoneHandler{},
+			&SubAccountHandler{}, &ReferralHandler{}, auth, pass, pass)
+		return app
+	}
+	user := newApp(token(false))
+	full := newApp(token(true, "read", "trade", "withdraw"))
+	none := newApp(token(true))
+
+	seen := 0
+	for _, r := range full.GetRoutes(true) {
+		if r.Method == fiber.MethodHead || !strings.HasPrefix(r.Path, "/api/") {
+			continue
+		}
+		seen++
+		route := r.Method + " " + r.Path
+		path := strings.NewReplacer(":id", "00000000-0000-0000-0000-000000000003", ":keyId", "00000000-0000-0000-0000-000000000004").Replace(r.Path)
+
+		status := func(app *fiber.App) int {
+			resp, err := app.Test(httptest.NewRequest(r.Method, path, nil))
+			if err != nil {
+				t.Fatalf("%s: %v", route, err)
+			}
+			return resp.StatusCode
+		}
+
+		t.Run(route, func(t *testing.T) {
+			if got := status(user); got == fiber.StatusForbidden {
+				t.Errorf("user token: status %d", got)
+			}
+			readable := subAccountReadable[route]
+			if got := status(full); (got == fiber.StatusForbidden) == readable {
+				t.Errorf("full sub-account scope: status %d, readable %v", got, readable)
+			}
+			if got := status(none); got != fiber.StatusForbidden {
+				t.Errorf("empty sub-account scope: status %d, want 403", got)
+			}
+		})
+	}
+	if seen < 30 {
+		t.Fatalf("only %d routes walked", seen)
+	}
+
+	// Group roots are registered with a trailing slash; clients omit it
+	for _, path := range []string{"/api/v1/users/me", "/api/v1/kyc", "/api/v1/subaccounts"} {
+		resp, err := full.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("GET %s: status %d, want 403", path, resp.StatusCode)
+		}
+	}
+}
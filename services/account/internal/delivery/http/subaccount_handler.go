@@ -0,0 +1,216 @@
+package http
+
+import (
+	"errors"
+
+	"bitka/pkg/response"
+	"bitka/services/account/internal/delivery/http/dto"
+	"bitka/services/account/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SubAccountHandler struct {
+	uc domain.SubAccountUsecase
+}
+
+func NewSubAccountHandler(uc domain.SubAccountUsecase) *SubAccountHandler {
+	return &SubAccountHandler{uc: uc}
+}
+
+func (h *SubAccountHandler) Create(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.SubAccountCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	sub, err := h.uc.CreateSubAccount(c.UserContext(), userID, req.Label, req.Permissions)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.APIResponse{Success: true, Data: sub})
+}
+
+func (h *SubAccountHandler) List(c *fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	subs, err := h.uc.ListSubAccounts(c.UserContext(), userID)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, subs)
+}
+
+func (h *SubAccountHandler) Get(c *fiber.Ctx) error {
+	userID, id, err := subAccountTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	sub, err := h.uc.GetSubAccount(c.UserContext(), userID, id)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, sub)
+}
+
+// Update changes the label and/or the permissions.
+func (h *SubAccountHandler) Update(c *fiber.Ctx) error {
+	userID, id, err := subAccountTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var req dto.SubAccountUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	sub, err := h.uc.UpdateSubAccount(c.UserContext(), userID, id, domain.SubAccountUpdate{
+		Label:       req.Label,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, sub)
+}
+
+func (h *SubAccountHandler) Disable(c *fiber.Ctx) error {
+	return h.setEnabled(c, false)
+}
+
+func (h *SubAccountHandler) Enable(c *fiber.Ctx) error {
+	return h.setEnabled(c, true)
+}
+
+func (h *SubAccountHandler) setEnabled(c *fiber.Ctx, enabled bool) error {
+	userID, id, err := subAccountTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	sub, err := h.uc.SetSubAccountEnabled(c.UserContext(), userID, id, enabled)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, sub)
+}
+
+// Resolve serves the internal ownership lookup: any service holding a
+// sub-account ID learns its parent, permissions and whether it is enabled.
+func (h *SubAccountHandler) Resolve(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid sub-account ID")
+	}
+
+	sub, err := h.uc.ResolveSubAccount(c.UserContext(), id)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, sub)
+}
+
+// CreateAPIKey issues a key acting as the sub-account; the secret is in
+// this response only.
+func (h *SubAccountHandler) CreateAPIKey(c *fiber.Ctx) error {
+	userID, id, err := subAccountTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var req dto.APIKeyCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	key, secret, err := h.uc.CreateAPIKey(c.UserContext(), userID, id, req.Label, req.Permissions)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.APIResponse{
+		Success: true,
+		Data:    dto.APIKeyCreated{APIKey: key, Secret: secret},
+	})
+}
+
+func (h *SubAccountHandler) ListAPIKeys(c *fiber.Ctx) error {
+	userID, id, err := subAccountTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	keys, err := h.uc.ListAPIKeys(c.UserContext(), userID, id)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, keys)
+}
+
+func (h *SubAccountHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	userID, id, err := subAccountTarget(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+	keyID, err := uuid.Parse(c.Params("keyId"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid API key ID")
+	}
+
+	key, err := h.uc.RevokeAPIKey(c.UserContext(), userID, id, keyID)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, key)
+}
+
+// VerifyAPIKey serves the internal secret lookup auth uses to exchange a
+// key for a token. The secret travels in the body, never in a URL.
+func (h *SubAccountHandler) VerifyAPIKey(c *fiber.Ctx) error {
+	var req dto.APIKeyVerifyRequest
+	if err := c.BodyParser(&req); err != nil || req.Secret == "" {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	resolved, err := h.uc.VerifyAPIKey(c.UserContext(), req.Secret)
+	if err != nil {
+		return subAccountError(c, err)
+	}
+	return response.Success(c, resolved)
+}
+
+func subAccountTarget(c *fiber.Ctx) (userID, id uuid.UUID, err error) {
+	if userID, err = currentUser(c); err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid user ID")
+	}
+	if id, err = uuid.Parse(c.Params("id")); err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("Invalid sub-account ID")
+	}
+	return userID, id, nil
+}
+
+// subAccountError maps sub-account errors to HTTP statuses.
+func subAccountError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrSubAccountNotFound), errors.Is(err, domain.ErrProfileNotFound),
+		errors.Is(err, domain.ErrAPIKeyNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrSubAccountInvalid):
+		return response.Error(c, fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, domain.ErrSubAccountLabelTaken), errors.Is(err, domain.ErrSubAccountLimit),
+		errors.Is(err, domain.ErrAPIKeyLimit):
+		return response.Error(c, fiber.StatusConflict, err.Error())
+	default:
+		return response.InternalError(c, err)
+	}
+}
@@ -0,0 +1,247 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitka/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// KycStatus is the state of a user's identity verification.
+type KycStatus string
+
+const (
+	KycNone     KycStatus = events.KycStatusNone
+	KycPending  KycStatus = events.KycStatusPending
+	KycApproved KycStatus = events.KycStatusApproved
+	KycRejected KycStatus = events.KycStatusRejected
+	KycExpired  KycStatus = events.KycStatusExpired
+)
+
+// kycTransitions lists the allowed moves of the state machine.
+// An approved user goes back to pending when applying for a higher level.
+var kycTransitions = map[KycStatus][]KycStatus{
+	KycNone:     {KycPending},
+	KycPending:  {KycApproved, KycRejected},
+	KycApproved: {KycPending, KycExpired},
+	KycRejected: {KycPending},
+	KycExpired:  {KycPending},
+}
+
+// CanTransition reports whether the state machine allows s -> to.
+func (s KycStatus) CanTransition(to KycStatus) bool {
+	for _, next := range kycTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// KycLevel is a verification tier. Other services gate limits on it.
+type KycLevel int
+
+const (
+	KycLevel0 KycLevel = iota // unverified
+	KycLevel1                 // personal details
+	KycLevel2                 // identity document + selfie
+	KycLevel3                 // proof of address
+)
+
+// MaxKycLevel is the highest tier a user can apply for.
+const MaxKycLevel = KycLevel3
+
+// KycDocumentType is the kind of file attached to an application.
+type KycDocumentType string
+
+const (
+	DocPassport       KycDocumentType = "passport"
+	DocIDCard         KycDocumentType = "id_card"
+	DocDriversLicense KycDocumentType = "drivers_license"
+	DocSelfie         KycDocumentType = "selfie"
+	DocProofOfAddress KycDocumentType = "proof_of_address"
+)
+
+// Valid reports whether t is a known document type.
+func (t KycDocumentType) Valid() bool {
+	switch t {
+	case DocPassport, DocIDCard, DocDriversLicense, DocSelfie, DocProofOfAddress:
+		return true
+	}
+	return false
+}
+
+func (t KycDocumentType) isIdentity() bool {
+	return t == DocPassport || t == DocIDCard || t == DocDriversLicense
+}
+
+// MissingDocuments returns what docs lack for level, as a readable list.
+func (l KycLevel) MissingDocuments(docs []KycDocument) []string {
+	var identity, selfie, address bool
+	for _, d := range docs {
+		switch {
+		case d.Type.isIdentity():
+			identity = true
+		case d.Type == DocSelfie:
+			selfie = true
+		case d.Type == DocProofOfAddress:
+			address = true
+		}
+	}
+
+	var missing []string
+	if l >= KycLevel2 && !identity {
+		missing = append(missing, "identity document")
+	}
+	if l >= KycLevel2 && !selfie {
+		missing = append(missing, string(DocSelfie))
+	}
+	if l >= KycLevel3 && !address {
+		missing = append(missing, string(DocProofOfAddress))
+	}
+	return missing
+}
+
+// KycApplication is one submission for a level. The latest application
+// drives the status on the profile; older ones are kept for audit.
+type KycApplication struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	Level  KycLevel  `json:"level"`
+	Status KycStatus `json:"status"`
+
+	// Personal details
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	DateOfBirth time.Time `gorm:"type:date" json:"date_of_birth"`
+	Country     string    `json:"country"` // ISO 3166-1 alpha-2
+
+	Provider    string     `json:"provider"`
+	ProviderRef string     `json:"provider_ref,omitempty"`
+	Reason      string     `json:"reason,omitempty"` // rejection reason
+	ReviewerID  *uuid.UUID `gorm:"type:uuid" json:"reviewer_id,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+
+	Documents []KycDocument `gorm:"foreignKey:ApplicationID" json:"documents,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KycDocument is an uploaded file. Documents are uploaded before the
+// application exists and attached to it on submit.
+type KycDocument struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	UserID        uuid.UUID       `gorm:"type:uuid;index" json:"user_id"`
+	ApplicationID *uuid.UUID      `gorm:"type:uuid" json:"application_id,omitempty"`
+	Type          KycDocumentType `json:"type"`
+	FileName      string          `json:"file_name"`
+	ContentType   string          `json:"content_type"`
+	Size          int64           `json:"size"`
+	SHA256        string          `gorm:"column:sha256" json:"sha256"`
+	StorageKey    string          `json:"-"`
+	// Content is filled on download. Rows written before documents moved
+	// to the media store also keep it in the table.
+	Content   []byte    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KycSubmission is what a user sends to apply for a level.
+type KycSubmission struct {
+	Level       KycLevel
+	FirstName   string
+	LastName    string
+	DateOfBirth time.Time
+	Country     string
+}
+
+// KycState is the verification summary shown to the user.
+type KycState struct {
+	Status      KycStatus       `json:"status"`
+	Level       KycLevel        `json:"level"`
+	Application *KycApplication `json:"application,omitempty"`
+}
+
+// KycDecision is a provider's verdict. Pending means a human must review.
+type KycDecision struct {
+	Status    KycStatus
+	Reason    string
+	Reference string
+}
+
+// KycProvider verifies a submitted application (e.g. an external
+// identity-verification vendor). Implementations live in repository/kycprovider.
+type KycProvider interface {
+	Name() string
+	Verify(ctx context.Context, app *KycApplication) (KycDecision, error)
+}
+
+// KycEventPublisher emits KycUpdated in the caller's transaction.
+type KycEventPublisher interface {
+	PublishKycUpdated(ctx context.Context, event events.KycUpdated) error
+}
+
+var (
+	ErrKycTransition       = errors.New("kyc: status change not allowed")
+	ErrKycInvalidLevel     = errors.New("kyc: invalid level")
+	ErrKycIncomplete       = errors.New("kyc: submission incomplete")
+	ErrKycNotFound         = errors.New("kyc: not found")
+	ErrKycDocumentType     = errors.New("kyc: unknown document type")
+	ErrKycDocumentTooLarge = errors.New("kyc: document too large")
+	ErrKycSelfReview       = errors.New("kyc: reviewers cannot decide their own application")
+)
+
+// KycFilter selects applications for the review queue.
+type KycFilter struct {
+	UserID uuid.UUID // zero matches every user
+	Status KycStatus
+	Limit  int
+	Offset int
+}
+
+type KycRepository interface {
+	CreateDocument(ctx context.Context, doc *KycDocument) error
+	GetDocument(ctx context.Context, id uuid.UUID) (*KycDocument, error)
+	// PendingDocuments are uploaded by userID but not yet attached.
+	PendingDocuments(ctx context.Context, userID uuid.UUID) ([]KycDocument, error)
+	AttachDocuments(ctx context.Context, userID, applicationID uuid.UUID) error
+
+	CreateApplication(ctx context.Context, app *KycApplication) error
+	UpdateApplication(ctx context.Context, app *KycApplication) error
+	// GetApplication loads the application with its document metadata
+	// and locks the row when called inside a transaction.
+	GetApplication(ctx context.Context, id uuid.UUID) (*KycApplication, error)
+	LatestApplication(ctx context.Context, userID uuid.UUID) (*KycApplication, error)
+	ListApplications(ctx context.Context, filter KycFilter) ([]KycApplication, int64, error)
+	// SupersedeApprovals stops older approvals of userID from expiring
+	// once keepID is the effective one.
+	SupersedeApprovals(ctx context.Context, userID, keepID uuid.UUID) error
+	// DueForExpiry returns approved applications whose ExpiresAt has passed.
+	DueForExpiry(ctx context.Context, now time.Time, limit int) ([]KycApplication, error)
+
+	// GetProfileKyc reads the effective status and level, locking the
+	// profile row when called inside a transaction.
+	GetProfileKyc(ctx context.Context, userID uuid.UUID) (KycStatus, KycLevel, error)
+	// SetProfileKyc stores the effective status and level on the profile.
+	SetProfileKyc(ctx context.Context, userID uuid.UUID, status KycStatus, level KycLevel) error
+}
+
+type KycUsecase interface {
+	UploadDocument(ctx context.Context, userID uuid.UUID, doc *KycDocument) error
+	Submit(ctx context.Context, userID uuid.UUID, sub KycSubmission) (*KycApplication, error)
+	GetState(ctx context.Context, userID uuid.UUID) (*KycState, error)
+
+	// Reviewer endpoints
+	ListApplications(ctx context.Context, filter KycFilter) ([]KycApplication, int64, error)
+	GetApplication(ctx context.Context, id uuid.UUID) (*KycApplication, error)
+	GetDocument(ctx context.Context, id uuid.UUID) (*KycDocument, error)
+	Approve(ctx context.Context, id, reviewerID uuid.UUID) (*KycApplication, error)
+	Reject(ctx context.Context, id, reviewerID uuid.UUID, reason string) (*KycApplication, error)
+
+	// ExpireDue moves approvals past their validity to expired.
+	ExpireDue(ctx context.Context) (int, error)
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "testing"
+
+func TestKycCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to KycStatus
+		want     bool
+	}{
+		{KycNone, KycPending, true},
+		{KycNone, KycApproved, false},
+		{KycPending, KycApproved, true},
+		{KycPending, KycRejected, true},
+		{KycPending, KycExpired, false},
+		{KycPending, KycPending, false},
+		{KycApproved, KycPending, true}, // applying for a higher level
+		{KycApproved, KycExpired, true},
+		{KycApproved, KycRejected, false},
+		{KycRejected, KycPending, true},
+		{KycRejected, KycApproved, false},
+		{KycExpired, KycPending, true},
+		{KycExpired, KycApproved, false},
+		{KycStatus("unknown"), KycPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Fatalf("CanTransition = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"errors"
+
+	"bitka/pkg/storage"
+)
+
+// Object key prefixes in the media store. Only avatars are public.
+const (
+	AvatarPrefix = "avatars/"
+	UploadPrefix = "uploads/" // pre-signed uploads waiting to be processed
+	KycPrefix    = "kyc/"
+	ExportPrefix = "exports/" // personal data archives, downloaded through the API
+)
+
+// AvatarUpload is a pre-signed direct upload. Key is passed back to
+// CompleteAvatarUpload once the client has uploaded the file.
+type AvatarUpload struct {
+	Key     string                   `json:"key"`
+	Request storage.PresignedRequest `json:"request"`
+	MaxSize int64                    `json:"max_size"`
+}
+
+var (
+	ErrMediaTooLarge   = errors.New("media: file too large")
+	ErrMediaType       = errors.New("media: unsupported file type")
+	ErrUploadNotFound  = errors.New("media: upload not found")
+	ErrPresignDisabled = errors.New("media: direct uploads are not available with this storage driver")
+)
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneVerification is a one-time code sent by SMS to prove the user
+// controls a number. The number only reaches the profile once confirmed.
+type PhoneVerification struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;index" json:"-"`
+	Phone      string     `gorm:"serializer:encrypted" json:"phone"`
+	CodeHash   string     `json:"-"`
+	Attempts   int        `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+var (
+	ErrPhoneInvalid         = errors.New("phone number must be in international format, e.g. +48123456789")
+	ErrPhoneRateLimited     = errors.New("too many verification codes requested; try again later")
+	ErrVerificationNotFound = errors.New("no pending phone verification")
+	ErrVerificationLocked   = errors.New("too many wrong codes; request a new one")
+	ErrCodeInvalid          = errors.New("verification code is invalid")
+)
+
+// SmsSender delivers text messages (see repository/sms).
+type SmsSender interface {
+	Name() string
+	Send(ctx context.Context, to, message string) error
+}
+
+type PhoneRepository interface {
+	CreateVerification(ctx context.Context, v *PhoneVerification) error
+	UpdateVerification(ctx context.Context, v *PhoneVerification) error
+	// LatestVerification returns the user's newest unverified code; it
+	// locks the row inside a transaction.
+	LatestVerification(ctx context.Context, userID uuid.UUID) (*PhoneVerification, error)
+	// CountVerificationsSince counts the codes sent to the user since t.
+	CountVerificationsSince(ctx context.Context, userID uuid.UUID, t time.Time) (int64, error)
+}
+
+type PhoneUsecase interface {
+	// StartVerification sends a code to phone.
+	StartVerification(ctx context.Context, userID uuid.UUID, phone string) (*PhoneVerification, error)
+	// ConfirmVerification checks code against the latest verification and
+	// sets the number on the profile.
+	ConfirmVerification(ctx context.Context, userID uuid.UUID, code string) (*PhoneVerification, error)
+	// RemovePhone clears the number from the profile.
+	RemovePhone(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitka/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// Theme is the UI colour scheme.
+type Theme string
+
+const (
+	ThemeSystem Theme = "system"
+	ThemeLight  Theme = "light"
+	ThemeDark   Theme = "dark"
+)
+
+// Valid reports whether t is a known theme.
+func (t Theme) Valid() bool {
+	return t == ThemeSystem || t == ThemeLight || t == ThemeDark
+}
+
+// DisplayCurrencies are the currencies balances can be shown in.
+var DisplayCurrencies = []string{"USD", "EUR", "PLN", "GBP", "BTC", "USDT"}
+
+// NotificationChannels and NotificationSettings are shared with the
+// PreferencesChanged event, so consumers see exactly what is stored.
+type (
+	NotificationChannels = events.NotificationChannels
+	NotificationSettings = events.NotificationSettings
+)
+
+// Preferences are per-user settings. A user without a stored row gets
+// DefaultPreferences.
+type Preferences struct {
+	UserID          uuid.UUID            `gorm:"type:uuid;primary_key" json:"-"`
+	Locale          string               `json:"locale"`
+	Timezone        string               `json:"timezone"`
+	DisplayCurrency string               `json:"display_currency"`
+	Theme           Theme                `json:"theme"`
+	Notifications   NotificationSettings `gorm:"type:jsonb;serializer:json" json:"notifications"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// DefaultPreferences are what a new user starts with. SMS is opt-in for
+// every notification type, and trade fills only push.
+func DefaultPreferences(userID uuid.UUID) *Preferences {
+	return &Preferences{
+		UserID:          userID,
+		Locale:          "en",
+		Timezone:        "UTC",
+		DisplayCurrency: "USD",
+		Theme:           ThemeSystem,
+		Notifications: NotificationSettings{
+			Login:      NotificationChannels{Email: true, Push: true},
+			Deposit:    NotificationChannels{Email: true, Push: true},
+			TradeFill:  NotificationChannels{Push: true},
+			Withdrawal: NotificationChannels{Email: true, Push: true},
+		},
+	}
+}
+
+var (
+	ErrPreferencesInvalid  = errors.New("preferences: invalid value")
+	ErrPreferencesNotFound = errors.New("preferences: not found")
+)
+
+type PreferencesRepository interface {
+	// GetPreferences returns ErrPreferencesNotFound when nothing is stored,
+	// and locks the row when called inside a transaction.
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error)
+	// InitPreferences stores prefs only if the user has no row yet.
+	InitPreferences(ctx context.Context, prefs *Preferences) error
+	SavePreferences(ctx context.Context, prefs *Preferences) error
+}
+
+// PreferencesEventPublisher emits PreferencesChanged in the caller's transaction.
+type PreferencesEventPublisher interface {
+	PublishPreferencesChanged(ctx context.Context, event events.PreferencesChanged) error
+}
+
+type PreferencesUsecase interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error)
+	// PatchPreferences applies a JSON merge patch; nested notification
+	// settings merge field by field.
+	PatchPreferences(ctx context.Context, userID uuid.UUID, patch []byte) (*Preferences, error)
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"bitka/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// ExportStatus is the state of a personal data export.
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"
+	ExportProcessing ExportStatus = "processing" // claimed by a worker until ClaimedUntil
+	ExportReady      ExportStatus = "ready"
+	ExportFailed     ExportStatus = "failed"
+	ExportExpired    ExportStatus = "expired" // archive deleted after its retention
+)
+
+// DataExport is a request for a user's personal data. A background job
+// assembles the archive; the user downloads it until ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	UserID      uuid.UUID    `gorm:"type:uuid;index" json:"user_id"`
+	Status      ExportStatus `json:"status"`
+	StorageKey  string       `json:"-"`
+	Size        int64        `json:"size,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"`
+
+	ClaimedUntil *time.Time `json:"-"` // a crashed worker's claim lapses after this
+}
+
+// DeletionStatus is the state of an account deletion request.
+type DeletionStatus string
+
+const (
+	DeletionScheduled DeletionStatus = "scheduled" // cooling off; the user may cancel
+	DeletionCancelled DeletionStatus = "cancelled"
+	DeletionErasing   DeletionStatus = "erasing" // account data pseudonymised, waiting for other services
+	DeletionCompleted DeletionStatus = "completed"
+)
+
+// DeletionRequest asks for the user's personal data to be erased once
+// ScheduledFor has passed. Erasure pseudonymises rather than deletes, so
+// financial and KYC records the law requires us to keep still resolve.
+type DeletionRequest struct {
+	ID           uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	UserID       uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	Status       DeletionStatus `json:"status"`
+	ScheduledFor time.Time      `json:"scheduled_for"`
+	CreatedAt    time.Time      `json:"created_at"`
+	CancelledAt  *time.Time     `json:"cancelled_at,omitempty"`
+	ErasedAt     *time.Time     `json:"erased_at,omitempty"` // account data pseudonymised
+	// Services that answered with UserErased
+	ConfirmedBy []string   `gorm:"type:jsonb;serializer:json" json:"confirmed_by,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // every service confirmed
+}
+
+// Pseudonym is the username an erased user is left with. Auth derives the
+// same one, so both services agree.
+func Pseudonym(id uuid.UUID) string {
+	return "deleted_" + strings.ReplaceAll(id.String(), "-", "")
+}
+
+// ErasingServices must confirm with UserErased before a deletion completes.
+var ErasingServices = []string{"auth"}
+
+var (
+	ErrExportNotFound     = errors.New("export: not found")
+	ErrExportNotReady     = errors.New("export: not ready")
+	ErrDeletionNotFound   = errors.New("deletion: not found")
+	ErrDeletionScheduled  = errors.New("deletion: already requested")
+	ErrDeletionNotPending = errors.New("deletion: can no longer be cancelled")
+)
+
+// AuthDirectory reads what the auth service holds about users, through
+// its internal API.
+type AuthDirectory interface {
+	// ExportUser returns the auth part of a data export as JSON.
+	ExportUser(ctx context.Context, userID uuid.UUID) (json.RawMessage, error)
+	// ListUsers returns up to limit users with an ID greater than after,
+	// in ID order, and the cursor of the next page (uuid.Nil after the
+	// last one).
+	ListUsers(ctx context.Context, after uuid.UUID, limit int) ([]AuthUser, uuid.UUID, error)
+}
+
+// PrivacyEventPublisher emits UserErasureRequested in the caller's transaction.
+type PrivacyEventPublisher interface {
+	PublishUserErasureRequested(ctx context.Context, event events.UserErasureRequested) error
+}
+
+type PrivacyRepository interface {
+	CreateExport(ctx context.Context, export *DataExport) error
+	UpdateExport(ctx context.Context, export *DataExport) error
+	// GetExport locks the row when called inside a transaction.
+	GetExport(ctx context.Context, id uuid.UUID) (*DataExport, error)
+	ListExports(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	// NextPendingExport locks the oldest pending export, or a processing
+	// one whose claim has lapsed, skipping rows other instances hold.
+	// It must run inside a transaction.
+	NextPendingExport(ctx context.Context, now time.Time) (*DataExport, error)
+	// FailOpenExports marks the pending and processing exports of userID
+	// failed with reason.
+	FailOpenExports(ctx context.Context, userID uuid.UUID, reason string) error
+	// ExpiredExports returns ready exports whose ExpiresAt has passed.
+	ExpiredExports(ctx context.Context, now time.Time, limit int) ([]DataExport, error)
+
+	CreateDeletion(ctx context.Context, req *DeletionRequest) error
+	UpdateDeletion(ctx context.Context, req *DeletionRequest) error
+	GetDeletion(ctx context.Context, id uuid.UUID) (*DeletionRequest, error)
+	// ActiveDeletion returns the scheduled or erasing request of userID,
+	// locked when called inside a transaction.
+	ActiveDeletion(ctx context.Context, userID uuid.UUID) (*DeletionRequest, error)
+	LatestDeletion(ctx context.Context, userID uuid.UUID) (*DeletionRequest, error)
+	// DueDeletions returns scheduled requests whose cooling-off has passed.
+	DueDeletions(ctx context.Context, now time.Time, limit int) ([]DeletionRequest, error)
+
+	// ErasePersonalData pseudonymises the profile, drops the preferences
+	// and blanks the values in the change log. KYC records are kept.
+	ErasePersonalData(ctx context.Context, userID uuid.UUID, erasedAt time.Time) error
+}
+
+type PrivacyUsecase interface {
+	RequestExport(ctx context.Context, userID uuid.UUID) (*DataExport, error)
+	ListExports(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	// OpenExport returns the archive of a ready export owned by userID.
+	// The caller closes the reader.
+	OpenExport(ctx context.Context, userID, exportID uuid.UUID) (*DataExport, io.ReadCloser, error)
+
+	RequestDeletion(ctx context.Context, userID uuid.UUID) (*DeletionRequest, error)
+	GetDeletion(ctx context.Context, userID uuid.UUID) (*DeletionRequest, error)
+	CancelDeletion(ctx context.Context, userID uuid.UUID) (*DeletionRequest, error)
+	// ConfirmErasure records a UserErased from another service.
+	ConfirmErasure(ctx context.Context, event events.UserErased) error
+
+	// Background jobs; each returns how many items it handled.
+	ProcessExports(ctx context.Context) (int, error)
+	ExpireExports(ctx context.Context) (int, error)
+	ProcessDeletions(ctx context.Context) (int, error)
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,23 +12,118 @@ import (
 // Profile is specific to Account Service.
 // It shares the same ID as Auth User, but lives in a different DB.
 type Profile struct {
-	UserID    uuid.UUID `gorm:"type:uuid;primary_key"`
-	Email     string
-	Username  string
-	FullName  string
-	AvatarURL string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	UserID   uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	// Object keys in the media store; the service never stores client URLs
+	AvatarKey      string `json:"-"`
+	AvatarThumbKey string `json:"-"`
+	// Effective verification, maintained by the KYC workflow
+	KycStatus KycStatus `gorm:"default:none" json:"kyc_status"`
+	KycLevel  KycLevel  `gorm:"default:0" json:"kyc_level"`
+
+	// Personal details, encrypted at rest (see pkg/encryption). The phone
+	// is only set through verification.
+	Address         *Address   `gorm:"serializer:encrypted" json:"address,omitempty"`
+	Nationality     string     `gorm:"serializer:encrypted" json:"nationality,omitempty"`   // ISO 3166-1 alpha-2
+	DateOfBirth     string     `gorm:"serializer:encrypted" json:"date_of_birth,omitempty"` // YYYY-MM-DD
+	Phone           string     `gorm:"serializer:encrypted" json:"phone,omitempty"`         // E.164
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Resolved from the keys on read
+	AvatarURL      string `gorm:"-" json:"avatar_url,omitempty"`
+	AvatarThumbURL string `gorm:"-" json:"avatar_thumb_url,omitempty"`
+}
+
+// Address is a residential address.
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	Region     string `json:"region,omitempty"` // state, province or county
+	Country    string `json:"country"`          // ISO 3166-1 alpha-2
+}
+
+// MinimumAge is the age a date of birth must imply.
+const MinimumAge = 18
+
+var ErrProfileNotFound = errors.New("profile not found")
+
+// PublicProfile is what any signed-in user may see of another user.
+// It never carries contact details.
+type PublicProfile struct {
+	UserID         uuid.UUID `json:"id"`
+	Username       string    `json:"username"`
+	AvatarURL      string    `json:"avatar_url,omitempty"`
+	AvatarThumbURL string    `json:"avatar_thumb_url,omitempty"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
+// Public projects p onto the fields other users may see.
+func (p *Profile) Public() *PublicProfile {
+	return &PublicProfile{
+		UserID:         p.UserID,
+		Username:       p.Username,
+		AvatarURL:      p.AvatarURL,
+		AvatarThumbURL: p.AvatarThumbURL,
+		JoinedAt:       p.CreatedAt,
+	}
+}
+
+// ETag identifies this version of the profile for If-Match. UpdatedAt is
+// stored with microsecond precision, so that is what the tag carries.
+func (p *Profile) ETag() string {
+	return fmt.Sprintf(`"%d"`, p.UpdatedAt.UnixMicro())
 }
 
 type AccountRepository interface {
-	GetProfile(userID uuid.UUID) (*Profile, error)
-	CreateProfile(userID uuid.UUID, email string, username string) error
-	UpsertProfile(profile *Profile) error
+	// GetProfile returns ErrProfileNotFound when the user has no profile.
+	GetProfile(ctx context.Context, userID uuid.UUID) (*Profile, error)
+	// GetProfileForUpdate reads the primary and locks the row when called
+	// inside a transaction.
+	GetProfileForUpdate(ctx context.Context, userID uuid.UUID) (*Profile, error)
+	// GetProfileByUsername matches case-insensitively.
+	GetProfileByUsername(ctx context.Context, username string) (*Profile, error)
+	// SearchProfiles matches query against email, username and full name.
+	// An empty query lists every profile, newest first.
+	SearchProfiles(ctx context.Context, query string, page Page) ([]Profile, int64, error)
+	CreateProfile(ctx context.Context, userID uuid.UUID, email string, username string) error
+	// ListProfileRange returns the profiles with after < user_id <= until,
+	// ordered by user ID.
+	ListProfileRange(ctx context.Context, after, until uuid.UUID) ([]Profile, error)
+	// UpdateProfileFields writes the given columns of profile, and
+	// updated_at. Values go through the model, so encrypted fields are
+	// sealed.
+	UpdateProfileFields(ctx context.Context, profile *Profile, columns ...string) error
+
+	AppendChanges(ctx context.Context, changes []ProfileChange) error
+	ListChanges(ctx context.Context, userID uuid.UUID, page Page) ([]ProfileChange, int64, error)
 }
 
 type AccountUsecase interface {
-	GetMyProfile(userID uuid.UUID) (*Profile, error)
-	UpdateMyProfile(userID uuid.UUID, fullName, avatar string) error
-	CreateUserProfile(userID uuid.UUID, email, username string) error
+	GetMyProfile(ctx context.Context, userID uuid.UUID) (*Profile, error)
+	// PatchMyProfile applies an RFC 7396 merge patch. ifMatch is the
+	// If-Match header; empty skips the version check.
+	PatchMyProfile(ctx context.Context, userID uuid.UUID, patch ProfilePatch, ifMatch string) (*Profile, error)
+	CreateUserProfile(ctx context.Context, userID uuid.UUID, email, username string) error
+
+	// GetPublicProfile looks a user up by ID or, failing that, by username.
+	GetPublicProfile(ctx context.Context, idOrUsername string) (*PublicProfile, error)
+	// SearchProfiles is the admin search across email, username and name.
+	SearchProfiles(ctx context.Context, query string, page Page) ([]Profile, int64, error)
+
+	// Avatar: either upload through the service, or get a pre-signed URL,
+	// upload directly to the store, then complete with the returned key.
+	UploadAvatar(ctx context.Context, userID uuid.UUID, data []byte) (*Profile, error)
+	CreateAvatarUpload(ctx context.Context, userID uuid.UUID, contentType string) (*AvatarUpload, error)
+	CompleteAvatarUpload(ctx context.Context, userID uuid.UUID, key string) (*Profile, error)
+	DeleteAvatar(ctx context.Context, userID uuid.UUID) error
+
+	// ListChanges returns the change log of userID, newest first.
+	ListChanges(ctx context.Context, userID uuid.UUID, page Page) ([]ProfileChange, int64, error)
 }
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProfilePatch is an RFC 7396 merge patch: a field set to null is cleared,
+// a missing field is left alone.
+type ProfilePatch map[string]json.RawMessage
+
+var (
+	ErrPatchInvalid       = errors.New("invalid profile patch")
+	ErrPreconditionFailed = errors.New("profile was modified by another request")
+)
+
+// Change sources
+const (
+	ChangeSourceUser   = "user"
+	ChangeSourceSystem = "system"
+)
+
+// ProfileChange is one row of the append-only change log. Values are JSON,
+// so a cleared field (null) differs from an empty string.
+type ProfileChange struct {
+	ID        int64           `gorm:"primaryKey" json:"id"`
+	UserID    uuid.UUID       `gorm:"type:uuid" json:"user_id"`
+	Field     string          `json:"field"`
+	OldValue  json.RawMessage `gorm:"type:jsonb" json:"old_value"`
+	NewValue  json.RawMessage `gorm:"type:jsonb" json:"new_value"`
+	ActorID   *uuid.UUID      `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Source    string          `json:"source"`
+	TraceID   string          `json:"trace_id,omitempty"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// Page is an offset page of a list endpoint.
+type Page struct {
+	Limit  int
+	Offset int
+}
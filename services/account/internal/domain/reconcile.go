@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthUser is a user as listed by the auth service's internal directory.
+type AuthUser struct {
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	Username     string    `json:"username"`
+	ReferralCode string    `json:"referral_code"` // entered at registration
+	CreatedAt    time.Time `json:"created_at"`
+	Erased       bool      `json:"erased"`
+}
+
+// Drift is one kind of disagreement between auth and account. Sample
+// holds the first IDs found; Count is the total.
+type Drift struct {
+	Count  int         `json:"count"`
+	Sample []uuid.UUID `json:"sample,omitempty"`
+}
+
+// DriftSampleSize bounds Drift.Sample.
+const DriftSampleSize = 100
+
+// Add counts id and keeps it in the sample while there is room.
+func (d *Drift) Add(id uuid.UUID) {
+	d.Count++
+	if len(d.Sample) < DriftSampleSize {
+		d.Sample = append(d.Sample, id)
+	}
+}
+
+// ReconcileReport is the outcome of comparing auth users with profiles.
+// Missing counts what was found, including the profiles then backfilled.
+type ReconcileReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DryRun     bool      `json:"dry_run"`
+
+	AuthUsers int `json:"auth_users"`
+	Profiles  int `json:"profiles"`
+
+	Missing    Drift `json:"missing"`    // registered users without a profile
+	Orphaned   Drift `json:"orphaned"`   // profiles of users auth does not know
+	Mismatched Drift `json:"mismatched"` // email or username differ
+	Backfilled int   `json:"backfilled"`
+}
+
+// ReconcileUsecase finds and repairs profiles that drifted from auth,
+// e.g. because a UserRegistered event was never consumed.
+type ReconcileUsecase interface {
+	// Reconcile walks every auth user once. With dryRun it only reports;
+	// otherwise it creates the missing profiles and attributes them to
+	// the referral code entered at registration. Orphans and mismatches
+	// are reported, never changed.
+	Reconcile(ctx context.Context, dryRun bool) (*ReconcileReport, error)
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Referral codes are ReferralCodeLength characters of ReferralAlphabet,
+// which leaves out 0/O and 1/I so codes survive being read aloud.
+const (
+	ReferralCodeLength = 8
+	ReferralAlphabet   = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ReferralCode is the code a user shares to invite others. It is created
+// on first use and deleted when the user is erased.
+type ReferralCode struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Referral attributes a registration to the user whose code was entered.
+// A user is referred at most once, so referrals form a tree.
+type Referral struct {
+	RefereeID  uuid.UUID `gorm:"type:uuid;primary_key"`
+	ReferrerID uuid.UUID `gorm:"type:uuid"`
+	Code       string
+	CreatedAt  time.Time
+}
+
+// ReferralStatus is how far an invited user got.
+type ReferralStatus string
+
+const (
+	ReferralRegistered ReferralStatus = "registered"
+	ReferralVerified   ReferralStatus = "verified" // identity verification approved
+	ReferralClosed     ReferralStatus = "closed"   // account deleted
+)
+
+// ReferredUser is an invited user as their referrer sees them. Depth is 1
+// for users the referrer invited, 2 for the users those invited, etc.
+// Username and Status are only set at depth 1; deeper users are counted
+// but not named.
+type ReferredUser struct {
+	UserID     uuid.UUID      `json:"user_id"`
+	ReferrerID uuid.UUID      `json:"referrer_id"`
+	Username   string         `json:"username,omitempty"`
+	Status     ReferralStatus `gorm:"-" json:"status,omitempty"`
+	KycStatus  KycStatus      `json:"-"`
+	Depth      int            `json:"depth"`
+	JoinedAt   time.Time      `json:"joined_at"`
+}
+
+// ResolveStatus sets Status from the profile fields read with the user.
+func (u *ReferredUser) ResolveStatus() {
+	switch {
+	case u.Username == Pseudonym(u.UserID):
+		u.Status = ReferralClosed
+	case u.KycStatus == KycApproved:
+		u.Status = ReferralVerified
+	default:
+		u.Status = ReferralRegistered
+	}
+}
+
+// ReferralNode is a user in a referral tree, with the users they invited.
+type ReferralNode struct {
+	ReferredUser
+	Children []*ReferralNode `json:"children,omitempty"`
+}
+
+// ReferralTree holds the users invited by a referrer, directly or not, up
+// to Depth levels. Truncated is set when there were more than fit.
+type ReferralTree struct {
+	Depth     int             `json:"depth"`
+	Total     int             `json:"total"`
+	Truncated bool            `json:"truncated"`
+	Children  []*ReferralNode `json:"children"`
+}
+
+// ReferralSummary is a user's own referral page.
+type ReferralSummary struct {
+	Code       string     `json:"code"`
+	ReferredBy *uuid.UUID `json:"referred_by,omitempty"`
+	Invited    int64      `json:"invited"`  // direct invitees
+	Verified   int64      `json:"verified"` // of which verified
+}
+
+var (
+	ErrReferralCodeNotFound = errors.New("referral code not found")
+	ErrReferralCodeTaken    = errors.New("referral code already in use")
+	ErrReferralNotFound     = errors.New("referral not found")
+)
+
+type ReferralRepository interface {
+	GetReferralCode(ctx context.Context, userID uuid.UUID) (*ReferralCode, error)
+	// FindReferralCode matches code exactly; codes are stored upper case.
+	FindReferralCode(ctx context.Context, code string) (*ReferralCode, error)
+	// CreateReferralCode returns ErrReferralCodeTaken when the code belongs
+	// to someone else, and does nothing when the user already has one.
+	CreateReferralCode(ctx context.Context, code *ReferralCode) error
+	// CreateReferral does nothing when the referee is already attributed.
+	CreateReferral(ctx context.Context, referral *Referral) error
+	GetReferral(ctx context.Context, refereeID uuid.UUID) (*Referral, error)
+	// ListReferred returns the direct invitees of referrerID, newest first.
+	ListReferred(ctx context.Context, referrerID uuid.UUID, page Page) ([]ReferredUser, int64, error)
+	// CountReferred counts the direct invitees, and those verified.
+	CountReferred(ctx context.Context, referrerID uuid.UUID) (invited, verified int64, err error)
+	// ListReferralTree returns up to limit users below referrerID, at most
+	// depth levels down, ordered by depth so parents precede children.
+	ListReferralTree(ctx context.Context, referrerID uuid.UUID, depth, limit int) ([]ReferredUser, error)
+}
+
+type ReferralUsecase interface {
+	// GetSummary returns the user's code, creating it on first use.
+	GetSummary(ctx context.Context, userID uuid.UUID) (*ReferralSummary, error)
+	ListReferred(ctx context.Context, userID uuid.UUID, page Page) ([]ReferredUser, int64, error)
+	// GetTree returns the user's referral tree, depth levels down (capped
+	// by configuration).
+	GetTree(ctx context.Context, userID uuid.UUID, depth int) (*ReferralTree, error)
+	// Attribute records that refereeID registered with code. Unknown codes
+	// return ErrReferralCodeNotFound; attributing twice is a no-op.
+	Attribute(ctx context.Context, refereeID uuid.UUID, code string) error
+}
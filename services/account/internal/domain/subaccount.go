@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permissions a sub-account can be granted. They bound what API keys and
+// tokens acting as the sub-account may do; services enforce them.
+const (
+	PermissionRead     = "read"
+	PermissionTrade    = "trade"
+	PermissionWithdraw = "withdraw"
+)
+
+// SubAccountPermissions lists the known permissions in a fixed order.
+var SubAccountPermissions = []string{PermissionRead, PermissionTrade, PermissionWithdraw}
+
+// MaxSubAccountLabel bounds the length of a label, in characters.
+const MaxSubAccountLabel = 50
+
+// SubAccount isolates part of a user's activity (e.g. one trading
+// strategy) under the same login. The parent user owns it; balances and
+// orders in other services are keyed by its ID.
+type SubAccount struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	ParentID    uuid.UUID  `gorm:"type:uuid" json:"parent_id"`
+	Label       string     `json:"label"`
+	Permissions []string   `gorm:"type:jsonb;serializer:json" json:"permissions"`
+	Enabled     bool       `json:"enabled"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`
+}
+
+// Allows reports whether the sub-account is enabled and has permission.
+func (s *SubAccount) Allows(permission string) bool {
+	if !s.Enabled {
+		return false
+	}
+	for _, p := range s.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey lets a program act as a sub-account: auth exchanges the secret
+// for an access token scoped to the key's permissions that the
+// sub-account still holds. Only the SHA-256 hash of the secret is stored;
+// the secret itself is returned once, when the key is created.
+type APIKey struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	SubAccountID uuid.UUID  `gorm:"type:uuid" json:"sub_account_id"`
+	Label        string     `json:"label"`
+	Prefix       string     `json:"prefix"` // start of the secret, to tell keys apart
+	SecretHash   string     `json:"-"`
+	Permissions  []string   `gorm:"type:jsonb;serializer:json" json:"permissions"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ResolvedAPIKey is what verifying a secret tells another service.
+type ResolvedAPIKey struct {
+	Key        *APIKey     `json:"key"`
+	SubAccount *SubAccount `json:"sub_account"`
+}
+
+// SubAccountUpdate holds the fields a PATCH may change; nil leaves a
+// field as it is.
+type SubAccountUpdate struct {
+	Label       *string
+	Permissions *[]string
+}
+
+var (
+	ErrSubAccountNotFound   = errors.New("sub-account not found")
+	ErrSubAccountInvalid    = errors.New("sub-account: invalid value")
+	ErrSubAccountLabelTaken = errors.New("sub-account label already in use")
+	ErrSubAccountLimit      = errors.New("sub-account limit reached")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrAPIKeyLimit          = errors.New("API key limit reached")
+)
+
+type SubAccountRepository interface {
+	CreateSubAccount(ctx context.Context, s *SubAccount) error
+	UpdateSubAccount(ctx context.Context, s *SubAccount) error
+	// GetSubAccount returns ErrSubAccountNotFound for an unknown ID, and
+	// locks the row when called inside a transaction.
+	GetSubAccount(ctx context.Context, id uuid.UUID) (*SubAccount, error)
+	ListSubAccounts(ctx context.Context, parentID uuid.UUID) ([]SubAccount, error)
+	CountSubAccounts(ctx context.Context, parentID uuid.UUID) (int64, error)
+
+	CreateAPIKey(ctx context.Context, k *APIKey) error
+	UpdateAPIKey(ctx context.Context, k *APIKey) error
+	// GetAPIKey returns ErrAPIKeyNotFound for an unknown ID, and locks the
+	// row when called inside a transaction.
+	GetAPIKey(ctx context.Context, id uuid.UUID) (*APIKey, error)
+	// GetAPIKeyByHash returns ErrAPIKeyNotFound unless an unrevoked key
+	// has the secret hash.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, subAccountID uuid.UUID) ([]APIKey, error)
+	// CountAPIKeys counts the unrevoked keys of the sub-account.
+	CountAPIKeys(ctx context.Context, subAccountID uuid.UUID) (int64, error)
+}
+
+type SubAccountUsecase interface {
+	CreateSubAccount(ctx context.Context, parentID uuid.UUID, label string, permissions []string) (*SubAccount, error)
+	ListSubAccounts(ctx context.Context, parentID uuid.UUID) ([]SubAccount, error)
+	// GetSubAccount returns ErrSubAccountNotFound unless parentID owns it.
+	GetSubAccount(ctx context.Context, parentID, id uuid.UUID) (*SubAccount, error)
+	UpdateSubAccount(ctx context.Context, parentID, id uuid.UUID, update SubAccountUpdate) (*SubAccount, error)
+	SetSubAccountEnabled(ctx context.Context, parentID, id uuid.UUID, enabled bool) (*SubAccount, error)
+	// ResolveSubAccount looks a sub-account up by ID alone, for services
+	// checking who owns it.
+	ResolveSubAccount(ctx context.Context, id uuid.UUID) (*SubAccount, error)
+
+	// CreateAPIKey returns the key and its secret, which is not stored.
+	// The permissions must be granted to the sub-account; nil means read.
+	CreateAPIKey(ctx context.Context, parentID, subAccountID uuid.UUID, label string, permissions []string) (*APIKey, string, error)
+	ListAPIKeys(ctx context.Context, parentID, subAccountID uuid.UUID) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, parentID, subAccountID, keyID uuid.UUID) (*APIKey, error)
+	// VerifyAPIKey returns ErrAPIKeyNotFound unless secret belongs to an
+	// unrevoked key; the sub-account may be disabled.
+	VerifyAPIKey(ctx context.Context, secret string) (*ResolvedAPIKey, error)
+}
@@ -0,0 +1,100 @@
+// Package authclient calls the auth service's internal API.
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitka/pkg/middleware"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxResponseSize bounds what is read from auth; a data export is the
+// largest response.
+const maxResponseSize = 32 << 20
+
+// Client implements domain.AuthDirectory.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New returns a client for the auth service at baseURL (e.g.
+// "http://auth-service:3000"), authenticating with the internal API token.
+func New(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) ExportUser(ctx context.Context, userID uuid.UUID) (json.RawMessage, error) {
+	var data json.RawMessage
+	err := c.get(ctx, fmt.Sprintf("/internal/v1/users/%s/export", userID), &data)
+	return data, err
+}
+
+func (c *Client) ListUsers(ctx context.Context, after uuid.UUID, limit int) ([]domain.AuthUser, uuid.UUID, error) {
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(limit))
+	if after != uuid.Nil {
+		q.Set("after", after.String())
+	}
+
+	var page struct {
+		Items []domain.AuthUser `json:"items"`
+		Next  string            `json:"next"`
+	}
+	if err := c.get(ctx, "/internal/v1/users?"+q.Encode(), &page); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	next := uuid.Nil
+	if page.Next != "" {
+		id, err := uuid.Parse(page.Next)
+		if err != nil {
+			return nil, uuid.Nil, fmt.Errorf("auth: invalid cursor %q: %w", page.Next, err)
+		}
+		next = id
+	}
+	return page.Items, next, nil
+}
+
+// get fetches path and decodes the data of the response envelope into out.
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(middleware.InternalTokenHeader, c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var env struct {
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&env); err != nil {
+		return fmt.Errorf("auth: GET %s: %s: %w", path, resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth: GET %s: %s: %s", path, resp.Status, env.Error)
+	}
+	return json.Unmarshal(env.Data, out)
+}
@@ -1,7 +1,12 @@
 package repository
 
 import (
+	"bitka/pkg/database"
 	"bitka/services/account/internal/domain"
+	"context"
+	"errors"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,7 +22,7 @@ func NewAccountRepo(db *gorm.DB) domain.AccountRepository {
 	return &accountRepo{db: db}
 }
 
-func (r *accountRepo) CreateProfile(userID uuid.UUID, email, username string) error {
+func (r *accountRepo) CreateProfile(ctx context.Context, userID uuid.UUID, email, username string) error {
 	p := domain.Profile{
 		UserID:    userID,
 		Username:  username,
@@ -26,24 +31,147 @@ func (r *accountRepo) CreateProfile(userID uuid.UUID, email, username string) er
 		UpdatedAt: time.Now(),
 	}
 
-	return r.db.
+	// The inbox skips redelivered events; this guards against a second,
+	// distinct event for the same user overwriting the user's later edits
+	return database.Conn(ctx, r.db).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "user_id"}},
-			UpdateAll: true,
+			DoNothing: true,
 		}).
 		Create(&p).Error
 }
 
-func (r *accountRepo) GetProfile(userID uuid.UUID) (*domain.Profile, error) {
+func (r *accountRepo) ListProfileRange(ctx context.Context, after, until uuid.UUID) ([]domain.Profile, error) {
+	var profiles []domain.Profile
+	err := database.Conn(ctx, r.db).
+		Select("user_id", "email", "username").
+		Where("user_id > ? AND user_id <= ?", after, until).
+		Order("user_id").
+		Find(&profiles).Error
+	return profiles, err
+}
+
+func (r *accountRepo) GetProfile(ctx context.Context, userID uuid.UUID) (*domain.Profile, error) {
 	var profile domain.Profile
-	err := r.db.First(&profile, "user_id = ?", userID).Error
-	return &profile, err
+	// Profile reads are the hottest path and tolerate replica lag
+	if err := database.ReadReplica(database.Conn(ctx, r.db)).First(&profile, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrProfileNotFound
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *accountRepo) GetProfileForUpdate(ctx context.Context, userID uuid.UUID) (*domain.Profile, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var profile domain.Profile
+	if err := q.First(&profile, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrProfileNotFound
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *accountRepo) GetProfileByUsername(ctx context.Context, username string) (*domain.Profile, error) {
+	var profile domain.Profile
+	err := database.ReadReplica(database.Conn(ctx, r.db)).
+		First(&profile, "lower(username) = lower(?)", username).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrProfileNotFound
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// minTrigramQuery is the shortest query worth a similarity match; shorter
+// ones only match as a prefix.
+const minTrigramQuery = 3
+
+func (r *accountRepo) SearchProfiles(ctx context.Context, query string, page domain.Page) ([]domain.Profile, int64, error) {
+	q := database.ReadReplica(database.Conn(ctx, r.db)).Model(&domain.Profile{})
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	order := "created_at DESC, user_id"
+	if query != "" {
+		prefix := escapeLike(query) + "%"
+		if len([]rune(query)) < minTrigramQuery {
+			q = q.Where("lower(email) LIKE ? OR lower(username) LIKE ? OR lower(full_name) LIKE ?",
+				prefix, prefix, prefix)
+		} else {
+			// The trigram indexes serve both the substring and the % match
+			contains := "%" + prefix
+			q = q.Where("lower(email) LIKE ? OR lower(username) LIKE ? OR lower(full_name) LIKE ? "+
+				"OR lower(username) % ? OR lower(full_name) % ?",
+				contains, contains, contains, query, query)
+		}
+		order = "GREATEST(similarity(lower(email), ?), similarity(lower(username), ?), similarity(lower(full_name), ?)) DESC, user_id"
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var profiles []domain.Profile
+	if query != "" {
+		q = q.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: order, Vars: []any{query, query, query}}})
+	} else {
+		q = q.Order(order)
+	}
+	err := q.Limit(page.Limit).Offset(page.Offset).Find(&profiles).Error
+	return profiles, total, err
 }
 
-func (r *accountRepo) UpsertProfile(profile *domain.Profile) error {
-	// Upsert: Create or Update if exists
-	return r.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"full_name", "avatar_url", "updated_at"}),
-	}).Create(profile).Error
+// escapeLike makes s match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *accountRepo) UpdateProfileFields(ctx context.Context, profile *domain.Profile, columns ...string) error {
+	// Select writes zero values too, so a cleared field is stored as such
+	res := database.Conn(ctx, r.db).
+		Model(profile).
+		Select(append(slices.Clip(columns), "updated_at")).
+		Updates(profile)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrProfileNotFound
+	}
+	return nil
+}
+
+func (r *accountRepo) AppendChanges(ctx context.Context, changes []domain.ProfileChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return database.Conn(ctx, r.db).Create(&changes).Error
+}
+
+func (r *accountRepo) ListChanges(ctx context.Context, userID uuid.UUID, page domain.Page) ([]domain.ProfileChange, int64, error) {
+	q := database.ReadReplica(database.Conn(ctx, r.db)).
+		Model(&domain.ProfileChange{}).
+		Where("user_id = ?", userID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var changes []domain.ProfileChange
+	err := q.Order("id DESC").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&changes).Error
+	return changes, total, err
 }
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type kycRepo struct {
+	db *gorm.DB
+}
+
+func NewKycRepo(db *gorm.DB) domain.KycRepository {
+	return &kycRepo{db: db}
+}
+
+func (r *kycRepo) CreateDocument(ctx context.Context, doc *domain.KycDocument) error {
+	return database.Conn(ctx, r.db).Create(doc).Error
+}
+
+func (r *kycRepo) GetDocument(ctx context.Context, id uuid.UUID) (*domain.KycDocument, error) {
+	var doc domain.KycDocument
+	err := database.Conn(ctx, r.db).First(&doc, "id = ?", id).Error
+	return &doc, kycNotFound(err)
+}
+
+func (r *kycRepo) PendingDocuments(ctx context.Context, userID uuid.UUID) ([]domain.KycDocument, error) {
+	var docs []domain.KycDocument
+	err := database.Conn(ctx, r.db).
+		Omit("content").
+		Where("user_id = ? AND application_id IS NULL", userID).
+		Order("created_at").
+		Find(&docs).Error
+	return docs, err
+}
+
+func (r *kycRepo) AttachDocuments(ctx context.Context, userID, applicationID uuid.UUID) error {
+	return database.Conn(ctx, r.db).
+		Model(&domain.KycDocument{}).
+		Where("user_id = ? AND application_id IS NULL", userID).
+		Update("application_id", applicationID).Error
+}
+
+func (r *kycRepo) CreateApplication(ctx context.Context, app *domain.KycApplication) error {
+	return database.Conn(ctx, r.db).Omit("Documents").Create(app).Error
+}
+
+func (r *kycRepo) UpdateApplication(ctx context.Context, app *domain.KycApplication) error {
+	return database.Conn(ctx, r.db).Omit("Documents").Save(app).Error
+}
+
+func (r *kycRepo) GetApplication(ctx context.Context, id uuid.UUID) (*domain.KycApplication, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		// Serialises concurrent reviews of the same application
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var app domain.KycApplication
+	if err := q.First(&app, "id = ?", id).Error; err != nil {
+		return nil, kycNotFound(err)
+	}
+	if err := r.loadDocuments(ctx, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+func (r *kycRepo) LatestApplication(ctx context.Context, userID uuid.UUID) (*domain.KycApplication, error) {
+	var app domain.KycApplication
+	err := database.Conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&app).Error
+	if err != nil {
+		return nil, kycNotFound(err)
+	}
+	if err := r.loadDocuments(ctx, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+func (r *kycRepo) ListApplications(ctx context.Context, filter domain.KycFilter) ([]domain.KycApplication, int64, error) {
+	// The review queue tolerates replica lag
+	q := database.ReadReplica(database.Conn(ctx, r.db)).Model(&domain.KycApplication{})
+	if filter.UserID != uuid.Nil {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var apps []domain.KycApplication
+	err := q.Order("created_at").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&apps).Error
+	return apps, total, err
+}
+
+func (r *kycRepo) DueForExpiry(ctx context.Context, now time.Time, limit int) ([]domain.KycApplication, error) {
+	var apps []domain.KycApplication
+	err := database.Conn(ctx, r.db).
+		Where("status = ? AND expires_at <= ?", domain.KycApproved, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&apps).Error
+	return apps, err
+}
+
+func (r *kycRepo) SupersedeApprovals(ctx context.Context, userID, keepID uuid.UUID) error {
+	return database.Conn(ctx, r.db).
+		Model(&domain.KycApplication{}).
+		Where("user_id = ? AND status = ? AND id <> ?", userID, domain.KycApproved, keepID).
+		Update("expires_at", nil).Error
+}
+
+func (r *kycRepo) GetProfileKyc(ctx context.Context, userID uuid.UUID) (domain.KycStatus, domain.KycLevel, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		// One status change per user at a time
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var p domain.Profile
+	if err := q.Select("kyc_status", "kyc_level").First(&p, "user_id = ?", userID).Error; err != nil {
+		return "", 0, kycNotFound(err)
+	}
+	return p.KycStatus, p.KycLevel, nil
+}
+
+func (r *kycRepo) SetProfileKyc(ctx context.Context, userID uuid.UUID, status domain.KycStatus, level domain.KycLevel) error {
+	return database.Conn(ctx, r.db).
+		Model(&domain.Profile{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"kyc_status": status,
+			"kyc_level":  level,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// loadDocuments fills in document metadata; content is served separately.
+func (r *kycRepo) loadDocuments(ctx context.Context, app *domain.KycApplication) error {
+	return database.Conn(ctx, r.db).
+		Omit("content").
+		Where("application_id = ?", app.ID).
+		Order("created_at").
+		Find(&app.Documents).Error
+}
+
+func kycNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrKycNotFound
+	}
+	return err
+}
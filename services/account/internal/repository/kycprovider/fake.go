@@ -0,0 +1,45 @@
+// Package kycprovider holds the identity-verification vendors behind
+// domain.KycProvider.
+package kycprovider
+
+import (
+	"context"
+	"fmt"
+
+	"bitka/services/account/internal/domain"
+)
+
+// Fake decisions, selected with ACCOUNT_KYC_FAKE_DECISION.
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+	DecisionManual  = "manual" // leave pending for a reviewer
+)
+
+// Fake is a local provider for development and tests. It never calls out;
+// the decision is fixed by configuration.
+type Fake struct {
+	decision string
+}
+
+func NewFake(decision string) (*Fake, error) {
+	switch decision {
+	case DecisionApprove, DecisionReject, DecisionManual:
+		return &Fake{decision: decision}, nil
+	}
+	return nil, fmt.Errorf("kycprovider: unknown fake decision %q", decision)
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) Verify(_ context.Context, app *domain.KycApplication) (domain.KycDecision, error) {
+	ref := "fake-" + app.ID.String()
+	switch f.decision {
+	case DecisionApprove:
+		return domain.KycDecision{Status: domain.KycApproved, Reference: ref}, nil
+	case DecisionReject:
+		return domain.KycDecision{Status: domain.KycRejected, Reason: "rejected by fake provider", Reference: ref}, nil
+	default:
+		return domain.KycDecision{Status: domain.KycPending, Reference: ref}, nil
+	}
+}
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+
+	"bitka/pkg/events"
+	"bitka/pkg/outbox"
+	"gorm.io/gorm"
+)
+
+type Publisher struct {
+	writer *outbox.Writer
+}
+
+// NewPublisher creates an event publisher backed by the account outbox table.
+func NewPublisher(db *gorm.DB) *Publisher {
+	return &Publisher{writer: outbox.NewWriter(db)}
+}
+
+// PublishKycUpdated enqueues the event in the current transaction.
+func (p *Publisher) PublishKycUpdated(ctx context.Context, event events.KycUpdated) error {
+	return p.enqueue(ctx, events.TopicKycUpdated, "user", event.UserID, event)
+}
+
+// PublishPreferencesChanged enqueues the event in the current transaction.
+func (p *Publisher) PublishPreferencesChanged(ctx context.Context, event events.PreferencesChanged) error {
+	return p.enqueue(ctx, events.TopicPreferences, "user", event.UserID, event)
+}
+
+// PublishUserErasureRequested enqueues the event in the current transaction.
+func (p *Publisher) PublishUserErasureRequested(ctx context.Context, event events.UserErasureRequested) error {
+	return p.enqueue(ctx, events.TopicUserErasureRequested, "user", event.UserID, event)
+}
+
+func (p *Publisher) enqueue(ctx context.Context, topic, aggregateType, aggregateID string, e events.Event) error {
+	env, err := events.New(e)
+	if err != nil {
+		return err
+	}
+	_, err = p.writer.Enqueue(ctx, outbox.Event{
+		EventID:       env.EventID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Topic:         topic,
+		EventType:     env.Type,
+		Payload:       env,
+		Headers:       env.Headers(),
+	})
+	return err
+}
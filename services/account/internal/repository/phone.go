@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type phoneRepo struct {
+	db *gorm.DB
+}
+
+func NewPhoneRepo(db *gorm.DB) domain.PhoneRepository {
+	return &phoneRepo{db: db}
+}
+
+func (r *phoneRepo) CreateVerification(ctx context.Context, v *domain.PhoneVerification) error {
+	return database.Conn(ctx, r.db).Create(v).Error
+}
+
+func (r *phoneRepo) UpdateVerification(ctx context.Context, v *domain.PhoneVerification) error {
+	return database.Conn(ctx, r.db).Save(v).Error
+}
+
+func (r *phoneRepo) LatestVerification(ctx context.Context, userID uuid.UUID) (*domain.PhoneVerification, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var v domain.PhoneVerification
+	err := q.Where("user_id = ? AND verified_at IS NULL", userID).
+		Order("created_at DESC").
+		First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrVerificationNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *phoneRepo) CountVerificationsSince(ctx context.Context, userID uuid.UUID, t time.Time) (int64, error) {
+	var n int64
+	err := database.Conn(ctx, r.db).
+		Model(&domain.PhoneVerification{}).
+		Where("user_id = ? AND created_at >= ?", userID, t).
+		Count(&n).Error
+	return n, err
+}
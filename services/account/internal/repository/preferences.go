@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type preferencesRepo struct {
+	db *gorm.DB
+}
+
+func NewPreferencesRepo(db *gorm.DB) domain.PreferencesRepository {
+	return &preferencesRepo{db: db}
+}
+
+func (r *preferencesRepo) GetPreferences(ctx context.Context, userID uuid.UUID) (*domain.Preferences, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var prefs domain.Preferences
+	if err := q.First(&prefs, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrPreferencesNotFound
+		}
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// InitPreferences inserts prefs unless the user already has a row.
+func (r *preferencesRepo) InitPreferences(ctx context.Context, prefs *domain.Preferences) error {
+	return database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(prefs).Error
+}
+
+// SavePreferences upserts: the first change of a user who still had the
+// defaults creates the row.
+func (r *preferencesRepo) SavePreferences(ctx context.Context, prefs *domain.Preferences) error {
+	return database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			UpdateAll: true,
+		}).
+		Create(prefs).Error
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type privacyRepo struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepo(db *gorm.DB) domain.PrivacyRepository {
+	return &privacyRepo{db: db}
+}
+
+func (r *privacyRepo) CreateExport(ctx context.Context, export *domain.DataExport) error {
+	return database.Conn(ctx, r.db).Create(export).Error
+}
+
+func (r *privacyRepo) UpdateExport(ctx context.Context, export *domain.DataExport) error {
+	return database.Conn(ctx, r.db).Save(export).Error
+}
+
+func (r *privacyRepo) GetExport(ctx context.Context, id uuid.UUID) (*domain.DataExport, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var export domain.DataExport
+	if err := q.First(&export, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *privacyRepo) ListExports(ctx context.Context, userID uuid.UUID) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := database.Conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *privacyRepo) NextPendingExport(ctx context.Context, now time.Time) (*domain.DataExport, error) {
+	var export domain.DataExport
+	err := database.Conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? OR (status = ? AND claimed_until <= ?)",
+			domain.ExportPending, domain.ExportProcessing, now).
+		Order("created_at").
+		First(&export).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *privacyRepo) FailOpenExports(ctx context.Context, userID uuid.UUID, reason string) error {
+	return database.Conn(ctx, r.db).Model(&domain.DataExport{}).
+		Where("user_id = ? AND status IN ?", userID,
+			[]domain.ExportStatus{domain.ExportPending, domain.ExportProcessing}).
+		Updates(map[string]any{"status": domain.ExportFailed, "error": reason, "claimed_until": nil}).Error
+}
+
+func (r *privacyRepo) ExpiredExports(ctx context.Context, now time.Time, limit int) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := database.Conn(ctx, r.db).
+		Where("status = ? AND expires_at <= ?", domain.ExportReady, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *privacyRepo) CreateDeletion(ctx context.Context, req *domain.DeletionRequest) error {
+	return database.Conn(ctx, r.db).Create(req).Error
+}
+
+func (r *privacyRepo) UpdateDeletion(ctx context.Context, req *domain.DeletionRequest) error {
+	return database.Conn(ctx, r.db).Save(req).Error
+}
+
+func (r *privacyRepo) GetDeletion(ctx context.Context, id uuid.UUID) (*domain.DeletionRequest, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var req domain.DeletionRequest
+	if err := q.First(&req, "id = ?", id).Error; err != nil {
+		return nil, deletionNotFound(err)
+	}
+	return &req, nil
+}
+
+func (r *privacyRepo) ActiveDeletion(ctx context.Context, userID uuid.UUID) (*domain.DeletionRequest, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var req domain.DeletionRequest
+	err := q.Where("user_id = ? AND status IN ?", userID,
+		[]domain.DeletionStatus{domain.DeletionScheduled, domain.DeletionErasing}).
+		First(&req).Error
+	if err != nil {
+		return nil, deletionNotFound(err)
+	}
+	return &req, nil
+}
+
+func (r *privacyRepo) LatestDeletion(ctx context.Context, userID uuid.UUID) (*domain.DeletionRequest, error) {
+	var req domain.DeletionRequest
+	err := database.Conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&req).Error
+	if err != nil {
+		return nil, deletionNotFound(err)
+	}
+	return &req, nil
+}
+
+func (r *privacyRepo) DueDeletions(ctx context.Context, now time.Time, limit int) ([]domain.DeletionRequest, error) {
+	var reqs []domain.DeletionRequest
+	err := database.Conn(ctx, r.db).
+		Where("status = ? AND scheduled_for <= ?", domain.DeletionScheduled, now).
+		Order("scheduled_for").
+		Limit(limit).
+		Find(&reqs).Error
+	return reqs, err
+}
+
+func (r *privacyRepo) ErasePersonalData(ctx context.Context, userID uuid.UUID, erasedAt time.Time) error {
+	db := database.Conn(ctx, r.db)
+
+	// The row stays so the user ID keeps resolving; the username matches
+	// the pseudonym auth gives the user
+	err := db.Model(&domain.Profile{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]any{
+			"email":            "",
+			"username":         domain.Pseudonym(userID),
+			"full_name":        "",
+			"avatar_key":       "",
+			"avatar_thumb_key": "",
+			// Cleared values are stored as "" (see pkg/encryption)
+			"address":           "",
+			"nationality":       "",
+			"date_of_birth":     "",
+			"phone":             "",
+			"phone_verified_at": nil,
+			"updated_at":        erasedAt,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := db.Where("user_id = ?", userID).Delete(&domain.Preferences{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("user_id = ?", userID).Delete(&domain.PhoneVerification{}).Error; err != nil {
+		return err
+	}
+
+	// The code stops attributing; referrals made with it are kept
+	if err := db.Where("user_id = ?", userID).Delete(&domain.ReferralCode{}).Error; err != nil {
+		return err
+	}
+
+	// Sub-accounts keep resolving for the services holding their IDs, but
+	// lose their labels and can no longer be used; their API keys are revoked
+	err = db.Model(&domain.SubAccount{}).
+		Where("parent_id = ?", userID).
+		Updates(map[string]any{
+			"label":       gorm.Expr("id::text"),
+			"enabled":     false,
+			"disabled_at": gorm.Expr("COALESCE(disabled_at, ?)", erasedAt),
+			"updated_at":  erasedAt,
+		}).Error
+	if err != nil {
+		return err
+	}
+	err = db.Model(&domain.APIKey{}).
+		Where("sub_account_id IN (?)", db.Model(&domain.SubAccount{}).Select("id").Where("parent_id = ?", userID)).
+		Updates(map[string]any{
+			"label":      "",
+			"revoked_at": gorm.Expr("COALESCE(revoked_at, ?)", erasedAt),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	// The trigger on profile_changes allows exactly this update
+	return db.Model(&domain.ProfileChange{}).
+		Where("user_id = ? AND (old_value IS NOT NULL OR new_value IS NOT NULL)", userID).
+		Updates(map[string]any{"old_value": nil, "new_value": nil}).Error
+}
+
+func deletionNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrDeletionNotFound
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// referredColumns reads a ReferredUser from referrals r joined with
+// profiles p; a missing profile reads as a registered user without name.
+const referredColumns = `r.referee_id AS user_id, r.referrer_id, r.created_at AS joined_at,
+	COALESCE(p.username, '') AS username, COALESCE(p.kyc_status, 'none') AS kyc_status`
+
+// referralTreeQuery walks the referrals below a user breadth first.
+// Profiles are joined for direct invitees only: the users they invited in
+// turn never dealt with the root, so their name and KYC status stay out.
+const referralTreeQuery = `
+WITH RECURSIVE tree AS (
+	SELECT referee_id, referrer_id, created_at, 1 AS depth
+	FROM referrals
+	WHERE referrer_id = @root
+	UNION ALL
+	SELECT r.referee_id, r.referrer_id, r.created_at, t.depth + 1
+	FROM referrals r
+	JOIN tree t ON r.referrer_id = t.referee_id
+	WHERE t.depth < @depth
+)
+SELECT r.referee_id AS user_id, r.referrer_id, r.created_at AS joined_at, r.depth,
+	COALESCE(p.username, '') AS username, COALESCE(p.kyc_status, 'none') AS kyc_status
+FROM tree r
+LEFT JOIN profiles p ON p.user_id = r.referee_id AND r.depth = 1
+ORDER BY r.depth, r.created_at, r.referee_id
+LIMIT @limit`
+
+type referralRepo struct {
+	db *gorm.DB
+}
+
+func NewReferralRepo(db *gorm.DB) domain.ReferralRepository {
+	return &referralRepo{db: db}
+}
+
+func (r *referralRepo) GetReferralCode(ctx context.Context, userID uuid.UUID) (*domain.ReferralCode, error) {
+	var code domain.ReferralCode
+	if err := database.Conn(ctx, r.db).First(&code, "user_id = ?", userID).Error; err != nil {
+		return nil, referralCodeNotFound(err)
+	}
+	return &code, nil
+}
+
+func (r *referralRepo) FindReferralCode(ctx context.Context, code string) (*domain.ReferralCode, error) {
+	var rc domain.ReferralCode
+	if err := database.Conn(ctx, r.db).First(&rc, "code = ?", code).Error; err != nil {
+		return nil, referralCodeNotFound(err)
+	}
+	return &rc, nil
+}
+
+func (r *referralRepo) CreateReferralCode(ctx context.Context, code *domain.ReferralCode) error {
+	err := database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(code).Error
+	if err != nil && strings.Contains(err.Error(), "idx_referral_codes_code") {
+		return domain.ErrReferralCodeTaken
+	}
+	return err
+}
+
+func (r *referralRepo) CreateReferral(ctx context.Context, referral *domain.Referral) error {
+	return database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(referral).Error
+}
+
+func (r *referralRepo) GetReferral(ctx context.Context, refereeID uuid.UUID) (*domain.Referral, error) {
+	var referral domain.Referral
+	if err := database.Conn(ctx, r.db).First(&referral, "referee_id = ?", refereeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrReferralNotFound
+		}
+		return nil, err
+	}
+	return &referral, nil
+}
+
+func (r *referralRepo) ListReferred(ctx context.Context, referrerID uuid.UUID, page domain.Page) ([]domain.ReferredUser, int64, error) {
+	db := database.Conn(ctx, r.db)
+
+	var total int64
+	if err := db.Model(&domain.Referral{}).Where("referrer_id = ?", referrerID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []domain.ReferredUser
+	err := db.Table("referrals r").
+		Select(referredColumns+", 1 AS depth").
+		Joins("LEFT JOIN profiles p ON p.user_id = r.referee_id").
+		Where("r.referrer_id = ?", referrerID).
+		Order("r.created_at DESC, r.referee_id").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Scan(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *referralRepo) CountReferred(ctx context.Context, referrerID uuid.UUID) (int64, int64, error) {
+	var counts struct {
+		Invited  int64
+		Verified int64
+	}
+	err := database.Conn(ctx, r.db).
+		Table("referrals r").
+		Select("COUNT(*) AS invited, COUNT(*) FILTER (WHERE p.kyc_status = ?) AS verified", domain.KycApproved).
+		Joins("LEFT JOIN profiles p ON p.user_id = r.referee_id").
+		Where("r.referrer_id = ?", referrerID).
+		Scan(&counts).Error
+	return counts.Invited, counts.Verified, err
+}
+
+func (r *referralRepo) ListReferralTree(ctx context.Context, referrerID uuid.UUID, depth, limit int) ([]domain.ReferredUser, error) {
+	var users []domain.ReferredUser
+	err := database.Conn(ctx, r.db).
+		Raw(referralTreeQuery,
+			sql.Named("root", referrerID),
+			sql.Named("depth", depth),
+			sql.Named("limit", limit)).
+		Scan(&users).Error
+	return users, err
+}
+
+func referralCodeNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrReferralCodeNotFound
+	}
+	return err
+}
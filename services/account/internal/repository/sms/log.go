@@ -0,0 +1,26 @@
+// Package sms holds the text message gateways behind domain.SmsSender.
+package sms
+
+import (
+	"context"
+
+	"bitka/pkg/logger"
+)
+
+// Log is a sender for development and tests: it writes the message,
+// code included, to the service log instead of sending it.
+type Log struct{}
+
+func NewLog() *Log { return &Log{} }
+
+func (Log) Name() string { return "log" }
+
+func (Log) Send(ctx context.Context, to, message string) error {
+	logger.From(ctx).Info().
+		Str("action", "sms_send").
+		Str("status", "success").
+		Str("to", to).
+		Str("message", message).
+		Msg("SMS not sent (log sender)")
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type subAccountRepo struct {
+	db *gorm.DB
+}
+
+func NewSubAccountRepo(db *gorm.DB) domain.SubAccountRepository {
+	return &subAccountRepo{db: db}
+}
+
+func (r *subAccountRepo) CreateSubAccount(ctx context.Context, s *domain.SubAccount) error {
+	return labelTaken(database.Conn(ctx, r.db).Create(s).Error)
+}
+
+func (r *subAccountRepo) UpdateSubAccount(ctx context.Context, s *domain.SubAccount) error {
+	return labelTaken(database.Conn(ctx, r.db).Save(s).Error)
+}
+
+func (r *subAccountRepo) GetSubAccount(ctx context.Context, id uuid.UUID) (*domain.SubAccount, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var s domain.SubAccount
+	if err := q.First(&s, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSubAccountNotFound
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *subAccountRepo) ListSubAccounts(ctx context.Context, parentID uuid.UUID) ([]domain.SubAccount, error) {
+	var subs []domain.SubAccount
+	err := database.Conn(ctx, r.db).
+		Where("parent_id = ?", parentID).
+		Order("created_at, id").
+		Find(&subs).Error
+	return subs, err
+}
+
+func (r *subAccountRepo) CountSubAccounts(ctx context.Context, parentID uuid.UUID) (int64, error) {
+	var n int64
+	err := database.Conn(ctx, r.db).
+		Model(&domain.SubAccount{}).
+		Where("parent_id = ?", parentID).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *subAccountRepo) CreateAPIKey(ctx context.Context, k *domain.APIKey) error {
+	return database.Conn(ctx, r.db).Create(k).Error
+}
+
+func (r *subAccountRepo) UpdateAPIKey(ctx context.Context, k *domain.APIKey) error {
+	return database.Conn(ctx, r.db).Save(k).Error
+}
+
+func (r *subAccountRepo) GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var k domain.APIKey
+	if err := q.First(&k, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *subAccountRepo) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	var k domain.APIKey
+	err := database.Conn(ctx, r.db).
+		First(&k, "secret_hash = ? AND revoked_at IS NULL", hash).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *subAccountRepo) ListAPIKeys(ctx context.Context, subAccountID uuid.UUID) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	err := database.Conn(ctx, r.db).
+		Where("sub_account_id = ?", subAccountID).
+		Order("created_at, id").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *subAccountRepo) CountAPIKeys(ctx context.Context, subAccountID uuid.UUID) (int64, error) {
+	var n int64
+	err := database.Conn(ctx, r.db).
+		Model(&domain.APIKey{}).
+		Where("sub_account_id = ? AND revoked_at IS NULL", subAccountID).
+		Count(&n).Error
+	return n, err
+}
+
+// labelTaken maps a violation of the per-parent label index.
+func labelTaken(err error) error {
+	if err != nil && strings.Contains(err.Error(), "idx_sub_accounts_parent_label") {
+		return domain.ErrSubAccountLabelTaken
+	}
+	return err
+}
@@ -1,46 +1,311 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"time"
 
+	"bitka/pkg/database"
+	"bitka/pkg/imaging"
+	"bitka/pkg/logger"
+	"bitka/pkg/storage"
 	"bitka/services/account/internal/domain"
 
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
+// AvatarOptions bounds avatar uploads.
+type AvatarOptions struct {
+	MaxSize       int64 // bytes, before re-encoding
+	MaxDimension  int   // longer side of the stored image
+	ThumbnailSize int   // longer side of the thumbnail
+	PresignTTL    time.Duration
+}
+
 type accountUC struct {
-	repo domain.AccountRepository
+	repo   domain.AccountRepository
+	tx     *database.TxManager
+	store  storage.Storage
+	avatar AvatarOptions
 }
 
-func NewAccountUsecase(repo domain.AccountRepository) domain.AccountUsecase {
-	return &accountUC{repo: repo}
+func NewAccountUsecase(repo domain.AccountRepository, tx *database.TxManager, store storage.Storage, avatar AvatarOptions) domain.AccountUsecase {
+	return &accountUC{repo: repo, tx: tx, store: store, avatar: avatar}
 }
 
-func (u *accountUC) CreateUserProfile(id uuid.UUID, email, username string) error {
-	return u.repo.CreateProfile(id, email, username)
+func (u *accountUC) CreateUserProfile(ctx context.Context, id uuid.UUID, email, username string) error {
+	return u.repo.CreateProfile(ctx, id, email, username)
 }
 
-func (u *accountUC) GetMyProfile(id uuid.UUID) (*domain.Profile, error) {
-	profile, err := u.repo.GetProfile(id)
+func (u *accountUC) GetMyProfile(ctx context.Context, id uuid.UUID) (*domain.Profile, error) {
+	// The owner's read feeds the ETag of their next PATCH, so it must not
+	// lag behind their own writes: read the primary, not a replica
+	profile, err := u.repo.GetProfileForUpdate(ctx, id)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Return a default empty profile if not found, or error based on requirements
+		if errors.Is(err, domain.ErrProfileNotFound) {
+			// The UserRegistered event has not been consumed (yet);
+			// reconciliation backfills the profile if it never is
+			logger.From(ctx).Warn().
+				Str("action", "get_profile").
+				Str("status", "missing").
+				Str("user_id", id.String()).
+				Msg("Profile not found; returning an empty one")
 			return &domain.Profile{UserID: id}, nil
 		}
 		return nil, err
 	}
+	u.resolveAvatar(ctx, profile)
+	return profile, nil
+}
+
+func (u *accountUC) GetPublicProfile(ctx context.Context, idOrUsername string) (*domain.PublicProfile, error) {
+	var (
+		profile *domain.Profile
+		err     error
+	)
+	if id, parseErr := uuid.Parse(idOrUsername); parseErr == nil {
+		profile, err = u.repo.GetProfile(ctx, id)
+	} else {
+		profile, err = u.repo.GetProfileByUsername(ctx, idOrUsername)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.resolveAvatar(ctx, profile)
+	return profile.Public(), nil
+}
+
+func (u *accountUC) SearchProfiles(ctx context.Context, query string, page domain.Page) ([]domain.Profile, int64, error) {
+	profiles, total, err := u.repo.SearchProfiles(ctx, query, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range profiles {
+		u.resolveAvatar(ctx, &profiles[i])
+	}
+	return profiles, total, nil
+}
+
+func (u *accountUC) PatchMyProfile(ctx context.Context, id uuid.UUID, patch domain.ProfilePatch, ifMatch string) (*domain.Profile, error) {
+	fields, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile *domain.Profile
+	err = u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if profile, err = u.repo.GetProfileForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if !matchesETag(ifMatch, profile.ETag()) {
+			return domain.ErrPreconditionFailed
+		}
+
+		var columns []string
+		var changes []domain.ProfileChange
+		for _, f := range fields {
+			old := f.get(profile)
+			if old == f.value {
+				continue
+			}
+			columns = append(columns, f.column)
+			change := newChange(ctx, id, f.name, old, f.value)
+			if f.sensitive {
+				change = redactChange(change)
+			}
+			changes = append(changes, change)
+			f.set(profile, f.value)
+		}
+		if len(columns) == 0 {
+			return nil // a no-op patch keeps the ETag
+		}
+
+		profile.UpdatedAt = now()
+		if err := u.repo.UpdateProfileFields(ctx, profile, columns...); err != nil {
+			return err
+		}
+		return u.repo.AppendChanges(ctx, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.resolveAvatar(ctx, profile)
+	return profile, nil
+}
+
+func (u *accountUC) ListChanges(ctx context.Context, id uuid.UUID, page domain.Page) ([]domain.ProfileChange, int64, error) {
+	return u.repo.ListChanges(ctx, id, page)
+}
+
+func (u *accountUC) UploadAvatar(ctx context.Context, id uuid.UUID, data []byte) (*domain.Profile, error) {
+	if int64(len(data)) > u.avatar.MaxSize {
+		return nil, domain.ErrMediaTooLarge
+	}
+	return u.storeAvatar(ctx, id, data)
+}
+
+func (u *accountUC) CreateAvatarUpload(ctx context.Context, id uuid.UUID, contentType string) (*domain.AvatarUpload, error) {
+	if !imaging.IsImage(contentType) {
+		return nil, domain.ErrMediaType
+	}
+
+	key := fmt.Sprintf("%s%s/%s", domain.UploadPrefix, id, uuid.New())
+	req, err := u.store.PresignPut(ctx, key, contentType, u.avatar.PresignTTL)
+	if errors.Is(err, storage.ErrUnsupported) {
+		return nil, domain.ErrPresignDisabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain.AvatarUpload{Key: key, Request: req, MaxSize: u.avatar.MaxSize}, nil
+}
+
+func (u *accountUC) CompleteAvatarUpload(ctx context.Context, id uuid.UUID, key string) (*domain.Profile, error) {
+	// Only the user's own pending uploads can be claimed
+	if !strings.HasPrefix(key, fmt.Sprintf("%s%s/", domain.UploadPrefix, id)) || storage.ValidateKey(key) != nil {
+		return nil, domain.ErrUploadNotFound
+	}
+
+	// Size is checked before reading: the client picked it, not us
+	info, err := u.store.Stat(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, domain.ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size > u.avatar.MaxSize {
+		u.deleteObjects(ctx, key)
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	body, _, err := u.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(body, u.avatar.MaxSize+1))
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > u.avatar.MaxSize {
+		u.deleteObjects(ctx, key)
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	profile, err := u.storeAvatar(ctx, id, data)
+	// The raw upload is never served, whatever the outcome
+	u.deleteObjects(ctx, key)
+	return profile, err
+}
+
+func (u *accountUC) DeleteAvatar(ctx context.Context, id uuid.UUID) error {
+	previous, _, err := u.setAvatar(ctx, id, "", "")
+	if err != nil {
+		return err
+	}
+	u.deleteObjects(ctx, previous.AvatarKey, previous.AvatarThumbKey)
+	return nil
+}
+
+// storeAvatar re-encodes data (dropping metadata), stores the image and
+// its thumbnail under fresh keys, and points the profile at them.
+func (u *accountUC) storeAvatar(ctx context.Context, id uuid.UUID, data []byte) (*domain.Profile, error) {
+	if !imaging.IsImage(imaging.Sniff(data)) {
+		return nil, domain.ErrMediaType
+	}
+	img, err := imaging.Process(data, imaging.Options{
+		MaxDimension:  u.avatar.MaxDimension,
+		ThumbnailSize: u.avatar.ThumbnailSize,
+	})
+	if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+		return nil, fmt.Errorf("%w: %v", domain.ErrMediaType, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ext := ".jpg"
+	if img.ContentType == "image/png" {
+		ext = ".png"
+	}
+	base := fmt.Sprintf("%s%s/%s", domain.AvatarPrefix, id, uuid.New())
+	key, thumbKey := base+ext, base+"_thumb"+ext
+
+	if err := u.store.Put(ctx, key, img.Image, img.ContentType); err != nil {
+		return nil, err
+	}
+	if err := u.store.Put(ctx, thumbKey, img.Thumbnail, img.ContentType); err != nil {
+		u.deleteObjects(ctx, key)
+		return nil, err
+	}
+
+	previous, profile, err := u.setAvatar(ctx, id, key, thumbKey)
+	if err != nil {
+		u.deleteObjects(ctx, key, thumbKey)
+		return nil, err
+	}
+
+	// Keys are never reused, so the old objects can go once the row moved on
+	u.deleteObjects(ctx, previous.AvatarKey, previous.AvatarThumbKey)
+
+	u.resolveAvatar(ctx, profile)
 	return profile, nil
 }
 
-func (u *accountUC) UpdateMyProfile(id uuid.UUID, fullName, avatar string) error {
-	profile := domain.Profile{
-		UserID:    id,
-		FullName:  fullName,
-		AvatarURL: avatar,
-		UpdatedAt: time.Now(),
+// setAvatar points the profile at new keys and logs the change. It returns
+// the profile before and after, so the caller can delete replaced objects.
+func (u *accountUC) setAvatar(ctx context.Context, id uuid.UUID, key, thumbKey string) (previous, updated *domain.Profile, err error) {
+	err = u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if previous, err = u.repo.GetProfileForUpdate(ctx, id); err != nil {
+			return err
+		}
+		next := *previous
+		updated = &next
+		if previous.AvatarKey == key {
+			return nil
+		}
+
+		updated.AvatarKey, updated.AvatarThumbKey = key, thumbKey
+		updated.UpdatedAt = now()
+		if err := u.repo.UpdateProfileFields(ctx, updated, "avatar_key", "avatar_thumb_key"); err != nil {
+			return err
+		}
+		return u.repo.AppendChanges(ctx, []domain.ProfileChange{
+			newChange(ctx, id, "avatar_key", previous.AvatarKey, key),
+		})
+	})
+	return previous, updated, err
+}
+
+// resolveAvatar fills in the URLs a client can load the avatar from.
+func (u *accountUC) resolveAvatar(ctx context.Context, p *domain.Profile) {
+	if p.AvatarKey == "" {
+		return
 	}
+	p.AvatarURL, _ = u.store.URL(ctx, p.AvatarKey, u.avatar.PresignTTL)
+	p.AvatarThumbURL, _ = u.store.URL(ctx, p.AvatarThumbKey, u.avatar.PresignTTL)
+}
 
-	return u.repo.UpsertProfile(&profile)
+// deleteObjects removes objects best-effort; an orphan costs storage only.
+func (u *accountUC) deleteObjects(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := u.store.Delete(ctx, key); err != nil {
+			logger.From(ctx).Warn().Err(err).
+				Str("action", "media_delete").
+				Str("status", "error").
+				Str("key", key).
+				Msg("Failed to delete object")
+		}
+	}
 }
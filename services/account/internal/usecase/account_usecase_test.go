@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"bitka/pkg/database/databasetest"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestPatchMyProfile(t *testing.T) {
+	userID := uuid.New()
+	stored := domain.Profile{
+		UserID:    userID,
+		Email:     "ada@x.io",
+		Username:  "ada",
+		FullName:  "Ada Lovelace",
+		UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	etag := stored.ETag()
+
+	type change struct{ field, old, new string }
+	tests := []struct {
+		name        string
+		patch       string
+		ifMatch     string
+		wantErr     error
+		wantName    string
+		wantCountry string
+		wantChanges []change
+	}{
+		{
+			name:        "set a field",
+			patch:       `{"full_name": "  Ada King  "}`,
+			wantName:    "Ada King",
+			wantChanges: []change{{"full_name", `"Ada Lovelace"`, `"Ada King"`}},
+		},
+		{
+			name:        "null clears a field",
+			patch:       `{"full_name": null}`,
+			wantChanges: []change{{"full_name", `"Ada Lovelace"`, `null`}},
+		},
+		{
+			name:        "sensitive values stay out of the log",
+			patch:       `{"nationality": "gb"}`,
+			wantName:    "Ada Lovelace",
+			wantCountry: "GB",
+			wantChanges: []change{{"nationality", `null`, `"[redacted]"`}},
+		},
+		{name: "unchanged value", patch: `{"full_name": "Ada Lovelace"}`, wantName: "Ada Lovelace"},
+		{name: "null on an empty field", patch: `{"nationality": null}`, wantName: "Ada Lovelace"},
+		{name: "empty patch", patch: `{}`, wantName: "Ada Lovelace"},
+		{name: "unknown field", patch: `{"favourite_colour": "blue"}`, wantErr: domain.ErrPatchInvalid},
+		{name: "read-only email", patch: `{"email": "eve@x.io"}`, wantErr: domain.ErrPatchInvalid},
+		{name: "read-only username", patch: `{"username": "eve"}`, wantErr: domain.ErrPatchInvalid},
+		{name: "read-only KYC status", patch: `{"kyc_status": "approved"}`, wantErr: domain.ErrPatchInvalid},
+		{name: "one bad member fails the whole patch", patch: `{"full_name": "Ada King", "nationality": "Poland"}`, wantErr: domain.ErrPatchInvalid},
+		{name: "wrong type", patch: `{"full_name": 42}`, wantErr: domain.ErrPatchInvalid},
+		{
+			name:        "current ETag",
+			patch:       `{"full_name": "Ada King"}`,
+			ifMatch:     etag,
+			wantName:    "Ada King",
+			wantChanges: []change{{"full_name", `"Ada Lovelace"`, `"Ada King"`}},
+		},
+		{
+			name:        "current ETag in a list",
+			patch:       `{"full_name": "Ada King"}`,
+			ifMatch:     `"1", ` + etag,
+			wantName:    "Ada King",
+			wantChanges: []change{{"full_name", `"Ada Lovelace"`, `"Ada King"`}},
+		},
+		{
+			name:        "any version",
+			patch:       `{"full_name": "Ada King"}`,
+			ifMatch:     "*",
+			wantName:    "Ada King",
+			wantChanges: []change{{"full_name", `"Ada Lovelace"`, `"Ada King"`}},
+		},
+		{name: "stale ETag", patch: `{"full_name": "Ada King"}`, ifMatch: `"1"`, wantErr: domain.ErrPreconditionFailed},
+		{name: "weak ETag", patch: `{"full_name": "Ada King"}`, ifMatch: "W/" + etag, wantErr: domain.ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := &singleProfile{profile: stored}
+			uc := NewAccountUsecase(accounts, databasetest.NewTxManager(), nil, AvatarOptions{})
+
+			var patch domain.ProfilePatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+			profile, err := uc.PatchMyProfile(context.Background(), userID, patch, tt.ifMatch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if accounts.updates != 0 || len(accounts.changes) != 0 {
+					t.Fatal("a refused patch was written")
+				}
+				return
+			}
+
+			if profile.FullName != tt.wantName || profile.Nationality != tt.wantCountry {
+				t.Fatalf("full name %q, nationality %q", profile.FullName, profile.Nationality)
+			}
+			if len(accounts.changes) != len(tt.wantChanges) {
+				t.Fatalf("logged %d changes, want %d", len(accounts.changes), len(tt.wantChanges))
+			}
+			for i, want := range tt.wantChanges {
+				got := accounts.changes[i]
+				if got.Field != want.field || string(got.OldValue) != want.old || string(got.NewValue) != want.new {
+					t.Fatalf("change %d = %s %s -> %s", i, got.Field, got.OldValue, got.NewValue)
+				}
+				if got.ActorID == nil || *got.ActorID != userID || got.Source != domain.ChangeSourceUser {
+					t.Fatalf("change %d attributed to %v (%s)", i, got.ActorID, got.Source)
+				}
+			}
+
+			// A no-op patch writes nothing and keeps the ETag
+			if len(tt.wantChanges) == 0 {
+				if accounts.updates != 0 || profile.ETag() != etag {
+					t.Fatalf("no-op patch wrote %d times, ETag %s", accounts.updates, profile.ETag())
+				}
+			} else if profile.ETag() == etag {
+				t.Fatal("the ETag did not change")
+			}
+		})
+	}
+}
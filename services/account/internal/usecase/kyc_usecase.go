@@ -0,0 +1,398 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/events"
+	"bitka/pkg/imaging"
+	"bitka/pkg/logger"
+	"bitka/pkg/storage"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// expiryBatch bounds one ExpireDue round.
+const expiryBatch = 100
+
+// minKycAge is the youngest a verified user can be.
+const minKycAge = 18
+
+// kycContentTypes are the sniffed types accepted for documents.
+var kycContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+type kycUC struct {
+	repo     domain.KycRepository
+	tx       *database.TxManager
+	provider domain.KycProvider
+	events   domain.KycEventPublisher
+	store    storage.Storage
+
+	validity        time.Duration
+	maxDocumentSize int64
+}
+
+func NewKycUsecase(
+	repo domain.KycRepository,
+	tx *database.TxManager,
+	provider domain.KycProvider,
+	ep domain.KycEventPublisher,
+	store storage.Storage,
+	validity time.Duration,
+	maxDocumentSize int64,
+) domain.KycUsecase {
+	return &kycUC{
+		repo:            repo,
+		tx:              tx,
+		provider:        provider,
+		events:          ep,
+		store:           store,
+		validity:        validity,
+		maxDocumentSize: maxDocumentSize,
+	}
+}
+
+func (u *kycUC) UploadDocument(ctx context.Context, userID uuid.UUID, doc *domain.KycDocument) error {
+	if !doc.Type.Valid() {
+		return domain.ErrKycDocumentType
+	}
+	if int64(len(doc.Content)) > u.maxDocumentSize {
+		return domain.ErrKycDocumentTooLarge
+	}
+
+	// Trust the bytes, not the client's Content-Type
+	contentType := imaging.Sniff(doc.Content)
+	if !kycContentTypes[contentType] {
+		return fmt.Errorf("%w: unsupported file type %s", domain.ErrKycIncomplete, contentType)
+	}
+	if imaging.IsImage(contentType) {
+		// Re-encoding strips EXIF (location, device) from photos
+		img, err := imaging.Process(doc.Content, imaging.Options{})
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrKycIncomplete, err)
+		}
+		doc.Content, contentType = img.Image, img.ContentType
+	}
+
+	sum := sha256.Sum256(doc.Content)
+	doc.ID = uuid.New()
+	doc.UserID = userID
+	doc.ApplicationID = nil
+	doc.ContentType = contentType
+	doc.Size = int64(len(doc.Content))
+	doc.SHA256 = hex.EncodeToString(sum[:])
+	doc.StorageKey = fmt.Sprintf("%s%s/%s", domain.KycPrefix, userID, doc.ID)
+	doc.CreatedAt = time.Now()
+
+	if err := u.store.Put(ctx, doc.StorageKey, doc.Content, contentType); err != nil {
+		return err
+	}
+
+	// The row only references the object
+	content := doc.Content
+	doc.Content = nil
+	if err := u.repo.CreateDocument(ctx, doc); err != nil {
+		_ = u.store.Delete(ctx, doc.StorageKey)
+		return err
+	}
+	doc.Content = content
+	return nil
+}
+
+func (u *kycUC) Submit(ctx context.Context, userID uuid.UUID, sub domain.KycSubmission) (*domain.KycApplication, error) {
+	if err := validateSubmission(sub); err != nil {
+		return nil, err
+	}
+
+	app := &domain.KycApplication{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Level:       sub.Level,
+		Status:      domain.KycPending,
+		FirstName:   strings.TrimSpace(sub.FirstName),
+		LastName:    strings.TrimSpace(sub.LastName),
+		DateOfBirth: sub.DateOfBirth,
+		Country:     strings.ToUpper(sub.Country),
+		Provider:    u.provider.Name(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		status, level, err := u.repo.GetProfileKyc(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !status.CanTransition(domain.KycPending) {
+			return fmt.Errorf("%w: verification is %s", domain.ErrKycTransition, status)
+		}
+		if status == domain.KycApproved && sub.Level <= level {
+			return fmt.Errorf("%w: already verified at level %d", domain.ErrKycInvalidLevel, level)
+		}
+
+		docs, err := u.repo.PendingDocuments(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if missing := sub.Level.MissingDocuments(docs); len(missing) > 0 {
+			return fmt.Errorf("%w: missing %s", domain.ErrKycIncomplete, strings.Join(missing, ", "))
+		}
+
+		if err := u.repo.CreateApplication(ctx, app); err != nil {
+			return err
+		}
+		if err := u.repo.AttachDocuments(ctx, userID, app.ID); err != nil {
+			return err
+		}
+		app.Documents = docs
+		return u.setStatus(ctx, userID, status, domain.KycPending, level, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.verify(ctx, app)
+	return app, nil
+}
+
+// verify asks the provider for an automatic decision. Errors and "pending"
+// verdicts leave the application in the reviewer queue.
+func (u *kycUC) verify(ctx context.Context, app *domain.KycApplication) {
+	l := logger.From(ctx)
+
+	decision, err := u.provider.Verify(ctx, app)
+	if err != nil {
+		l.Warn().Err(err).
+			Str("action", "kyc_verify").
+			Str("status", "error").
+			Str("application_id", app.ID.String()).
+			Msg("Verification provider failed, leaving application for manual review")
+		return
+	}
+	if decision.Status == domain.KycPending {
+		return
+	}
+
+	decided, err := u.decide(ctx, app.ID, nil, decision)
+	if err != nil {
+		l.Error().Err(err).
+			Str("action", "kyc_verify").
+			Str("status", "error").
+			Str("application_id", app.ID.String()).
+			Msg("Failed to apply provider decision")
+		return
+	}
+	*app = *decided
+}
+
+func (u *kycUC) GetState(ctx context.Context, userID uuid.UUID) (*domain.KycState, error) {
+	status, level, err := u.repo.GetProfileKyc(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &domain.KycState{Status: status, Level: level}
+	app, err := u.repo.LatestApplication(ctx, userID)
+	switch {
+	case errors.Is(err, domain.ErrKycNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		state.Application = app
+	}
+	return state, nil
+}
+
+func (u *kycUC) ListApplications(ctx context.Context, filter domain.KycFilter) ([]domain.KycApplication, int64, error) {
+	return u.repo.ListApplications(ctx, filter)
+}
+
+func (u *kycUC) GetApplication(ctx context.Context, id uuid.UUID) (*domain.KycApplication, error) {
+	return u.repo.GetApplication(ctx, id)
+}
+
+func (u *kycUC) GetDocument(ctx context.Context, id uuid.UUID) (*domain.KycDocument, error) {
+	doc, err := u.repo.GetDocument(ctx, id)
+	if err != nil || doc.StorageKey == "" {
+		return doc, err // older rows carry the bytes inline
+	}
+
+	body, _, err := u.store.Get(ctx, doc.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if doc.Content, err = io.ReadAll(body); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (u *kycUC) Approve(ctx context.Context, id, reviewerID uuid.UUID) (*domain.KycApplication, error) {
+	return u.decide(ctx, id, &reviewerID, domain.KycDecision{Status: domain.KycApproved})
+}
+
+func (u *kycUC) Reject(ctx context.Context, id, reviewerID uuid.UUID, reason string) (*domain.KycApplication, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a rejection reason is required", domain.ErrKycIncomplete)
+	}
+	return u.decide(ctx, id, &reviewerID, domain.KycDecision{Status: domain.KycRejected, Reason: reason})
+}
+
+// decide moves a pending application to approved or rejected. reviewerID
+// is nil for provider decisions.
+func (u *kycUC) decide(ctx context.Context, id uuid.UUID, reviewerID *uuid.UUID, d domain.KycDecision) (*domain.KycApplication, error) {
+	var app *domain.KycApplication
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		app, err = u.repo.GetApplication(ctx, id)
+		if err != nil {
+			return err
+		}
+		if reviewerID != nil && *reviewerID == app.UserID {
+			return domain.ErrKycSelfReview
+		}
+		if !app.Status.CanTransition(d.Status) {
+			return fmt.Errorf("%w: application is %s", domain.ErrKycTransition, app.Status)
+		}
+
+		status, level, err := u.repo.GetProfileKyc(ctx, app.UserID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		app.Status = d.Status
+		app.Reason = d.Reason
+		app.ReviewerID = reviewerID
+		app.ReviewedAt = &now
+		app.UpdatedAt = now
+		if d.Reference != "" {
+			app.ProviderRef = d.Reference
+		}
+
+		next := d.Status
+		if d.Status == domain.KycApproved {
+			expiresAt := now.Add(u.validity)
+			app.ExpiresAt = &expiresAt
+			level = max(level, app.Level)
+			if err := u.repo.SupersedeApprovals(ctx, app.UserID, app.ID); err != nil {
+				return err
+			}
+		}
+		// A rejected upgrade leaves the earlier approval, which still has a
+		// level (ExpireDue resets it), in force
+		if d.Status == domain.KycRejected && level > domain.KycLevel0 {
+			next = domain.KycApproved
+		}
+
+		if err := u.repo.UpdateApplication(ctx, app); err != nil {
+			return err
+		}
+		return u.setStatus(ctx, app.UserID, status, next, level, d.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.From(ctx).Info().
+		Str("action", "kyc_decide").
+		Str("status", "success").
+		Str("application_id", app.ID.String()).
+		Str("kyc_status", string(app.Status)).
+		Bool("manual", reviewerID != nil).
+		Msg("KYC application decided")
+	return app, nil
+}
+
+func (u *kycUC) ExpireDue(ctx context.Context) (int, error) {
+	due, err := u.repo.DueForExpiry(ctx, time.Now(), expiryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, candidate := range due {
+		changed := false
+		err := u.tx.Do(ctx, func(ctx context.Context) error {
+			app, err := u.repo.GetApplication(ctx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			// Re-check under the lock: superseded or already expired
+			if app.Status != domain.KycApproved || app.ExpiresAt == nil || app.ExpiresAt.After(time.Now()) {
+				return nil
+			}
+
+			status, _, err := u.repo.GetProfileKyc(ctx, app.UserID)
+			if err != nil {
+				return err
+			}
+
+			app.Status = domain.KycExpired
+			app.UpdatedAt = time.Now()
+			if err := u.repo.UpdateApplication(ctx, app); err != nil {
+				return err
+			}
+
+			// A pending upgrade keeps its status but loses the level
+			next := status
+			if status == domain.KycApproved {
+				next = domain.KycExpired
+			}
+			changed = true
+			return u.setStatus(ctx, app.UserID, status, next, domain.KycLevel0, "")
+		})
+		if err != nil {
+			return expired, err
+		}
+		if changed {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// setStatus stores the effective status on the profile and emits KycUpdated
+// in the same transaction.
+func (u *kycUC) setStatus(ctx context.Context, userID uuid.UUID, from, to domain.KycStatus, level domain.KycLevel, reason string) error {
+	if err := u.repo.SetProfileKyc(ctx, userID, to, level); err != nil {
+		return err
+	}
+	return u.events.PublishKycUpdated(ctx, events.KycUpdated{
+		UserID:         userID.String(),
+		Status:         string(to),
+		PreviousStatus: string(from),
+		Level:          int(level),
+		Reason:         reason,
+	})
+}
+
+func validateSubmission(sub domain.KycSubmission) error {
+	if sub.Level < domain.KycLevel1 || sub.Level > domain.MaxKycLevel {
+		return fmt.Errorf("%w: level must be between %d and %d", domain.ErrKycInvalidLevel, domain.KycLevel1, domain.MaxKycLevel)
+	}
+	if strings.TrimSpace(sub.FirstName) == "" || strings.TrimSpace(sub.LastName) == "" {
+		return fmt.Errorf("%w: first and last name are required", domain.ErrKycIncomplete)
+	}
+	if len(sub.Country) != 2 {
+		return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", domain.ErrKycIncomplete)
+	}
+	if sub.DateOfBirth.IsZero() || sub.DateOfBirth.AddDate(minKycAge, 0, 0).After(time.Now()) {
+		return fmt.Errorf("%w: applicant must be at least %d years old", domain.ErrKycIncomplete, minKycAge)
+	}
+	return nil
+}
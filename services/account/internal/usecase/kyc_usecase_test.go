@@ -0,0 +1,404 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/database/databasetest"
+	"bitka/pkg/events"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// profileKyc is the status and level stored on a profile.
+type profileKyc struct {
+	status domain.KycStatus
+	level  domain.KycLevel
+}
+
+// fakeKyc keeps applications, documents and profile states in memory.
+type fakeKyc struct {
+	domain.KycRepository
+	apps     map[uuid.UUID]domain.KycApplication
+	docs     []domain.KycDocument
+	profiles map[uuid.UUID]profileKyc
+	due      []domain.KycApplication // what DueForExpiry saw, possibly stale
+	unlocked int                     // GetApplication calls outside a transaction
+}
+
+func newFakeKyc() *fakeKyc {
+	return &fakeKyc{apps: map[uuid.UUID]domain.KycApplication{}, profiles: map[uuid.UUID]profileKyc{}}
+}
+
+func (r *fakeKyc) PendingDocuments(_ context.Context, userID uuid.UUID) ([]domain.KycDocument, error) {
+	var out []domain.KycDocument
+	for _, d := range r.docs {
+		if d.UserID == userID && d.ApplicationID == nil {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeKyc) AttachDocuments(_ context.Context, userID, applicationID uuid.UUID) error {
+	for i := range r.docs {
+		if r.docs[i].UserID == userID && r.docs[i].ApplicationID == nil {
+			r.docs[i].ApplicationID = &applicationID
+		}
+	}
+	return nil
+}
+
+func (r *fakeKyc) CreateApplication(_ context.Context, app *domain.KycApplication) error {
+	r.apps[app.ID] = *app
+	return nil
+}
+
+func (r *fakeKyc) UpdateApplication(_ context.Context, app *domain.KycApplication) error {
+	r.apps[app.ID] = *app
+	return nil
+}
+
+func (r *fakeKyc) GetApplication(ctx context.Context, id uuid.UUID) (*domain.KycApplication, error) {
+	if !database.InTx(ctx) {
+		r.unlocked++
+	}
+	app, ok := r.apps[id]
+	if !ok {
+		return nil, domain.ErrKycNotFound
+	}
+	return &app, nil
+}
+
+func (r *fakeKyc) SupersedeApprovals(_ context.Context, userID, keepID uuid.UUID) error {
+	for id, app := range r.apps {
+		if app.UserID == userID && id != keepID && app.Status == domain.KycApproved {
+			app.ExpiresAt = nil
+			r.apps[id] = app
+		}
+	}
+	return nil
+}
+
+func (r *fakeKyc) DueForExpiry(context.Context, time.Time, int) ([]domain.KycApplication, error) {
+	return r.due, nil
+}
+
+func (r *fakeKyc) GetProfileKyc(_ context.Context, userID uuid.UUID) (domain.KycStatus, domain.KycLevel, error) {
+	p, ok := r.profiles[userID]
+	if !ok {
+		return domain.KycNone, domain.KycLevel0, nil
+	}
+	return p.status, p.level, nil
+}
+
+func (r *fakeKyc) SetProfileKyc(_ context.Context, userID uuid.UUID, status domain.KycStatus, level domain.KycLevel) error {
+	r.profiles[userID] = profileKyc{status, level}
+	return nil
+}
+
+// fakeKycProvider answers every application with decision, or err.
+type fakeKycProvider struct {
+	decision domain.KycDecision
+	err      error
+}
+
+func (p *fakeKycProvider) Name() string { return "fake" }
+
+func (p *fakeKycProvider) Verify(context.Context, *domain.KycApplication) (domain.KycDecision, error) {
+	return p.decision, p.err
+}
+
+// kycEvents records the KycUpdated events published.
+type kycEvents struct {
+	published []events.KycUpdated
+}
+
+func (e *kycEvents) PublishKycUpdated(_ context.Context, event events.KycUpdated) error {
+	e.published = append(e.published, event)
+	return nil
+}
+
+func newTestKycUsecase(provider domain.KycDecision) (domain.KycUsecase, *fakeKyc, *kycEvents) {
+	repo := newFakeKyc()
+	ev := &kycEvents{}
+	uc := NewKycUsecase(repo, databasetest.NewTxManager(), &fakeKycProvider{decision: provider}, ev, nil, 365*24*time.Hour, 1<<20)
+	return uc, repo, ev
+}
+
+func kycSubmission(level domain.KycLevel) domain.KycSubmission {
+	return domain.KycSubmission{
+		Level:       level,
+		FirstName:   "Ada",
+		LastName:    "Lovelace",
+		DateOfBirth: time.Now().AddDate(-30, 0, 0),
+		Country:     "pl",
+	}
+}
+
+// withDocuments uploads what level needs for userID.
+func withDocuments(repo *fakeKyc, userID uuid.UUID, types ...domain.KycDocumentType) {
+	for _, t := range types {
+		repo.docs = append(repo.docs, domain.KycDocument{ID: uuid.New(), UserID: userID, Type: t})
+	}
+}
+
+var manualReview = domain.KycDecision{Status: domain.KycPending}
+
+func TestKycSubmit(t *testing.T) {
+	allDocuments := []domain.KycDocumentType{domain.DocPassport, domain.DocSelfie, domain.DocProofOfAddress}
+
+	tests := []struct {
+		name    string
+		current profileKyc
+		level   domain.KycLevel
+		docs    []domain.KycDocumentType
+		wantErr error
+		want    profileKyc
+	}{
+		{name: "first application", current: profileKyc{domain.KycNone, 0}, level: 1, want: profileKyc{domain.KycPending, 0}},
+		{name: "missing documents", current: profileKyc{domain.KycNone, 0}, level: 2, docs: []domain.KycDocumentType{domain.DocSelfie}, wantErr: domain.ErrKycIncomplete},
+		{name: "already pending", current: profileKyc{domain.KycPending, 0}, level: 1, wantErr: domain.ErrKycTransition},
+		{name: "same level again", current: profileKyc{domain.KycApproved, 2}, level: 2, docs: allDocuments, wantErr: domain.ErrKycInvalidLevel},
+		{name: "lower level", current: profileKyc{domain.KycApproved, 2}, level: 1, wantErr: domain.ErrKycInvalidLevel},
+		{name: "upgrade keeps the approved level", current: profileKyc{domain.KycApproved, 2}, level: 3, docs: allDocuments, want: profileKyc{domain.KycPending, 2}},
+		{name: "after a rejection", current: profileKyc{domain.KycRejected, 0}, level: 1, want: profileKyc{domain.KycPending, 0}},
+		{name: "after expiry", current: profileKyc{domain.KycExpired, 0}, level: 1, want: profileKyc{domain.KycPending, 0}},
+		{name: "level out of range", current: profileKyc{domain.KycNone, 0}, level: domain.MaxKycLevel + 1, wantErr: domain.ErrKycInvalidLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			uc, repo, ev := newTestKycUsecase(manualReview)
+			repo.profiles[userID] = tt.current
+			withDocuments(repo, userID, tt.docs...)
+
+			app, err := uc.Submit(context.Background(), userID, kycSubmission(tt.level))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(repo.apps) != 0 || len(ev.published) != 0 || repo.profiles[userID] != tt.current {
+					t.Fatal("a refused submission changed state")
+				}
+				return
+			}
+
+			if app.Status != domain.KycPending || len(app.Documents) != len(tt.docs) {
+				t.Fatalf("application %s with %d documents", app.Status, len(app.Documents))
+			}
+			if got := repo.profiles[userID]; got != tt.want {
+				t.Fatalf("profile = %+v, want %+v", got, tt.want)
+			}
+			if len(ev.published) != 1 || ev.published[0].PreviousStatus != string(tt.current.status) {
+				t.Fatalf("published %+v", ev.published)
+			}
+		})
+	}
+}
+
+func TestKycProviderDecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision domain.KycDecision
+		err      error
+		wantApp  domain.KycStatus
+		want     profileKyc
+	}{
+		{name: "approved", decision: domain.KycDecision{Status: domain.KycApproved, Reference: "ref-1"}, wantApp: domain.KycApproved, want: profileKyc{domain.KycApproved, 1}},
+		{name: "rejected", decision: domain.KycDecision{Status: domain.KycRejected, Reason: "blurry"}, wantApp: domain.KycRejected, want: profileKyc{domain.KycRejected, 0}},
+		{name: "left for a reviewer", decision: manualReview, wantApp: domain.KycPending, want: profileKyc{domain.KycPending, 0}},
+		{name: "provider down", err: errors.New("timeout"), wantApp: domain.KycPending, want: profileKyc{domain.KycPending, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			repo := newFakeKyc()
+			provider := &fakeKycProvider{decision: tt.decision, err: tt.err}
+			uc := NewKycUsecase(repo, databasetest.NewTxManager(), provider, &kycEvents{}, nil, time.Hour, 1<<20)
+
+			app, err := uc.Submit(context.Background(), userID, kycSubmission(domain.KycLevel1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if app.Status != tt.wantApp || repo.apps[app.ID].Status != tt.wantApp {
+				t.Fatalf("application %s (stored %s), want %s", app.Status, repo.apps[app.ID].Status, tt.wantApp)
+			}
+			if app.Status != domain.KycPending && app.ReviewerID != nil {
+				t.Fatal("a provider decision names a reviewer")
+			}
+			if got := repo.profiles[userID]; got != tt.want {
+				t.Fatalf("profile = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKycReview(t *testing.T) {
+	reviewer := uuid.New()
+
+	tests := []struct {
+		name     string
+		current  profileKyc // before the application was submitted
+		level    domain.KycLevel
+		reviewer func(userID uuid.UUID) uuid.UUID
+		approve  bool
+		reason   string
+		wantErr  error
+		want     profileKyc
+	}{
+		{name: "approve", current: profileKyc{domain.KycNone, 0}, level: 1, approve: true, want: profileKyc{domain.KycApproved, 1}},
+		{name: "reject", current: profileKyc{domain.KycNone, 0}, level: 1, reason: "blurry", want: profileKyc{domain.KycRejected, 0}},
+		{name: "approve an upgrade", current: profileKyc{domain.KycApproved, 1}, level: 2, approve: true, want: profileKyc{domain.KycApproved, 2}},
+		{name: "reject an upgrade", current: profileKyc{domain.KycApproved, 1}, level: 2, reason: "blurry", want: profileKyc{domain.KycApproved, 1}},
+		{name: "reject without a reason", current: profileKyc{domain.KycNone, 0}, level: 1, wantErr: domain.ErrKycIncomplete},
+		{
+			name:     "own application",
+			current:  profileKyc{domain.KycNone, 0},
+			level:    1,
+			reviewer: func(userID uuid.UUID) uuid.UUID { return userID },
+			approve:  true,
+			wantErr:  domain.ErrKycSelfReview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uuid.New()
+			uc, repo, ev := newTestKycUsecase(manualReview)
+			repo.profiles[userID] = tt.current
+			withDocuments(repo, userID, domain.DocIDCard, domain.DocSelfie)
+
+			app, err := uc.Submit(ctx, userID, kycSubmission(tt.level))
+			if err != nil {
+				t.Fatal(err)
+			}
+			submitted := repo.profiles[userID]
+			by := reviewer
+			if tt.reviewer != nil {
+				by = tt.reviewer(userID)
+			}
+
+			decide := func() (*domain.KycApplication, error) {
+				if tt.approve {
+					return uc.Approve(ctx, app.ID, by)
+				}
+				return uc.Reject(ctx, app.ID, by, tt.reason)
+			}
+			decided, err := decide()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if repo.apps[app.ID].Status != domain.KycPending || repo.profiles[userID] != submitted {
+					t.Fatal("a refused decision changed state")
+				}
+				return
+			}
+
+			if decided.ReviewerID == nil || *decided.ReviewerID != reviewer || decided.ReviewedAt == nil {
+				t.Fatalf("reviewer %v at %v", decided.ReviewerID, decided.ReviewedAt)
+			}
+			if (decided.ExpiresAt != nil) != tt.approve {
+				t.Fatalf("expires at %v", decided.ExpiresAt)
+			}
+			if got := repo.profiles[userID]; got != tt.want {
+				t.Fatalf("profile = %+v, want %+v", got, tt.want)
+			}
+			last := ev.published[len(ev.published)-1]
+			if last.Status != string(tt.want.status) || last.Level != int(tt.want.level) || last.Reason != tt.reason {
+				t.Fatalf("published %+v", last)
+			}
+
+			// A decided application stays decided
+			if _, err := uc.Approve(ctx, app.ID, reviewer); !errors.Is(err, domain.ErrKycTransition) {
+				t.Fatalf("second decision: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestKycExpireDue(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	approval := func(userID uuid.UUID, expiresAt *time.Time) domain.KycApplication {
+		return domain.KycApplication{ID: uuid.New(), UserID: userID, Level: 2, Status: domain.KycApproved, ExpiresAt: expiresAt}
+	}
+
+	tests := []struct {
+		name       string
+		profile    profileKyc
+		current    func(domain.KycApplication) domain.KycApplication // state once locked
+		wantExpire bool
+		want       profileKyc
+	}{
+		{
+			name:       "expired approval",
+			profile:    profileKyc{domain.KycApproved, 2},
+			wantExpire: true,
+			want:       profileKyc{domain.KycExpired, 0},
+		},
+		{
+			name:       "pending upgrade loses the level",
+			profile:    profileKyc{domain.KycPending, 2},
+			wantExpire: true,
+			want:       profileKyc{domain.KycPending, 0},
+		},
+		{
+			name:    "superseded since it was listed",
+			profile: profileKyc{domain.KycApproved, 3},
+			current: func(a domain.KycApplication) domain.KycApplication { a.ExpiresAt = nil; return a },
+			want:    profileKyc{domain.KycApproved, 3},
+		},
+		{
+			name:    "renewed since it was listed",
+			profile: profileKyc{domain.KycApproved, 2},
+			current: func(a domain.KycApplication) domain.KycApplication { a.ExpiresAt = &future; return a },
+			want:    profileKyc{domain.KycApproved, 2},
+		},
+		{
+			name:    "already expired by another run",
+			profile: profileKyc{domain.KycExpired, 0},
+			current: func(a domain.KycApplication) domain.KycApplication { a.Status = domain.KycExpired; return a },
+			want:    profileKyc{domain.KycExpired, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			uc, repo, ev := newTestKycUsecase(manualReview)
+			listed := approval(userID, &past)
+			stored := listed
+			if tt.current != nil {
+				stored = tt.current(listed)
+			}
+			repo.due = []domain.KycApplication{listed}
+			repo.apps[stored.ID] = stored
+			repo.profiles[userID] = tt.profile
+
+			n, err := uc.ExpireDue(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repo.unlocked != 0 {
+				t.Fatal("the application was re-read outside the transaction")
+			}
+			if (n == 1) != tt.wantExpire || (len(ev.published) == 1) != tt.wantExpire {
+				t.Fatalf("expired %d, published %d", n, len(ev.published))
+			}
+			if tt.wantExpire && repo.apps[stored.ID].Status != domain.KycExpired {
+				t.Fatalf("application %s", repo.apps[stored.ID].Status)
+			}
+			if got := repo.profiles[userID]; got != tt.want {
+				t.Fatalf("profile = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
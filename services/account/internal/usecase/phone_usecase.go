@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// PhoneOptions configures phone verification.
+type PhoneOptions struct {
+	CodeTTL        time.Duration // how long a code can be confirmed
+	MaxAttempts    int           // wrong codes before a verification is locked
+	ResendInterval time.Duration // minimum time between two codes
+	MaxPerDay      int           // codes a user may request in 24 hours
+	CodeSecret     []byte        // HMAC key of the stored code hashes
+}
+
+// codeDigits is the length of a verification code.
+const codeDigits = 6
+
+// e164 matches a normalized international number.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+type phoneUC struct {
+	phones   domain.PhoneRepository
+	accounts domain.AccountRepository
+	tx       *database.TxManager
+	sms      domain.SmsSender
+	opts     PhoneOptions
+}
+
+func NewPhoneUsecase(phones domain.PhoneRepository, accounts domain.AccountRepository, tx *database.TxManager, sms domain.SmsSender, opts PhoneOptions) domain.PhoneUsecase {
+	return &phoneUC{phones: phones, accounts: accounts, tx: tx, sms: sms, opts: opts}
+}
+
+func (u *phoneUC) StartVerification(ctx context.Context, userID uuid.UUID, phone string) (*domain.PhoneVerification, error) {
+	phone, err := normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return nil, err
+	}
+	var v *domain.PhoneVerification
+	err = u.tx.Do(ctx, func(ctx context.Context) error {
+		// Locking the profile serializes requests, so the limits hold
+		if _, err := u.accounts.GetProfileForUpdate(ctx, userID); err != nil {
+			return err
+		}
+		if err := u.checkRate(ctx, userID); err != nil {
+			return err
+		}
+
+		created := now()
+		v = &domain.PhoneVerification{
+			ID:        uuid.New(),
+			UserID:    userID,
+			Phone:     phone,
+			ExpiresAt: created.Add(u.opts.CodeTTL),
+			CreatedAt: created,
+		}
+		v.CodeHash = u.hashCode(v.ID, code)
+		return u.phones.CreateVerification(ctx, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The row is stored first so a failed send still counts towards the limits
+	msg := fmt.Sprintf("Your Bitka verification code is %s. It expires in %d minutes.", code, int(u.opts.CodeTTL.Minutes()))
+	if err := u.sms.Send(ctx, phone, msg); err != nil {
+		return nil, fmt.Errorf("sms %s: %w", u.sms.Name(), err)
+	}
+	return v, nil
+}
+
+// checkRate enforces the resend interval and the daily limit; it must run
+// inside a transaction that holds the profile lock.
+func (u *phoneUC) checkRate(ctx context.Context, userID uuid.UUID) error {
+	latest, err := u.phones.LatestVerification(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrVerificationNotFound) {
+		return err
+	}
+	if latest != nil && now().Sub(latest.CreatedAt) < u.opts.ResendInterval {
+		return domain.ErrPhoneRateLimited
+	}
+
+	sent, err := u.phones.CountVerificationsSince(ctx, userID, now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if sent >= int64(u.opts.MaxPerDay) {
+		return domain.ErrPhoneRateLimited
+	}
+	return nil
+}
+
+func (u *phoneUC) ConfirmVerification(ctx context.Context, userID uuid.UUID, code string) (*domain.PhoneVerification, error) {
+	var (
+		verification *domain.PhoneVerification
+		wrongCode    bool
+	)
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		v, err := u.phones.LatestVerification(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !now().Before(v.ExpiresAt) {
+			return domain.ErrVerificationNotFound
+		}
+		if v.Attempts >= u.opts.MaxAttempts {
+			return domain.ErrVerificationLocked
+		}
+
+		// The attempt is committed even when the code is wrong
+		v.Attempts++
+		if subtle.ConstantTimeCompare([]byte(u.hashCode(v.ID, strings.TrimSpace(code))), []byte(v.CodeHash)) != 1 {
+			wrongCode = true
+			return u.phones.UpdateVerification(ctx, v)
+		}
+
+		verifiedAt := now()
+		v.VerifiedAt = &verifiedAt
+		if err := u.phones.UpdateVerification(ctx, v); err != nil {
+			return err
+		}
+		verification = v
+		return u.setPhone(ctx, userID, v.Phone, &verifiedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if wrongCode {
+		return nil, domain.ErrCodeInvalid
+	}
+	return verification, nil
+}
+
+func (u *phoneUC) RemovePhone(ctx context.Context, userID uuid.UUID) error {
+	return u.tx.Do(ctx, func(ctx context.Context) error {
+		return u.setPhone(ctx, userID, "", nil)
+	})
+}
+
+// setPhone writes the number to the profile and logs the change; it must
+// run inside a transaction.
+func (u *phoneUC) setPhone(ctx context.Context, userID uuid.UUID, phone string, verifiedAt *time.Time) error {
+	profile, err := u.accounts.GetProfileForUpdate(ctx, userID)
+	if err != nil {
+		return err
+	}
+	old := profile.Phone
+	if old == phone && phone == "" {
+		return nil
+	}
+
+	profile.Phone = phone
+	profile.PhoneVerifiedAt = verifiedAt
+	profile.UpdatedAt = now()
+	if err := u.accounts.UpdateProfileFields(ctx, profile, "phone", "phone_verified_at"); err != nil {
+		return err
+	}
+	return u.accounts.AppendChanges(ctx, []domain.ProfileChange{
+		redactChange(newChange(ctx, userID, "phone", old, phone)),
+	})
+}
+
+// normalizePhone strips formatting and checks the number is E.164.
+func normalizePhone(phone string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !e164.MatchString(phone) {
+		return "", domain.ErrPhoneInvalid
+	}
+	return phone, nil
+}
+
+// newCode returns a random numeric code of codeDigits digits.
+func newCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n.Int64()), nil
+}
+
+// hashCode keys the hash with the server secret, so the million possible
+// codes cannot be tried offline against a leaked row, and binds it to its
+// verification, so the hash of one row says nothing about another.
+func (u *phoneUC) hashCode(id uuid.UUID, code string) string {
+	mac := hmac.New(sha256.New, u.opts.CodeSecret)
+	mac.Write([]byte(id.String() + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/database/databasetest"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakePhones keeps verifications in memory like the phone repository.
+type fakePhones struct {
+	rows []domain.PhoneVerification
+}
+
+func (p *fakePhones) CreateVerification(_ context.Context, v *domain.PhoneVerification) error {
+	p.rows = append(p.rows, *v)
+	return nil
+}
+
+func (p *fakePhones) UpdateVerification(_ context.Context, v *domain.PhoneVerification) error {
+	for i := range p.rows {
+		if p.rows[i].ID == v.ID {
+			p.rows[i] = *v
+		}
+	}
+	return nil
+}
+
+func (p *fakePhones) LatestVerification(_ context.Context, userID uuid.UUID) (*domain.PhoneVerification, error) {
+	var latest *domain.PhoneVerification
+	for i, v := range p.rows {
+		if v.UserID == userID && v.VerifiedAt == nil && (latest == nil || v.CreatedAt.After(latest.CreatedAt)) {
+			latest = &p.rows[i]
+		}
+	}
+	if latest == nil {
+		return nil, domain.ErrVerificationNotFound
+	}
+	v := *latest
+	return &v, nil
+}
+
+func (p *fakePhones) CountVerificationsSince(_ context.Context, userID uuid.UUID, t time.Time) (int64, error) {
+	var n int64
+	for _, v := range p.rows {
+		if v.UserID == userID && !v.CreatedAt.Before(t) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// singleProfile serves one profile, counts the locks taken on it and
+// records the change log.
+type singleProfile struct {
+	domain.AccountRepository
+	profile domain.Profile
+	locked  int
+	updates int
+	changes []domain.ProfileChange
+}
+
+func (a *singleProfile) GetProfileForUpdate(ctx context.Context, userID uuid.UUID) (*domain.Profile, error) {
+	if userID != a.profile.UserID {
+		return nil, domain.ErrProfileNotFound
+	}
+	if database.InTx(ctx) {
+		a.locked++
+	}
+	p := a.profile
+	return &p, nil
+}
+
+func (a *singleProfile) UpdateProfileFields(_ context.Context, p *domain.Profile, _ ...string) error {
+	a.profile = *p
+	a.updates++
+	return nil
+}
+
+func (a *singleProfile) AppendChanges(_ context.Context, changes []domain.ProfileChange) error {
+	a.changes = append(a.changes, changes...)
+	return nil
+}
+
+// fakeSms remembers the last code it was asked to send.
+type fakeSms struct {
+	code string
+}
+
+var smsCode = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+func (s *fakeSms) Name() string { return "fake" }
+
+func (s *fakeSms) Send(_ context.Context, _, message string) error {
+	s.code = smsCode.FindString(message)
+	return nil
+}
+
+var testPhoneOptions = PhoneOptions{
+	CodeTTL:        10 * time.Minute,
+	MaxAttempts:    3,
+	ResendInterval: time.Minute,
+	MaxPerDay:      5,
+	CodeSecret:     []byte("test secret"),
+}
+
+func newTestPhoneUsecase(userID uuid.UUID) (*phoneUC, *fakePhones, *singleProfile, *fakeSms) {
+	phones := &fakePhones{}
+	accounts := &singleProfile{profile: domain.Profile{UserID: userID}}
+	sms := &fakeSms{}
+	uc := NewPhoneUsecase(phones, accounts, databasetest.NewTxManager(), sms, testPhoneOptions).(*phoneUC)
+	return uc, phones, accounts, sms
+}
+
+func TestStartVerificationRateLimits(t *testing.T) {
+	userID := uuid.New()
+	sent := func(ago time.Duration, verified bool) domain.PhoneVerification {
+		v := domain.PhoneVerification{ID: uuid.New(), UserID: userID, CreatedAt: time.Now().Add(-ago)}
+		if verified {
+			at := v.CreatedAt
+			v.VerifiedAt = &at
+		}
+		return v
+	}
+	repeat := func(n int, v func() domain.PhoneVerification) []domain.PhoneVerification {
+		var out []domain.PhoneVerification
+		for range n {
+			out = append(out, v())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		earlier []domain.PhoneVerification
+		wantErr error
+	}{
+		{name: "first code", userID: userID},
+		{name: "after the resend interval", userID: userID, earlier: []domain.PhoneVerification{sent(2*time.Minute, false)}},
+		{name: "within the resend interval", userID: userID, earlier: []domain.PhoneVerification{sent(30*time.Second, false)}, wantErr: domain.ErrPhoneRateLimited},
+		{name: "a verified code does not hold back a new one", userID: userID, earlier: []domain.PhoneVerification{sent(30*time.Second, true)}},
+		{
+			name:    "below the daily cap",
+			userID:  userID,
+			earlier: repeat(4, func() domain.PhoneVerification { return sent(time.Hour, false) }),
+		},
+		{
+			name:    "at the daily cap",
+			userID:  userID,
+			earlier: repeat(5, func() domain.PhoneVerification { return sent(time.Hour, false) }),
+			wantErr: domain.ErrPhoneRateLimited,
+		},
+		{
+			name:    "codes older than a day do not count",
+			userID:  userID,
+			earlier: repeat(5, func() domain.PhoneVerification { return sent(25*time.Hour, false) }),
+		},
+		{name: "unknown profile", userID: uuid.New(), wantErr: domain.ErrProfileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, phones, accounts, sms := newTestPhoneUsecase(userID)
+			phones.rows = append(phones.rows, tt.earlier...)
+
+			v, err := uc.StartVerification(context.Background(), tt.userID, "+48 123 456 789")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(phones.rows) != len(tt.earlier) || sms.code != "" {
+					t.Fatal("a refused request stored or sent a code")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if accounts.locked != 1 {
+				t.Fatalf("profile locked %d times inside a transaction, want 1", accounts.locked)
+			}
+			if v.Phone != "+48123456789" || sms.code == "" {
+				t.Fatalf("phone = %q, code = %q", v.Phone, sms.code)
+			}
+			if v.CodeHash == sms.code || len(v.CodeHash) != 64 {
+				t.Fatalf("code hash %q is not an HMAC of the code", v.CodeHash)
+			}
+		})
+	}
+}
+
+func TestConfirmVerificationLockout(t *testing.T) {
+	tests := []struct {
+		name      string
+		wrong     int // wrong codes entered first
+		wantWrong []error
+		wantFinal error // then the right code
+	}{
+		{name: "right code first"},
+		{
+			name:      "right code after wrong ones",
+			wrong:     testPhoneOptions.MaxAttempts - 1,
+			wantWrong: []error{domain.ErrCodeInvalid, domain.ErrCodeInvalid},
+		},
+		{
+			name:      "locked after MaxAttempts wrong codes",
+			wrong:     testPhoneOptions.MaxAttempts + 1,
+			wantWrong: []error{domain.ErrCodeInvalid, domain.ErrCodeInvalid, domain.ErrCodeInvalid, domain.ErrVerificationLocked},
+			wantFinal: domain.ErrVerificationLocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uuid.New()
+			uc, _, accounts, sms := newTestPhoneUsecase(userID)
+
+			if _, err := uc.StartVerification(ctx, userID, "+48123456789"); err != nil {
+				t.Fatal(err)
+			}
+			wrong := "000000"
+			if sms.code == wrong {
+				wrong = "111111"
+			}
+
+			for i := range tt.wrong {
+				if _, err := uc.ConfirmVerification(ctx, userID, wrong); !errors.Is(err, tt.wantWrong[i]) {
+					t.Fatalf("wrong code %d: err = %v, want %v", i+1, err, tt.wantWrong[i])
+				}
+			}
+
+			_, err := uc.ConfirmVerification(ctx, userID, sms.code)
+			if !errors.Is(err, tt.wantFinal) {
+				t.Fatalf("right code: err = %v, want %v", err, tt.wantFinal)
+			}
+			verified := accounts.profile.Phone == "+48123456789" && accounts.profile.PhoneVerifiedAt != nil
+			if verified != (tt.wantFinal == nil) {
+				t.Fatalf("profile phone = %q, verified at %v", accounts.profile.Phone, accounts.profile.PhoneVerifiedAt)
+			}
+		})
+	}
+}
+
+func TestHashCodeUsesSecret(t *testing.T) {
+	id := uuid.New()
+	a := &phoneUC{opts: PhoneOptions{CodeSecret: []byte("one")}}
+	b := &phoneUC{opts: PhoneOptions{CodeSecret: []byte("two")}}
+
+	if a.hashCode(id, "123456") != a.hashCode(id, "123456") {
+		t.Fatal("hash is not deterministic")
+	}
+	if a.hashCode(id, "123456") == b.hashCode(id, "123456") {
+		t.Fatal("hash does not depend on the secret")
+	}
+	if a.hashCode(id, "123456") == a.hashCode(uuid.New(), "123456") {
+		t.Fatal("hash does not depend on the verification")
+	}
+}
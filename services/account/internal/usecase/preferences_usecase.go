@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/events"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+type preferencesUC struct {
+	repo   domain.PreferencesRepository
+	tx     *database.TxManager
+	events domain.PreferencesEventPublisher
+}
+
+func NewPreferencesUsecase(repo domain.PreferencesRepository, tx *database.TxManager, ep domain.PreferencesEventPublisher) domain.PreferencesUsecase {
+	return &preferencesUC{repo: repo, tx: tx, events: ep}
+}
+
+func (u *preferencesUC) GetPreferences(ctx context.Context, userID uuid.UUID) (*domain.Preferences, error) {
+	return u.load(ctx, userID)
+}
+
+func (u *preferencesUC) PatchPreferences(ctx context.Context, userID uuid.UUID, patch []byte) (*domain.Preferences, error) {
+	var prefs *domain.Preferences
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		// Make sure there is a row to lock, or two first PATCHes of a user
+		// would both start from the defaults and one would be lost
+		if err := u.repo.InitPreferences(ctx, domain.DefaultPreferences(userID)); err != nil {
+			return err
+		}
+		current, err := u.load(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		next, err := applyPreferencesPatch(current, patch)
+		if err != nil {
+			return err
+		}
+		changed := changedPreferences(current, next)
+		if len(changed) == 0 {
+			prefs = current
+			return nil
+		}
+
+		next.UpdatedAt = now()
+		if err := u.repo.SavePreferences(ctx, next); err != nil {
+			return err
+		}
+		prefs = next
+		return u.events.PublishPreferencesChanged(ctx, events.PreferencesChanged{
+			UserID:          userID.String(),
+			Locale:          next.Locale,
+			Timezone:        next.Timezone,
+			DisplayCurrency: next.DisplayCurrency,
+			Theme:           string(next.Theme),
+			Notifications:   next.Notifications,
+			Changed:         changed,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// load returns the stored preferences, or the defaults for a user who
+// never changed any.
+func (u *preferencesUC) load(ctx context.Context, userID uuid.UUID) (*domain.Preferences, error) {
+	prefs, err := u.repo.GetPreferences(ctx, userID)
+	if errors.Is(err, domain.ErrPreferencesNotFound) {
+		return domain.DefaultPreferences(userID), nil
+	}
+	return prefs, err
+}
+
+// applyPreferencesPatch merges patch (RFC 7396) into a copy of current.
+// A null member, at any depth, removes it and so resets it to its default;
+// unknown fields and invalid values are rejected.
+func applyPreferencesPatch(current *domain.Preferences, patch []byte) (*domain.Preferences, error) {
+	var members map[string]any
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return nil, fmt.Errorf("%w: body must be a JSON object", domain.ErrPreferencesInvalid)
+	}
+	delete(members, "updated_at")
+
+	target, err := toJSONObject(current)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := toJSONObject(domain.DefaultPreferences(current.UserID))
+	if err != nil {
+		return nil, err
+	}
+	merged, err := json.Marshal(withDefaults(mergePatch(target, members), defaults))
+	if err != nil {
+		return nil, err
+	}
+
+	next := domain.Preferences{UserID: current.UserID, UpdatedAt: current.UpdatedAt}
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrPreferencesInvalid, err)
+	}
+	if err := normalizePreferences(&next); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// mergePatch is the MergePatch algorithm of RFC 7396 on decoded JSON.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = mergePatch(t[name], value)
+		}
+	}
+	return t
+}
+
+// withDefaults fills the members missing from v, at any depth, from defaults.
+func withDefaults(v, defaults any) any {
+	obj, ok := v.(map[string]any)
+	def, defOK := defaults.(map[string]any)
+	if !ok || !defOK {
+		return v
+	}
+	for name, d := range def {
+		if cur, present := obj[name]; present {
+			obj[name] = withDefaults(cur, d)
+		} else {
+			obj[name] = d
+		}
+	}
+	return obj
+}
+
+// toJSONObject round-trips v through JSON into a generic object.
+func toJSONObject(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	return obj, json.Unmarshal(raw, &obj)
+}
+
+// normalizePreferences validates p and puts each value in canonical form.
+func normalizePreferences(p *domain.Preferences) error {
+	tag, err := language.Parse(p.Locale)
+	if err != nil {
+		return fmt.Errorf("%w: locale %q is not a BCP 47 tag", domain.ErrPreferencesInvalid, p.Locale)
+	}
+	p.Locale = tag.String()
+
+	// "Local" is whatever the server runs in, not a zone the user can mean
+	if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "" || p.Timezone == "Local" {
+		return fmt.Errorf("%w: unknown timezone %q", domain.ErrPreferencesInvalid, p.Timezone)
+	}
+
+	p.DisplayCurrency = strings.ToUpper(p.DisplayCurrency)
+	if !slices.Contains(domain.DisplayCurrencies, p.DisplayCurrency) {
+		return fmt.Errorf("%w: display_currency must be one of %v", domain.ErrPreferencesInvalid, domain.DisplayCurrencies)
+	}
+
+	if !p.Theme.Valid() {
+		return fmt.Errorf("%w: unknown theme %q", domain.ErrPreferencesInvalid, p.Theme)
+	}
+	return nil
+}
+
+// changedPreferences lists the top-level fields that differ, in a fixed order.
+func changedPreferences(a, b *domain.Preferences) []string {
+	var changed []string
+	if a.Locale != b.Locale {
+		changed = append(changed, "locale")
+	}
+	if a.Timezone != b.Timezone {
+		changed = append(changed, "timezone")
+	}
+	if a.DisplayCurrency != b.DisplayCurrency {
+		changed = append(changed, "display_currency")
+	}
+	if a.Theme != b.Theme {
+		changed = append(changed, "theme")
+	}
+	if a.Notifications != b.Notifications {
+		changed = append(changed, "notifications")
+	}
+	return changed
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestApplyPreferencesPatch(t *testing.T) {
+	userID := uuid.New()
+	defaults := domain.DefaultPreferences(userID)
+
+	// current differs from the defaults everywhere the cases look
+	current := domain.DefaultPreferences(userID)
+	current.Theme = domain.ThemeDark
+	current.Locale = "pl-PL"
+	current.Notifications.Login = domain.NotificationChannels{SMS: true}
+	current.Notifications.TradeFill = domain.NotificationChannels{Email: true}
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    func(p *domain.Preferences)
+		wantErr bool
+	}{
+		{
+			name:  "sets a top-level member",
+			patch: `{"theme":"light"}`,
+			want:  func(p *domain.Preferences) { p.Theme = domain.ThemeLight },
+		},
+		{
+			name:  "top-level null resets to the default",
+			patch: `{"theme":null}`,
+			want:  func(p *domain.Preferences) { p.Theme = defaults.Theme },
+		},
+		{
+			name:  "nested member merges field by field",
+			patch: `{"notifications":{"login":{"email":true}}}`,
+			want:  func(p *domain.Preferences) { p.Notifications.Login.Email = true },
+		},
+		{
+			name:  "nested null object resets that object",
+			patch: `{"notifications":{"login":null}}`,
+			want:  func(p *domain.Preferences) { p.Notifications.Login = defaults.Notifications.Login },
+		},
+		{
+			name:  "nested null leaf resets that leaf",
+			patch: `{"notifications":{"trade_fill":{"push":null,"email":null}}}`,
+			want: func(p *domain.Preferences) {
+				p.Notifications.TradeFill = defaults.Notifications.TradeFill
+			},
+		},
+		{
+			name:  "null notifications resets all of them",
+			patch: `{"notifications":null,"locale":"de"}`,
+			want: func(p *domain.Preferences) {
+				p.Notifications = defaults.Notifications
+				p.Locale = "de"
+			},
+		},
+		{
+			name:  "updated_at is ignored",
+			patch: `{"updated_at":"2001-01-01T00:00:00Z"}`,
+			want:  func(*domain.Preferences) {},
+		},
+		{name: "unknown member", patch: `{"colour":"red"}`, wantErr: true},
+		{name: "unknown nested member", patch: `{"notifications":{"fax":{"email":true}}}`, wantErr: true},
+		{name: "wrong type", patch: `{"notifications":{"login":true}}`, wantErr: true},
+		{name: "invalid value", patch: `{"theme":"neon"}`, wantErr: true},
+		{name: "not an object", patch: `[1]`, wantErr: true},
+		{name: "null body", patch: `null`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := *current
+			got, err := applyPreferencesPatch(current, []byte(tt.patch))
+			if *current != before {
+				t.Fatal("current was modified")
+			}
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrPreferencesInvalid) {
+					t.Fatalf("err = %v, want ErrPreferencesInvalid", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := *current
+			tt.want(&want)
+			if *got != want {
+				t.Errorf("got  %+v\nwant %+v", *got, want)
+			}
+		})
+	}
+}
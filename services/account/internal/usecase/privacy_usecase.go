@@ -0,0 +1,661 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"time"
+
+	"bitka/pkg/database"
+	"bitka/pkg/events"
+	"bitka/pkg/logger"
+	"bitka/pkg/storage"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// PrivacyOptions configures exports and deletions.
+type PrivacyOptions struct {
+	CoolingOff time.Duration // between a deletion request and the erasure
+	ExportTTL  time.Duration // how long a ready archive can be downloaded
+}
+
+// privacyBatch bounds the work of one background run.
+const privacyBatch = 100
+
+// exportClaimTTL is how long a worker may take to build one archive before
+// another worker takes the export over.
+const exportClaimTTL = 30 * time.Minute
+
+// erasedExportReason is shown on exports cut short by an account deletion.
+const erasedExportReason = "the account has been deleted"
+
+type privacyUC struct {
+	repo      domain.PrivacyRepository
+	accounts  domain.AccountRepository
+	prefs     domain.PreferencesRepository
+	kyc       domain.KycRepository
+	subs      domain.SubAccountRepository
+	referrals domain.ReferralRepository
+	auth      domain.AuthDirectory
+	tx        *database.TxManager
+	store     storage.Storage
+	events    domain.PrivacyEventPublisher
+	opts      PrivacyOptions
+}
+
+func NewPrivacyUsecase(
+	repo domain.PrivacyRepository,
+	accounts domain.AccountRepository,
+	prefs domain.PreferencesRepository,
+	kyc domain.KycRepository,
+	subs domain.SubAccountRepository,
+	referrals domain.ReferralRepository,
+	auth domain.AuthDirectory,
+	tx *database.TxManager,
+	store storage.Storage,
+	ep domain.PrivacyEventPublisher,
+	opts PrivacyOptions,
+) domain.PrivacyUsecase {
+	return &privacyUC{
+		repo:      repo,
+		accounts:  accounts,
+		prefs:     prefs,
+		kyc:       kyc,
+		subs:      subs,
+		referrals: referrals,
+		auth:      auth,
+		tx:        tx,
+		store:     store,
+		events:    ep,
+		opts:      opts,
+	}
+}
+
+// RequestExport queues an export, or returns the one already queued.
+func (u *privacyUC) RequestExport(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error) {
+	exports, err := u.repo.ListExports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range exports {
+		if exports[i].Status == domain.ExportPending || exports[i].Status == domain.ExportProcessing {
+			return &exports[i], nil
+		}
+	}
+
+	export := &domain.DataExport{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Status:    domain.ExportPending,
+		CreatedAt: now(),
+	}
+	if err := u.repo.CreateExport(ctx, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (u *privacyUC) ListExports(ctx context.Context, userID uuid.UUID) ([]domain.DataExport, error) {
+	return u.repo.ListExports(ctx, userID)
+}
+
+func (u *privacyUC) OpenExport(ctx context.Context, userID, exportID uuid.UUID) (*domain.DataExport, io.ReadCloser, error) {
+	export, err := u.repo.GetExport(ctx, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Someone else's export does not exist, as far as the caller knows
+	if export.UserID != userID {
+		return nil, nil, domain.ErrExportNotFound
+	}
+	if export.Status != domain.ExportReady {
+		return nil, nil, domain.ErrExportNotReady
+	}
+
+	body, _, err := u.store.Get(ctx, export.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, domain.ErrExportNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, body, nil
+}
+
+// ProcessExports builds pending archives one at a time. Each export is
+// claimed in a short transaction, built outside it and finalised in a
+// second one, so no row lock or connection is held while files are read.
+// A failed build marks the export failed; the user can request a new one.
+func (u *privacyUC) ProcessExports(ctx context.Context) (int, error) {
+	n := 0
+	for n < privacyBatch {
+		export, err := u.claimExport(ctx)
+		if err != nil || export == nil {
+			return n, err
+		}
+		n++
+		if export.Status != domain.ExportProcessing {
+			continue // refused while claiming
+		}
+
+		u.buildExport(ctx, export)
+		if err := u.finishExport(ctx, export); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// claimExport marks the next pending export processing, or returns nil
+// when there is none.
+func (u *privacyUC) claimExport(ctx context.Context) (*domain.DataExport, error) {
+	var export *domain.DataExport
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		export, err = u.repo.NextPendingExport(ctx, now())
+		if errors.Is(err, domain.ErrExportNotFound) {
+			export = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Nothing is exported once erasure has started
+		if del, err := u.repo.ActiveDeletion(ctx, export.UserID); err == nil && del.Status == domain.DeletionErasing {
+			export.Status = domain.ExportFailed
+			export.Error = erasedExportReason
+			export.ClaimedUntil = nil
+			return u.repo.UpdateExport(ctx, export)
+		}
+
+		claimedUntil := now().Add(exportClaimTTL)
+		export.Status = domain.ExportProcessing
+		export.ClaimedUntil = &claimedUntil
+		return u.repo.UpdateExport(ctx, export)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// finishExport stores the outcome of a build. If the export was failed
+// meanwhile (the account is being erased), the new archive is deleted
+// instead. Erasure fails open exports before it looks for ready ones, and
+// both sides lock the row, so an archive is never left behind.
+func (u *privacyUC) finishExport(ctx context.Context, built *domain.DataExport) error {
+	var discard bool
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		current, err := u.repo.GetExport(ctx, built.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != domain.ExportProcessing {
+			discard = true
+			return nil
+		}
+		built.ClaimedUntil = nil
+		return u.repo.UpdateExport(ctx, built)
+	})
+	if err != nil || !discard || built.StorageKey == "" {
+		return err
+	}
+
+	if err := u.store.Delete(ctx, built.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		logger.From(ctx).Warn().Err(err).
+			Str("action", "data_export").
+			Str("status", "error").
+			Str("export_id", built.ID.String()).
+			Msg("Failed to delete the archive of a cancelled export")
+	}
+	return nil
+}
+
+func (u *privacyUC) buildExport(ctx context.Context, export *domain.DataExport) {
+	completed := now()
+	export.CompletedAt = &completed
+
+	archive, err := u.buildArchive(ctx, export)
+	if err == nil {
+		key := fmt.Sprintf("%s%s/%s.zip", domain.ExportPrefix, export.UserID, export.ID)
+		if err = u.store.Put(ctx, key, archive, "application/zip"); err == nil {
+			expires := completed.Add(u.opts.ExportTTL)
+			export.Status = domain.ExportReady
+			export.StorageKey = key
+			export.Size = int64(len(archive))
+			export.ExpiresAt = &expires
+			return
+		}
+	}
+
+	logger.From(ctx).Error().Err(err).
+		Str("action", "data_export").
+		Str("status", "error").
+		Str("export_id", export.ID.String()).
+		Msg("Failed to build data export")
+	export.Status = domain.ExportFailed
+	export.Error = "the export could not be built; please request a new one"
+}
+
+// buildArchive collects everything held about the user into a zip. Ledger
+// history joins it once the ledger service exists.
+func (u *privacyUC) buildArchive(ctx context.Context, export *domain.DataExport) ([]byte, error) {
+	userID := export.UserID
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// files lists everything in the archive for the manifest
+	var files []string
+	writeJSON := func(name string, v any) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		files = append(files, name)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	// Account
+	var profile *domain.Profile
+	if p, err := u.accounts.GetProfile(ctx, userID); err == nil {
+		profile = p
+	} else if !errors.Is(err, domain.ErrProfileNotFound) {
+		return nil, err
+	}
+	if err := writeJSON("account/profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	prefs, err := u.prefs.GetPreferences(ctx, userID)
+	if errors.Is(err, domain.ErrPreferencesNotFound) {
+		prefs, err = domain.DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON("account/preferences.json", prefs); err != nil {
+		return nil, err
+	}
+
+	changes, err := u.allChanges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON("account/profile_changes.json", changes); err != nil {
+		return nil, err
+	}
+
+	subs, err := u.subs.ListSubAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON("account/sub_accounts.json", subs); err != nil {
+		return nil, err
+	}
+	var keys []domain.APIKey
+	for _, sub := range subs {
+		subKeys, err := u.subs.ListAPIKeys(ctx, sub.ID)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, subKeys...)
+	}
+	if err := writeJSON("account/api_keys.json", keys); err != nil {
+		return nil, err
+	}
+
+	referral, err := u.referralData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON("account/referral.json", referral); err != nil {
+		return nil, err
+	}
+
+	apps, err := u.kycApplications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON("account/kyc_applications.json", apps); err != nil {
+		return nil, err
+	}
+	for _, app := range apps {
+		for _, doc := range app.Documents {
+			name, err := u.writeDocument(ctx, zw, doc)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, name)
+		}
+	}
+
+	// Auth: user record and login history
+	authData, err := u.auth.ExportUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON("auth/user.json", authData); err != nil {
+		return nil, err
+	}
+
+	if err := writeJSON("manifest.json", map[string]any{
+		"export_id":    export.ID,
+		"user_id":      userID,
+		"generated_at": now(),
+		"files":        files,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (u *privacyUC) allChanges(ctx context.Context, userID uuid.UUID) ([]domain.ProfileChange, error) {
+	var all []domain.ProfileChange
+	page := domain.Page{Limit: 500}
+	for {
+		changes, total, err := u.accounts.ListChanges(ctx, userID, page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, changes...)
+		page.Offset += len(changes)
+		if len(changes) == 0 || int64(page.Offset) >= total {
+			return all, nil
+		}
+	}
+}
+
+// referralData is the user's own code and who invited them. Invitees are
+// left out: they are other people's data.
+func (u *privacyUC) referralData(ctx context.Context, userID uuid.UUID) (map[string]any, error) {
+	data := map[string]any{}
+	code, err := u.referrals.GetReferralCode(ctx, userID)
+	switch {
+	case err == nil:
+		data["code"] = code.Code
+		data["code_created_at"] = code.CreatedAt
+	case !errors.Is(err, domain.ErrReferralCodeNotFound):
+		return nil, err
+	}
+
+	referral, err := u.referrals.GetReferral(ctx, userID)
+	switch {
+	case err == nil:
+		data["referred_by"] = referral.ReferrerID
+		data["referred_with_code"] = referral.Code
+		data["referred_at"] = referral.CreatedAt
+	case !errors.Is(err, domain.ErrReferralNotFound):
+		return nil, err
+	}
+	return data, nil
+}
+
+func (u *privacyUC) kycApplications(ctx context.Context, userID uuid.UUID) ([]domain.KycApplication, error) {
+	var apps []domain.KycApplication
+	filter := domain.KycFilter{UserID: userID, Limit: privacyBatch}
+	for {
+		page, total, err := u.kyc.ListApplications(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, page...)
+		filter.Offset += len(page)
+		if len(page) == 0 || int64(filter.Offset) >= total {
+			break
+		}
+	}
+
+	for i := range apps {
+		full, err := u.kyc.GetApplication(ctx, apps[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		apps[i] = *full
+	}
+	return apps, nil
+}
+
+// writeDocument adds the file of doc and returns its name in the archive.
+func (u *privacyUC) writeDocument(ctx context.Context, zw *zip.Writer, doc domain.KycDocument) (string, error) {
+	name := path.Join("account/kyc_documents", doc.ID.String()+"_"+path.Base(doc.FileName))
+	w, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if doc.StorageKey == "" {
+		stored, err := u.kyc.GetDocument(ctx, doc.ID) // inline legacy content
+		if err != nil {
+			return "", err
+		}
+		_, err = w.Write(stored.Content)
+		return name, err
+	}
+
+	body, _, err := u.store.Get(ctx, doc.StorageKey)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	_, err = io.Copy(w, body)
+	return name, err
+}
+
+// ExpireExports deletes archives past their download window.
+func (u *privacyUC) ExpireExports(ctx context.Context) (int, error) {
+	exports, err := u.repo.ExpiredExports(ctx, now(), privacyBatch)
+	if err != nil {
+		return 0, err
+	}
+	for i := range exports {
+		if err := u.expireExport(ctx, &exports[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(exports), nil
+}
+
+func (u *privacyUC) expireExport(ctx context.Context, export *domain.DataExport) error {
+	if err := u.store.Delete(ctx, export.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	export.Status = domain.ExportExpired
+	export.StorageKey = ""
+	return u.repo.UpdateExport(ctx, export)
+}
+
+func (u *privacyUC) RequestDeletion(ctx context.Context, userID uuid.UUID) (*domain.DeletionRequest, error) {
+	var req *domain.DeletionRequest
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		_, err := u.repo.ActiveDeletion(ctx, userID)
+		if err == nil {
+			return domain.ErrDeletionScheduled
+		}
+		if !errors.Is(err, domain.ErrDeletionNotFound) {
+			return err
+		}
+
+		created := now()
+		req = &domain.DeletionRequest{
+			ID:           uuid.New(),
+			UserID:       userID,
+			Status:       domain.DeletionScheduled,
+			ScheduledFor: created.Add(u.opts.CoolingOff),
+			CreatedAt:    created,
+		}
+		return u.repo.CreateDeletion(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (u *privacyUC) GetDeletion(ctx context.Context, userID uuid.UUID) (*domain.DeletionRequest, error) {
+	return u.repo.LatestDeletion(ctx, userID)
+}
+
+func (u *privacyUC) CancelDeletion(ctx context.Context, userID uuid.UUID) (*domain.DeletionRequest, error) {
+	var req *domain.DeletionRequest
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if req, err = u.repo.ActiveDeletion(ctx, userID); err != nil {
+			return err
+		}
+		if req.Status != domain.DeletionScheduled {
+			return domain.ErrDeletionNotPending
+		}
+		cancelled := now()
+		req.Status = domain.DeletionCancelled
+		req.CancelledAt = &cancelled
+		return u.repo.UpdateDeletion(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// ProcessDeletions erases the users whose cooling-off period has passed.
+func (u *privacyUC) ProcessDeletions(ctx context.Context) (int, error) {
+	due, err := u.repo.DueDeletions(ctx, now(), privacyBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, req := range due {
+		erased, err := u.erase(ctx, req.ID)
+		if err != nil {
+			return n, err
+		}
+		if erased {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// erase pseudonymises the account data of one request and asks the other
+// services to do the same. Objects are deleted once the change committed.
+//
+// Access tokens are not tracked, so one issued before the erasure stays
+// valid until it expires (15 minutes); auth revokes the refresh tokens and
+// the account can no longer log in.
+func (u *privacyUC) erase(ctx context.Context, requestID uuid.UUID) (bool, error) {
+	var objects []string
+	var erased bool
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		req, err := u.repo.GetDeletion(ctx, requestID)
+		if err != nil {
+			return err
+		}
+		if req.Status != domain.DeletionScheduled {
+			return nil // cancelled or taken by another instance meanwhile
+		}
+
+		profile, err := u.accounts.GetProfileForUpdate(ctx, req.UserID)
+		if err != nil && !errors.Is(err, domain.ErrProfileNotFound) {
+			return err
+		}
+		if profile != nil {
+			objects = append(objects, profile.AvatarKey, profile.AvatarThumbKey)
+		}
+
+		erasedAt := now()
+		if err := u.repo.ErasePersonalData(ctx, req.UserID, erasedAt); err != nil {
+			return err
+		}
+
+		// Archives are copies of what was just erased. Open exports are
+		// failed first, so a build finishing meanwhile discards its archive
+		// (see finishExport) or is already ready when listed below.
+		if err := u.repo.FailOpenExports(ctx, req.UserID, erasedExportReason); err != nil {
+			return err
+		}
+		exports, err := u.repo.ListExports(ctx, req.UserID)
+		if err != nil {
+			return err
+		}
+		for i := range exports {
+			if exports[i].Status != domain.ExportReady {
+				continue
+			}
+			objects = append(objects, exports[i].StorageKey)
+			exports[i].Status = domain.ExportExpired
+			exports[i].StorageKey = ""
+			if err := u.repo.UpdateExport(ctx, &exports[i]); err != nil {
+				return err
+			}
+		}
+
+		req.Status = domain.DeletionErasing
+		req.ErasedAt = &erasedAt
+		if err := u.repo.UpdateDeletion(ctx, req); err != nil {
+			return err
+		}
+		erased = true
+		return u.events.PublishUserErasureRequested(ctx, events.UserErasureRequested{
+			UserID:    req.UserID.String(),
+			RequestID: req.ID.String(),
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, key := range objects {
+		if key == "" {
+			continue
+		}
+		if err := u.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			logger.From(ctx).Warn().Err(err).
+				Str("action", "user_erasure").
+				Str("status", "error").
+				Str("key", key).
+				Msg("Failed to delete object of erased user")
+		}
+	}
+	return erased, nil
+}
+
+func (u *privacyUC) ConfirmErasure(ctx context.Context, event events.UserErased) error {
+	requestID, err := uuid.Parse(event.RequestID)
+	if err != nil {
+		return fmt.Errorf("%w: request_id %q", domain.ErrDeletionNotFound, event.RequestID)
+	}
+
+	return u.tx.Do(ctx, func(ctx context.Context) error {
+		req, err := u.repo.GetDeletion(ctx, requestID)
+		if err != nil {
+			return err
+		}
+		if req.Status != domain.DeletionErasing || slices.Contains(req.ConfirmedBy, event.Service) {
+			return nil
+		}
+
+		req.ConfirmedBy = append(req.ConfirmedBy, event.Service)
+		if !slices.ContainsFunc(domain.ErasingServices, func(s string) bool {
+			return !slices.Contains(req.ConfirmedBy, s)
+		}) {
+			completed := now()
+			req.Status = domain.DeletionCompleted
+			req.CompletedAt = &completed
+		}
+		return u.repo.UpdateDeletion(ctx, req)
+	})
+}
@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bitka/pkg/database/databasetest"
+	"bitka/pkg/events"
+	"bitka/pkg/storage"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakePrivacy keeps exports and deletion requests in memory.
+type fakePrivacy struct {
+	domain.PrivacyRepository
+	exports   []domain.DataExport
+	deletions []domain.DeletionRequest
+	erased    []uuid.UUID
+}
+
+func (r *fakePrivacy) export(id uuid.UUID) *domain.DataExport {
+	for i := range r.exports {
+		if r.exports[i].ID == id {
+			return &r.exports[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakePrivacy) deletion(id uuid.UUID) *domain.DeletionRequest {
+	for i := range r.deletions {
+		if r.deletions[i].ID == id {
+			return &r.deletions[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakePrivacy) CreateExport(_ context.Context, export *domain.DataExport) error {
+	r.exports = append(r.exports, *export)
+	return nil
+}
+
+func (r *fakePrivacy) UpdateExport(_ context.Context, export *domain.DataExport) error {
+	*r.export(export.ID) = *export
+	return nil
+}
+
+func (r *fakePrivacy) GetExport(_ context.Context, id uuid.UUID) (*domain.DataExport, error) {
+	export := r.export(id)
+	if export == nil {
+		return nil, domain.ErrExportNotFound
+	}
+	e := *export
+	return &e, nil
+}
+
+func (r *fakePrivacy) ListExports(_ context.Context, userID uuid.UUID) ([]domain.DataExport, error) {
+	var out []domain.DataExport
+	for _, e := range r.exports {
+		if e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePrivacy) NextPendingExport(_ context.Context, now time.Time) (*domain.DataExport, error) {
+	for _, e := range r.exports {
+		lapsed := e.Status == domain.ExportProcessing && e.ClaimedUntil != nil && e.ClaimedUntil.Before(now)
+		if e.Status == domain.ExportPending || lapsed {
+			return &e, nil
+		}
+	}
+	return nil, domain.ErrExportNotFound
+}
+
+func (r *fakePrivacy) FailOpenExports(_ context.Context, userID uuid.UUID, reason string) error {
+	for i, e := range r.exports {
+		if e.UserID == userID && (e.Status == domain.ExportPending || e.Status == domain.ExportProcessing) {
+			r.exports[i].Status = domain.ExportFailed
+			r.exports[i].Error = reason
+		}
+	}
+	return nil
+}
+
+func (r *fakePrivacy) CreateDeletion(_ context.Context, req *domain.DeletionRequest) error {
+	r.deletions = append(r.deletions, *req)
+	return nil
+}
+
+func (r *fakePrivacy) UpdateDeletion(_ context.Context, req *domain.DeletionRequest) error {
+	*r.deletion(req.ID) = *req
+	return nil
+}
+
+func (r *fakePrivacy) GetDeletion(_ context.Context, id uuid.UUID) (*domain.DeletionRequest, error) {
+	req := r.deletion(id)
+	if req == nil {
+		return nil, domain.ErrDeletionNotFound
+	}
+	d := *req
+	return &d, nil
+}
+
+func (r *fakePrivacy) ActiveDeletion(_ context.Context, userID uuid.UUID) (*domain.DeletionRequest, error) {
+	for _, d := range r.deletions {
+		if d.UserID == userID && (d.Status == domain.DeletionScheduled || d.Status == domain.DeletionErasing) {
+			return &d, nil
+		}
+	}
+	return nil, domain.ErrDeletionNotFound
+}
+
+func (r *fakePrivacy) DueDeletions(_ context.Context, now time.Time, _ int) ([]domain.DeletionRequest, error) {
+	var out []domain.DeletionRequest
+	for _, d := range r.deletions {
+		if d.Status == domain.DeletionScheduled && !d.ScheduledFor.After(now) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePrivacy) ErasePersonalData(_ context.Context, userID uuid.UUID, _ time.Time) error {
+	r.erased = append(r.erased, userID)
+	return nil
+}
+
+type privacyEvents struct {
+	erasures []events.UserErasureRequested
+}
+
+func (e *privacyEvents) PublishUserErasureRequested(_ context.Context, event events.UserErasureRequested) error {
+	e.erasures = append(e.erasures, event)
+	return nil
+}
+
+type privacyTest struct {
+	uc      *privacyUC
+	repo    *fakePrivacy
+	profile *singleProfile
+	kyc     *fakeKyc
+	store   *storage.Local
+	events  *privacyEvents
+}
+
+func newTestPrivacyUsecase(t *testing.T, userID uuid.UUID) privacyTest {
+	t.Helper()
+	store, err := storage.NewLocal(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := privacyTest{
+		repo:    &fakePrivacy{},
+		profile: &singleProfile{profile: domain.Profile{UserID: userID}},
+		kyc:     newFakeKyc(),
+		store:   store,
+		events:  &privacyEvents{},
+	}
+	// Only erasure and the export claim run here; anything else reaching
+	// the other repositories panics on their nil interfaces
+	pt.uc = NewPrivacyUsecase(pt.repo, pt.profile, nil, pt.kyc, nil, nil, nil,
+		databasetest.NewTxManager(), store, pt.events,
+		PrivacyOptions{CoolingOff: 14 * 24 * time.Hour, ExportTTL: 7 * 24 * time.Hour},
+	).(*privacyUC)
+	return pt
+}
+
+func (pt privacyTest) put(t *testing.T, key string) {
+	t.Helper()
+	if err := pt.store.Put(context.Background(), key, []byte("data"), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (pt privacyTest) exists(t *testing.T, key string) bool {
+	t.Helper()
+	_, err := pt.store.Stat(context.Background(), key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestClaimExport(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lapsed := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		export     domain.DataExport
+		deletion   domain.DeletionStatus
+		wantStatus domain.ExportStatus
+		wantError  string
+	}{
+		{name: "pending", export: domain.DataExport{Status: domain.ExportPending}, wantStatus: domain.ExportProcessing},
+		{
+			name:       "lapsed claim is taken over",
+			export:     domain.DataExport{Status: domain.ExportProcessing, ClaimedUntil: &lapsed},
+			wantStatus: domain.ExportProcessing,
+		},
+		{
+			name:       "scheduled deletion still exports",
+			export:     domain.DataExport{Status: domain.ExportPending},
+			deletion:   domain.DeletionScheduled,
+			wantStatus: domain.ExportProcessing,
+		},
+		{
+			name:       "erasure started",
+			export:     domain.DataExport{Status: domain.ExportPending},
+			deletion:   domain.DeletionErasing,
+			wantStatus: domain.ExportFailed,
+			wantError:  erasedExportReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestPrivacyUsecase(t, userID)
+			tt.export.ID, tt.export.UserID = uuid.New(), userID
+			pt.repo.exports = []domain.DataExport{tt.export}
+			if tt.deletion != "" {
+				pt.repo.deletions = []domain.DeletionRequest{{ID: uuid.New(), UserID: userID, Status: tt.deletion}}
+			}
+
+			before := time.Now()
+			export, err := pt.uc.claimExport(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := pt.repo.exports[0]
+			if export.Status != tt.wantStatus || stored.Status != tt.wantStatus || stored.Error != tt.wantError {
+				t.Fatalf("claimed %s, stored %s (%q)", export.Status, stored.Status, stored.Error)
+			}
+			if tt.wantStatus == domain.ExportProcessing {
+				if stored.ClaimedUntil == nil || stored.ClaimedUntil.Before(before.Add(exportClaimTTL)) {
+					t.Fatalf("claimed until %v", stored.ClaimedUntil)
+				}
+			} else if stored.ClaimedUntil != nil {
+				t.Fatal("a refused export kept its claim")
+			}
+
+			// A live claim hides the export from the next worker
+			if next, err := pt.uc.claimExport(ctx); err != nil || next != nil {
+				t.Fatalf("second claim = %v, %v", next, err)
+			}
+		})
+	}
+}
+
+func TestFinishExportDiscardsArchiveAfterErasure(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	pt := newTestPrivacyUsecase(t, userID)
+
+	export := domain.DataExport{ID: uuid.New(), UserID: userID, Status: domain.ExportPending}
+	pt.repo.exports = []domain.DataExport{export}
+	claimed, err := pt.uc.claimExport(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The build finishes after erasure failed the export
+	key := domain.ExportPrefix + userID.String() + "/" + export.ID.String() + ".zip"
+	pt.put(t, key)
+	if err := pt.repo.FailOpenExports(ctx, userID, erasedExportReason); err != nil {
+		t.Fatal(err)
+	}
+	claimed.Status, claimed.StorageKey = domain.ExportReady, key
+
+	if err := pt.uc.finishExport(ctx, claimed); err != nil {
+		t.Fatal(err)
+	}
+	if stored := pt.repo.exports[0]; stored.Status != domain.ExportFailed || stored.StorageKey != "" {
+		t.Fatalf("stored export %s with key %q", stored.Status, stored.StorageKey)
+	}
+	if pt.exists(t, key) {
+		t.Fatal("the archive of an erased user was kept")
+	}
+}
+
+func TestCancelDeletion(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	pt := newTestPrivacyUsecase(t, userID)
+
+	req, err := pt.uc.RequestDeletion(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Status != domain.DeletionScheduled || !req.ScheduledFor.Equal(req.CreatedAt.Add(pt.uc.opts.CoolingOff)) {
+		t.Fatalf("request %s for %v", req.Status, req.ScheduledFor)
+	}
+	if _, err := pt.uc.RequestDeletion(ctx, userID); !errors.Is(err, domain.ErrDeletionScheduled) {
+		t.Fatalf("second request: err = %v", err)
+	}
+
+	cancelled, err := pt.uc.CancelDeletion(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != domain.DeletionCancelled || cancelled.CancelledAt == nil {
+		t.Fatalf("cancelled request %s at %v", cancelled.Status, cancelled.CancelledAt)
+	}
+	if _, err := pt.uc.CancelDeletion(ctx, userID); !errors.Is(err, domain.ErrDeletionNotFound) {
+		t.Fatalf("second cancel: err = %v", err)
+	}
+
+	// Once the grace period is over nothing is erased
+	pt.repo.deletions[0].ScheduledFor = time.Now().Add(-time.Hour)
+	if n, err := pt.uc.ProcessDeletions(ctx); err != nil || n != 0 {
+		t.Fatalf("ProcessDeletions = %d, %v", n, err)
+	}
+	// Nor when the cancel lands between listing and erasing
+	if erased, err := pt.uc.erase(ctx, req.ID); err != nil || erased {
+		t.Fatalf("erase = %v, %v", erased, err)
+	}
+	if len(pt.repo.erased) != 0 || len(pt.events.erasures) != 0 {
+		t.Fatal("a cancelled deletion was erased")
+	}
+
+	// The user may ask again, but not cancel once erasure started
+	again, err := pt.uc.RequestDeletion(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt.repo.deletion(again.ID).Status = domain.DeletionErasing
+	if _, err := pt.uc.CancelDeletion(ctx, userID); !errors.Is(err, domain.ErrDeletionNotPending) {
+		t.Fatalf("cancel while erasing: err = %v", err)
+	}
+}
+
+func TestEraseKeepsKyc(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	pt := newTestPrivacyUsecase(t, userID)
+
+	pt.profile.profile.AvatarKey = "avatars/" + userID.String() + ".png"
+	pt.put(t, pt.profile.profile.AvatarKey)
+	app := domain.KycApplication{ID: uuid.New(), UserID: userID, Status: domain.KycApproved, Level: domain.KycLevel2}
+	pt.kyc.apps[app.ID] = app
+	pt.kyc.profiles[userID] = profileKyc{status: domain.KycApproved, level: domain.KycLevel2}
+
+	ready := domain.DataExport{ID: uuid.New(), UserID: userID, Status: domain.ExportReady, StorageKey: domain.ExportPrefix + "ready.zip"}
+	pt.put(t, ready.StorageKey)
+	pending := domain.DataExport{ID: uuid.New(), UserID: userID, Status: domain.ExportPending}
+	pt.repo.exports = []domain.DataExport{ready, pending}
+
+	req := domain.DeletionRequest{ID: uuid.New(), UserID: userID, Status: domain.DeletionScheduled, ScheduledFor: time.Now().Add(-time.Minute)}
+	pt.repo.deletions = []domain.DeletionRequest{req}
+
+	if n, err := pt.uc.ProcessDeletions(ctx); err != nil || n != 1 {
+		t.Fatalf("ProcessDeletions = %d, %v", n, err)
+	}
+
+	if len(pt.repo.erased) != 1 || pt.repo.erased[0] != userID {
+		t.Fatalf("erased %v", pt.repo.erased)
+	}
+	if d := pt.repo.deletions[0]; d.Status != domain.DeletionErasing || d.ErasedAt == nil {
+		t.Fatalf("deletion %s, erased at %v", d.Status, d.ErasedAt)
+	}
+	want := events.UserErasureRequested{UserID: userID.String(), RequestID: req.ID.String()}
+	if len(pt.events.erasures) != 1 || pt.events.erasures[0] != want {
+		t.Fatalf("events = %v", pt.events.erasures)
+	}
+
+	// Copies of the data go: exports, archives and the avatar
+	if e := pt.repo.exports[0]; e.Status != domain.ExportExpired || e.StorageKey != "" {
+		t.Fatalf("ready export %s with key %q", e.Status, e.StorageKey)
+	}
+	if e := pt.repo.exports[1]; e.Status != domain.ExportFailed || e.Error != erasedExportReason {
+		t.Fatalf("pending export %s (%q)", e.Status, e.Error)
+	}
+	if pt.exists(t, ready.StorageKey) || pt.exists(t, pt.profile.profile.AvatarKey) {
+		t.Fatal("objects of the erased user were kept")
+	}
+
+	// KYC records must be retained
+	if got := pt.kyc.apps[app.ID]; len(pt.kyc.apps) != 1 || got.Status != app.Status || got.Level != app.Level {
+		t.Fatalf("KYC applications = %v", pt.kyc.apps)
+	}
+	if got := pt.kyc.profiles[userID]; got.status != domain.KycApproved || got.level != domain.KycLevel2 {
+		t.Fatalf("KYC state = %v", got)
+	}
+
+	// A second run finds nothing left to do
+	if n, err := pt.uc.ProcessDeletions(ctx); err != nil || n != 0 {
+		t.Fatalf("second ProcessDeletions = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bitka/pkg/tracing"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+// maxFullNameLength bounds full_name, in characters.
+const maxFullNameLength = 100
+
+// maxAddressLineLength bounds each address component, in characters.
+const maxAddressLineLength = 100
+
+// patchField is a profile field a merge patch may change. Fields not listed
+// here (email, username, KYC, avatar, phone) are owned by other flows.
+// Values are handled as strings; structured fields use their canonical JSON.
+type patchField struct {
+	column string
+	// Sensitive fields are encrypted at rest; the change log records that
+	// they changed, not their values.
+	sensitive bool
+	get       func(*domain.Profile) string
+	set       func(*domain.Profile, string)
+	// parse validates a non-null member and returns its canonical value
+	parse func(json.RawMessage) (string, error)
+}
+
+var patchFields = map[string]patchField{
+	"full_name": {
+		column: "full_name",
+		get:    func(p *domain.Profile) string { return p.FullName },
+		set:    func(p *domain.Profile, v string) { p.FullName = v },
+		parse: stringMember(func(v string) (string, error) {
+			v = strings.TrimSpace(v)
+			if utf8.RuneCountInString(v) > maxFullNameLength {
+				return "", fmt.Errorf("at most %d characters", maxFullNameLength)
+			}
+			return v, nil
+		}),
+	},
+	"nationality": {
+		column:    "nationality",
+		sensitive: true,
+		get:       func(p *domain.Profile) string { return p.Nationality },
+		set:       func(p *domain.Profile, v string) { p.Nationality = v },
+		parse:     stringMember(parseCountry),
+	},
+	"date_of_birth": {
+		column:    "date_of_birth",
+		sensitive: true,
+		get:       func(p *domain.Profile) string { return p.DateOfBirth },
+		set:       func(p *domain.Profile, v string) { p.DateOfBirth = v },
+		parse:     stringMember(parseDateOfBirth),
+	},
+	"address": {
+		column:    "address",
+		sensitive: true,
+		get: func(p *domain.Profile) string {
+			if p.Address == nil {
+				return ""
+			}
+			b, _ := json.Marshal(p.Address)
+			return string(b)
+		},
+		set: func(p *domain.Profile, v string) {
+			p.Address = nil
+			if v != "" {
+				p.Address = &domain.Address{}
+				_ = json.Unmarshal([]byte(v), p.Address)
+			}
+		},
+		parse: parseAddress,
+	},
+}
+
+// stringMember adapts a string parser to a JSON member.
+func stringMember(parse func(string) (string, error)) func(json.RawMessage) (string, error) {
+	return func(raw json.RawMessage) (string, error) {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "", errors.New("must be a string or null")
+		}
+		return parse(v)
+	}
+}
+
+// parseCountry accepts an ISO 3166-1 alpha-2 code in any case.
+func parseCountry(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	region, err := language.ParseRegion(v)
+	if err != nil || len(v) != 2 || !region.IsCountry() {
+		return "", errors.New("must be an ISO 3166-1 alpha-2 country code")
+	}
+	return region.String(), nil
+}
+
+// parseDateOfBirth accepts a YYYY-MM-DD date of someone at least
+// domain.MinimumAge years old.
+func parseDateOfBirth(v string) (string, error) {
+	dob, err := time.Parse(time.DateOnly, strings.TrimSpace(v))
+	if err != nil {
+		return "", errors.New("must be a date (YYYY-MM-DD)")
+	}
+	today := now()
+	if dob.Year() < 1900 || dob.After(today) {
+		return "", errors.New("is out of range")
+	}
+	if dob.AddDate(domain.MinimumAge, 0, 0).After(today) {
+		return "", fmt.Errorf("must be at least %d years ago", domain.MinimumAge)
+	}
+	return dob.Format(time.DateOnly), nil
+}
+
+// parseAddress validates a whole address; unlike the top-level members, it
+// is replaced rather than merged.
+func parseAddress(raw json.RawMessage) (string, error) {
+	var a domain.Address
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&a); err != nil {
+		return "", fmt.Errorf("must be an address object: %v", err)
+	}
+
+	for _, part := range []struct {
+		name     string
+		value    *string
+		required bool
+	}{
+		{"line1", &a.Line1, true},
+		{"line2", &a.Line2, false},
+		{"city", &a.City, true},
+		{"postal_code", &a.PostalCode, true},
+		{"region", &a.Region, false},
+	} {
+		*part.value = strings.TrimSpace(*part.value)
+		if part.required && *part.value == "" {
+			return "", fmt.Errorf("%s is required", part.name)
+		}
+		if utf8.RuneCountInString(*part.value) > maxAddressLineLength {
+			return "", fmt.Errorf("%s must be at most %d characters", part.name, maxAddressLineLength)
+		}
+	}
+
+	country, err := parseCountry(a.Country)
+	if err != nil {
+		return "", fmt.Errorf("country %v", err)
+	}
+	a.Country = country
+
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+// patchValue is a validated field of a patch. null clears to "".
+type patchValue struct {
+	patchField
+	name  string
+	value string
+}
+
+// parsePatch validates every member before anything is written, in a
+// stable order so the change log is deterministic.
+func parsePatch(patch domain.ProfilePatch) ([]patchValue, error) {
+	names := make([]string, 0, len(patch))
+	for name := range patch {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]patchValue, 0, len(names))
+	for _, name := range names {
+		f, ok := patchFields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s cannot be changed", domain.ErrPatchInvalid, name)
+		}
+
+		raw := bytes.TrimSpace(patch[name])
+		var v string
+		if !bytes.Equal(raw, []byte("null")) {
+			var err error
+			if v, err = f.parse(raw); err != nil {
+				return nil, fmt.Errorf("%w: %s %v", domain.ErrPatchInvalid, name, err)
+			}
+		}
+		values = append(values, patchValue{patchField: f, name: name, value: v})
+	}
+	return values, nil
+}
+
+// matchesETag implements If-Match: empty and "*" match any version,
+// otherwise one of the listed tags must equal etag. If-Match uses the
+// strong comparison (RFC 7232), so weak tags never match.
+func matchesETag(ifMatch, etag string) bool {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// newChange builds a change log row attributed to the user themselves.
+func newChange(ctx context.Context, userID uuid.UUID, field, oldValue, newValue string) domain.ProfileChange {
+	actor := userID
+	return domain.ProfileChange{
+		UserID:    userID,
+		Field:     field,
+		OldValue:  jsonString(oldValue),
+		NewValue:  jsonString(newValue),
+		ActorID:   &actor,
+		Source:    domain.ChangeSourceUser,
+		TraceID:   tracing.TraceIDFrom(ctx),
+		ChangedAt: now(),
+	}
+}
+
+// redactedValue stands in for the values of sensitive fields in the
+// change log.
+var redactedValue = json.RawMessage(`"[redacted]"`)
+
+// redactChange keeps whether a sensitive field was set or cleared, but
+// not its values.
+func redactChange(c domain.ProfileChange) domain.ProfileChange {
+	if string(c.OldValue) != "null" {
+		c.OldValue = redactedValue
+	}
+	if string(c.NewValue) != "null" {
+		c.NewValue = redactedValue
+	}
+	return c
+}
+
+// jsonString encodes v, with "" as null (a cleared field).
+func jsonString(v string) json.RawMessage {
+	if v == "" {
+		return json.RawMessage("null")
+	}
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// now is truncated to what Postgres stores, so an ETag computed before a
+// write matches the one computed after reading the row back.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitka/pkg/logger"
+	"bitka/pkg/metrics"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ReconcileOptions configures reconciliation runs.
+type ReconcileOptions struct {
+	PageSize int           // auth users fetched per request
+	Grace    time.Duration // users registered more recently are skipped; their event may be in flight
+}
+
+type reconcileUC struct {
+	accounts  domain.AccountRepository
+	referrals domain.ReferralUsecase
+	auth      domain.AuthDirectory
+	opts      ReconcileOptions
+}
+
+func NewReconcileUsecase(accounts domain.AccountRepository, referrals domain.ReferralUsecase, auth domain.AuthDirectory, opts ReconcileOptions) domain.ReconcileUsecase {
+	return &reconcileUC{accounts: accounts, referrals: referrals, auth: auth, opts: opts}
+}
+
+func (u *reconcileUC) Reconcile(ctx context.Context, dryRun bool) (*domain.ReconcileReport, error) {
+	report := &domain.ReconcileReport{StartedAt: now(), DryRun: dryRun}
+	err := u.run(ctx, report)
+	report.FinishedAt = now()
+
+	metrics.ObserveReconcile(map[string]int{
+		metrics.DriftMissing:    report.Missing.Count - report.Backfilled,
+		metrics.DriftOrphaned:   report.Orphaned.Count,
+		metrics.DriftMismatched: report.Mismatched.Count,
+	}, report.Backfilled, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Missing.Count+report.Orphaned.Count+report.Mismatched.Count > 0 {
+		logger.From(ctx).Warn().
+			Str("action", "profile_reconcile").
+			Str("status", "drift").
+			Bool("dry_run", dryRun).
+			Int("missing", report.Missing.Count).
+			Int("backfilled", report.Backfilled).
+			Int("orphaned", report.Orphaned.Count).
+			Int("mismatched", report.Mismatched.Count).
+			Msg("Profiles drifted from auth users")
+	}
+	return report, nil
+}
+
+// run pages through auth users by ID and compares each page with the
+// profiles in the same ID range, so both sides are read exactly once.
+func (u *reconcileUC) run(ctx context.Context, report *domain.ReconcileReport) error {
+	cutoff := report.StartedAt.Add(-u.opts.Grace)
+
+	after := uuid.Nil
+	for {
+		users, next, err := u.auth.ListUsers(ctx, after, u.opts.PageSize)
+		if err != nil {
+			return err
+		}
+
+		// The last page also covers profiles past the highest auth user
+		until := uuid.Max
+		if next != uuid.Nil {
+			until = next
+		}
+		profiles, err := u.accounts.ListProfileRange(ctx, after, until)
+		if err != nil {
+			return err
+		}
+
+		report.AuthUsers += len(users)
+		report.Profiles += len(profiles)
+		if err := u.compare(ctx, report, users, profiles, cutoff); err != nil {
+			return err
+		}
+
+		if next == uuid.Nil {
+			return nil
+		}
+		after = next
+	}
+}
+
+func (u *reconcileUC) compare(ctx context.Context, report *domain.ReconcileReport, users []domain.AuthUser, profiles []domain.Profile, cutoff time.Time) error {
+	byID := make(map[uuid.UUID]*domain.Profile, len(profiles))
+	for i := range profiles {
+		byID[profiles[i].UserID] = &profiles[i]
+	}
+
+	for _, user := range users {
+		profile, ok := byID[user.ID]
+		delete(byID, user.ID)
+
+		switch {
+		case !ok:
+			// Erased users need no profile; new ones may not have one yet
+			if user.Erased || user.CreatedAt.After(cutoff) {
+				continue
+			}
+			report.Missing.Add(user.ID)
+			if report.DryRun {
+				continue
+			}
+			// Attribution first: it is idempotent, and a failure leaves the
+			// profile missing so the next run retries both
+			if err := u.attribute(ctx, user); err != nil {
+				return err
+			}
+			if err := u.accounts.CreateProfile(ctx, user.ID, user.Email, user.Username); err != nil {
+				return err
+			}
+			report.Backfilled++
+
+		case user.Erased || profile.Username == domain.Pseudonym(user.ID):
+			// Erasure pseudonymises each side on its own schedule
+
+		case profile.Email != user.Email || profile.Username != user.Username:
+			report.Mismatched.Add(user.ID)
+		}
+	}
+
+	for _, profile := range profiles {
+		if _, ok := byID[profile.UserID]; ok {
+			report.Orphaned.Add(profile.UserID)
+		}
+	}
+	return nil
+}
+
+// attribute records the referral a backfilled user registered with, as
+// the lost UserRegistered event would have. Unknown codes are dropped.
+func (u *reconcileUC) attribute(ctx context.Context, user domain.AuthUser) error {
+	if user.ReferralCode == "" {
+		return nil
+	}
+	err := u.referrals.Attribute(ctx, user.ID, user.ReferralCode)
+	if errors.Is(err, domain.ErrReferralCodeNotFound) {
+		logger.From(ctx).Warn().
+			Str("action", "referral_attribution").
+			Str("status", "skipped").
+			Str("user_id", user.ID.String()).
+			Msg("Unknown referral code")
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeDirectory pages through users like the auth internal API: a full
+// page returns its last ID as the cursor, a short one ends the walk.
+type fakeDirectory struct {
+	domain.AuthDirectory
+	users []domain.AuthUser // sorted by ID
+	calls int
+}
+
+func (d *fakeDirectory) ListUsers(_ context.Context, after uuid.UUID, limit int) ([]domain.AuthUser, uuid.UUID, error) {
+	d.calls++
+	var page []domain.AuthUser
+	for _, u := range d.users {
+		if bytes.Compare(u.ID[:], after[:]) > 0 && len(page) < limit {
+			page = append(page, u)
+		}
+	}
+	next := uuid.Nil
+	if len(page) == limit {
+		next = page[len(page)-1].ID
+	}
+	return page, next, nil
+}
+
+// fakeAccounts serves profiles by ID range and records backfills.
+type fakeAccounts struct {
+	domain.AccountRepository
+	profiles []domain.Profile // sorted by user ID
+	reads    map[uuid.UUID]int
+	created  []uuid.UUID
+}
+
+func (a *fakeAccounts) ListProfileRange(_ context.Context, after, until uuid.UUID) ([]domain.Profile, error) {
+	var out []domain.Profile
+	for _, p := range a.profiles {
+		if bytes.Compare(p.UserID[:], after[:]) > 0 && bytes.Compare(p.UserID[:], until[:]) <= 0 {
+			out = append(out, p)
+			a.reads[p.UserID]++
+		}
+	}
+	return out, nil
+}
+
+func (a *fakeAccounts) CreateProfile(_ context.Context, userID uuid.UUID, _, _ string) error {
+	a.created = append(a.created, userID)
+	return nil
+}
+
+// fakeReferrals knows the codes in codes and records attributions.
+type fakeReferrals struct {
+	domain.ReferralUsecase
+	codes      map[string]bool
+	attributed map[uuid.UUID]string
+}
+
+func (r *fakeReferrals) Attribute(_ context.Context, refereeID uuid.UUID, code string) error {
+	if !r.codes[code] {
+		return domain.ErrReferralCodeNotFound
+	}
+	r.attributed[refereeID] = code
+	return nil
+}
+
+func testID(n int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", n))
+}
+
+func TestReconcile(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	user := func(n int, email, username string) domain.AuthUser {
+		return domain.AuthUser{ID: testID(n), Email: email, Username: username, CreatedAt: old}
+	}
+	profile := func(n int, email, username string) domain.Profile {
+		return domain.Profile{UserID: testID(n), Email: email, Username: username}
+	}
+	invited := func(n int, email, username, code string) domain.AuthUser {
+		u := user(n, email, username)
+		u.ReferralCode = code
+		return u
+	}
+
+	recent := user(6, "new@x.io", "new")
+	recent.CreatedAt = time.Now()
+	erasedNoProfile := user(7, "e7@erased.invalid", domain.Pseudonym(testID(7)))
+	erasedNoProfile.Erased = true
+	erasedStale := user(8, "e8@erased.invalid", domain.Pseudonym(testID(8)))
+	erasedStale.Erased = true
+
+	users := []domain.AuthUser{
+		invited(1, "a@x.io", "a", "FRIEND22"),  // in sync, nothing to attribute
+		invited(2, "b@x.io", "b", "FRIEND22"),  // missing, invited
+		user(4, "d@x.io", "d"),                 // email differs
+		user(5, "e@x.io", "e"),                 // username differs
+		recent,                                 // missing, but inside the grace period
+		erasedNoProfile,                        // erased, no profile needed
+		erasedStale,                            // erased in auth, account not yet
+		user(9, "i@x.io", "i"),                 // account already pseudonymised
+		invited(11, "k@x.io", "k", "GONE2222"), // missing, code since deleted
+	}
+	profiles := []domain.Profile{
+		profile(1, "a@x.io", "a"),
+		profile(3, "c@x.io", "c"), // orphan between users
+		profile(4, "old@x.io", "d"),
+		profile(5, "e@x.io", "old"),
+		profile(8, "h@x.io", "h"),
+		profile(9, "", domain.Pseudonym(testID(9))),
+		profile(12, "l@x.io", "l"), // orphan past the last user
+	}
+
+	for _, pageSize := range []int{1, 2, 3, 4, 9, 100} {
+		for _, dryRun := range []bool{false, true} {
+			t.Run(fmt.Sprintf("page=%d dry=%v", pageSize, dryRun), func(t *testing.T) {
+				dir := &fakeDirectory{users: users}
+				accounts := &fakeAccounts{profiles: profiles, reads: map[uuid.UUID]int{}}
+				referrals := &fakeReferrals{codes: map[string]bool{"FRIEND22": true}, attributed: map[uuid.UUID]string{}}
+				uc := NewReconcileUsecase(accounts, referrals, dir, ReconcileOptions{PageSize: pageSize, Grace: time.Minute})
+
+				report, err := uc.Reconcile(context.Background(), dryRun)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if report.AuthUsers != len(users) || report.Profiles != len(profiles) {
+					t.Errorf("read %d users and %d profiles, want %d and %d",
+						report.AuthUsers, report.Profiles, len(users), len(profiles))
+				}
+				for _, p := range profiles {
+					if n := accounts.reads[p.UserID]; n != 1 {
+						t.Errorf("profile %s read %d times", p.UserID, n)
+					}
+				}
+				if want := len(users)/pageSize + 1; dir.calls != want {
+					t.Errorf("directory called %d times, want %d", dir.calls, want)
+				}
+
+				wantMissing := []uuid.UUID{testID(2), testID(11)}
+				checkDrift(t, "missing", report.Missing, wantMissing)
+				checkDrift(t, "orphaned", report.Orphaned, []uuid.UUID{testID(3), testID(12)})
+				checkDrift(t, "mismatched", report.Mismatched, []uuid.UUID{testID(4), testID(5)})
+
+				if dryRun {
+					if len(accounts.created) != 0 || report.Backfilled != 0 || len(referrals.attributed) != 0 {
+						t.Errorf("dry run created %v, attributed %v", accounts.created, referrals.attributed)
+					}
+					return
+				}
+				if !slices.Equal(accounts.created, wantMissing) || report.Backfilled != len(wantMissing) {
+					t.Errorf("created %v (backfilled %d), want %v", accounts.created, report.Backfilled, wantMissing)
+				}
+				// Only the backfilled user with a known code is attributed
+				if len(referrals.attributed) != 1 || referrals.attributed[testID(2)] != "FRIEND22" {
+					t.Errorf("attributed %v, want user 2 to FRIEND22", referrals.attributed)
+				}
+			})
+		}
+	}
+}
+
+func checkDrift(t *testing.T, name string, got domain.Drift, want []uuid.UUID) {
+	t.Helper()
+	if got.Count != len(want) || !slices.Equal(got.Sample, want) {
+		t.Errorf("%s = %d %v, want %v", name, got.Count, got.Sample, want)
+	}
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ReferralOptions bounds the referral tree queries.
+type ReferralOptions struct {
+	MaxTreeDepth int // levels below the referrer a tree may show
+	MaxTreeSize  int // users a tree may hold
+}
+
+// referralCodeAttempts is how often a colliding code is regenerated.
+const referralCodeAttempts = 5
+
+type referralUC struct {
+	repo domain.ReferralRepository
+	opts ReferralOptions
+}
+
+func NewReferralUsecase(repo domain.ReferralRepository, opts ReferralOptions) domain.ReferralUsecase {
+	return &referralUC{repo: repo, opts: opts}
+}
+
+func (u *referralUC) GetSummary(ctx context.Context, userID uuid.UUID) (*domain.ReferralSummary, error) {
+	code, err := u.code(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	summary := &domain.ReferralSummary{Code: code.Code}
+
+	referral, err := u.repo.GetReferral(ctx, userID)
+	switch {
+	case err == nil:
+		summary.ReferredBy = &referral.ReferrerID
+	case !errors.Is(err, domain.ErrReferralNotFound):
+		return nil, err
+	}
+
+	if summary.Invited, summary.Verified, err = u.repo.CountReferred(ctx, userID); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (u *referralUC) ListReferred(ctx context.Context, userID uuid.UUID, page domain.Page) ([]domain.ReferredUser, int64, error) {
+	users, total, err := u.repo.ListReferred(ctx, userID, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range users {
+		users[i].ResolveStatus()
+	}
+	return users, total, nil
+}
+
+func (u *referralUC) GetTree(ctx context.Context, userID uuid.UUID, depth int) (*domain.ReferralTree, error) {
+	if depth <= 0 || depth > u.opts.MaxTreeDepth {
+		depth = u.opts.MaxTreeDepth
+	}
+
+	// One extra row tells whether the tree was cut off
+	users, err := u.repo.ListReferralTree(ctx, userID, depth, u.opts.MaxTreeSize+1)
+	if err != nil {
+		return nil, err
+	}
+	tree := &domain.ReferralTree{Depth: depth, Children: []*domain.ReferralNode{}}
+	if len(users) > u.opts.MaxTreeSize {
+		users = users[:u.opts.MaxTreeSize]
+		tree.Truncated = true
+	}
+	tree.Total = len(users)
+
+	// Rows come ordered by depth, so every parent is placed before its children
+	nodes := make(map[uuid.UUID]*domain.ReferralNode, len(users))
+	for _, user := range users {
+		// Only direct invitees dealt with the user; the rest stay anonymous
+		if user.Depth == 1 {
+			user.ResolveStatus()
+		} else {
+			user.Username, user.KycStatus = "", ""
+		}
+		node := &domain.ReferralNode{ReferredUser: user}
+		nodes[user.UserID] = node
+
+		if parent, ok := nodes[user.ReferrerID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			tree.Children = append(tree.Children, node)
+		}
+	}
+	return tree, nil
+}
+
+func (u *referralUC) Attribute(ctx context.Context, refereeID uuid.UUID, code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	owner, err := u.repo.FindReferralCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	// A user cannot invite themselves
+	if owner.UserID == refereeID {
+		return domain.ErrReferralCodeNotFound
+	}
+
+	return u.repo.CreateReferral(ctx, &domain.Referral{
+		RefereeID:  refereeID,
+		ReferrerID: owner.UserID,
+		Code:       code,
+		CreatedAt:  now(),
+	})
+}
+
+// code returns the user's referral code, creating it on first use.
+func (u *referralUC) code(ctx context.Context, userID uuid.UUID) (*domain.ReferralCode, error) {
+	code, err := u.repo.GetReferralCode(ctx, userID)
+	if !errors.Is(err, domain.ErrReferralCodeNotFound) {
+		return code, err
+	}
+
+	for range referralCodeAttempts {
+		value, err := newReferralCode()
+		if err != nil {
+			return nil, err
+		}
+		err = u.repo.CreateReferralCode(ctx, &domain.ReferralCode{
+			UserID:    userID,
+			Code:      value,
+			CreatedAt: now(),
+		})
+		if errors.Is(err, domain.ErrReferralCodeTaken) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Re-read: a concurrent request may have created another code first
+		return u.repo.GetReferralCode(ctx, userID)
+	}
+	return nil, domain.ErrReferralCodeTaken
+}
+
+func newReferralCode() (string, error) {
+	alphabet := big.NewInt(int64(len(domain.ReferralAlphabet)))
+	var b strings.Builder
+	for range domain.ReferralCodeLength {
+		n, err := rand.Int(rand.Reader, alphabet)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(domain.ReferralAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
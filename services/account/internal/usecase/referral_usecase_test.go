@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeReferralTree returns rows as the tree query would, depth first.
+type fakeReferralTree struct {
+	domain.ReferralRepository
+	rows []domain.ReferredUser
+}
+
+func (r *fakeReferralTree) ListReferralTree(_ context.Context, _ uuid.UUID, depth, limit int) ([]domain.ReferredUser, error) {
+	var out []domain.ReferredUser
+	for _, u := range r.rows {
+		if u.Depth <= depth && len(out) < limit {
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+func TestGetTreeNamesDirectInviteesOnly(t *testing.T) {
+	root := testID(1)
+	row := func(n int, referrer uuid.UUID, depth int, kyc domain.KycStatus) domain.ReferredUser {
+		return domain.ReferredUser{UserID: testID(n), ReferrerID: referrer, Username: fmt.Sprintf("u%d", n), KycStatus: kyc, Depth: depth}
+	}
+	rows := []domain.ReferredUser{
+		row(2, root, 1, domain.KycApproved),
+		row(3, root, 1, domain.KycNone),
+		row(4, testID(2), 2, domain.KycApproved),
+		row(5, testID(4), 3, domain.KycApproved),
+	}
+
+	tests := []struct {
+		name       string
+		user       uuid.UUID
+		wantStatus domain.ReferralStatus
+		wantNamed  bool
+	}{
+		{name: "direct invitee, verified", user: testID(2), wantStatus: domain.ReferralVerified, wantNamed: true},
+		{name: "direct invitee, registered", user: testID(3), wantStatus: domain.ReferralRegistered, wantNamed: true},
+		{name: "second level", user: testID(4)},
+		{name: "third level", user: testID(5)},
+	}
+
+	uc := NewReferralUsecase(&fakeReferralTree{rows: rows}, ReferralOptions{MaxTreeDepth: 3, MaxTreeSize: 10})
+	tree, err := uc.GetTree(context.Background(), root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Total != len(rows) || len(tree.Children) != 2 {
+		t.Fatalf("total %d with %d roots", tree.Total, len(tree.Children))
+	}
+
+	nodes := map[uuid.UUID]*domain.ReferralNode{}
+	var walk func([]*domain.ReferralNode)
+	walk = func(children []*domain.ReferralNode) {
+		for _, n := range children {
+			nodes[n.UserID] = n
+			walk(n.Children)
+		}
+	}
+	walk(tree.Children)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := nodes[tt.user]
+			if !ok {
+				t.Fatal("not in the tree")
+			}
+			if n.Status != tt.wantStatus || (n.Username != "") != tt.wantNamed || (n.KycStatus != "") != tt.wantNamed {
+				t.Fatalf("username %q, status %q, kyc %q", n.Username, n.Status, n.KycStatus)
+			}
+			body, err := json.Marshal(n.ReferredUser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if named := strings.Contains(string(body), `"username"`) || strings.Contains(string(body), `"status"`); named != tt.wantNamed {
+				t.Fatalf("serialised as %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"bitka/pkg/database"
+	"bitka/pkg/logger"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// API key secrets are apiKeyPrefix and 32 random bytes, base64url
+// encoded. The prefix lets secret scanners recognise them; the first
+// apiKeyShown characters are kept to tell keys apart.
+const (
+	apiKeyPrefix = "bk_"
+	apiKeyShown  = len(apiKeyPrefix) + 8
+)
+
+// SubAccountOptions bounds what a user may create.
+type SubAccountOptions struct {
+	MaxPerUser int // sub-accounts, disabled ones included
+	MaxAPIKeys int // unrevoked API keys per sub-account
+}
+
+type subAccountUC struct {
+	repo     domain.SubAccountRepository
+	accounts domain.AccountRepository
+	tx       *database.TxManager
+	opts     SubAccountOptions
+}
+
+func NewSubAccountUsecase(repo domain.SubAccountRepository, accounts domain.AccountRepository, tx *database.TxManager, opts SubAccountOptions) domain.SubAccountUsecase {
+	return &subAccountUC{repo: repo, accounts: accounts, tx: tx, opts: opts}
+}
+
+func (u *subAccountUC) CreateSubAccount(ctx context.Context, parentID uuid.UUID, label string, permissions []string) (*domain.SubAccount, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []string{domain.PermissionRead}
+	}
+	if permissions, err = normalizePermissions(permissions); err != nil {
+		return nil, err
+	}
+
+	var sub *domain.SubAccount
+	err = u.tx.Do(ctx, func(ctx context.Context) error {
+		// Locking the parent's profile serializes creates, so the limit holds
+		if _, err := u.accounts.GetProfileForUpdate(ctx, parentID); err != nil {
+			return err
+		}
+		n, err := u.repo.CountSubAccounts(ctx, parentID)
+		if err != nil {
+			return err
+		}
+		if n >= int64(u.opts.MaxPerUser) {
+			return fmt.Errorf("%w: at most %d per user", domain.ErrSubAccountLimit, u.opts.MaxPerUser)
+		}
+
+		t := now()
+		sub = &domain.SubAccount{
+			ID:          uuid.New(),
+			ParentID:    parentID,
+			Label:       label,
+			Permissions: permissions,
+			Enabled:     true,
+			CreatedAt:   t,
+			UpdatedAt:   t,
+		}
+		return u.repo.CreateSubAccount(ctx, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.From(ctx).Info().
+		Str("action", "subaccount_create").
+		Str("status", "success").
+		Str("user_id", parentID.String()).
+		Str("sub_account_id", sub.ID.String()).
+		Msg("Sub-account created")
+	return sub, nil
+}
+
+func (u *subAccountUC) ListSubAccounts(ctx context.Context, parentID uuid.UUID) ([]domain.SubAccount, error) {
+	return u.repo.ListSubAccounts(ctx, parentID)
+}
+
+func (u *subAccountUC) GetSubAccount(ctx context.Context, parentID, id uuid.UUID) (*domain.SubAccount, error) {
+	return u.owned(ctx, parentID, id)
+}
+
+func (u *subAccountUC) UpdateSubAccount(ctx context.Context, parentID, id uuid.UUID, update domain.SubAccountUpdate) (*domain.SubAccount, error) {
+	var sub *domain.SubAccount
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = u.owned(ctx, parentID, id); err != nil {
+			return err
+		}
+
+		if update.Label != nil {
+			if sub.Label, err = normalizeLabel(*update.Label); err != nil {
+				return err
+			}
+		}
+		if update.Permissions != nil {
+			if sub.Permissions, err = normalizePermissions(*update.Permissions); err != nil {
+				return err
+			}
+		}
+		sub.UpdatedAt = now()
+		return u.repo.UpdateSubAccount(ctx, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (u *subAccountUC) SetSubAccountEnabled(ctx context.Context, parentID, id uuid.UUID, enabled bool) (*domain.SubAccount, error) {
+	var sub *domain.SubAccount
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = u.owned(ctx, parentID, id); err != nil {
+			return err
+		}
+		if sub.Enabled == enabled {
+			return nil
+		}
+
+		t := now()
+		sub.Enabled = enabled
+		sub.UpdatedAt = t
+		sub.DisabledAt = nil
+		if !enabled {
+			sub.DisabledAt = &t
+		}
+		return u.repo.UpdateSubAccount(ctx, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.From(ctx).Info().
+		Str("action", "subaccount_set_enabled").
+		Str("status", "success").
+		Str("user_id", parentID.String()).
+		Str("sub_account_id", id.String()).
+		Bool("enabled", enabled).
+		Msg("Sub-account updated")
+	return sub, nil
+}
+
+func (u *subAccountUC) ResolveSubAccount(ctx context.Context, id uuid.UUID) (*domain.SubAccount, error) {
+	return u.repo.GetSubAccount(ctx, id)
+}
+
+func (u *subAccountUC) CreateAPIKey(ctx context.Context, parentID, subAccountID uuid.UUID, label string, permissions []string) (*domain.APIKey, string, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return nil, "", err
+	}
+	if permissions == nil {
+		permissions = []string{domain.PermissionRead}
+	}
+	if permissions, err = normalizePermissions(permissions); err != nil {
+		return nil, "", err
+	}
+	secret, err := newAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var key *domain.APIKey
+	err = u.tx.Do(ctx, func(ctx context.Context) error {
+		// Locking the sub-account serializes creates, so the limit holds
+		sub, err := u.owned(ctx, parentID, subAccountID)
+		if err != nil {
+			return err
+		}
+		for _, p := range permissions {
+			if !slices.Contains(sub.Permissions, p) {
+				return fmt.Errorf("%w: permission %q is not granted to the sub-account", domain.ErrSubAccountInvalid, p)
+			}
+		}
+		n, err := u.repo.CountAPIKeys(ctx, sub.ID)
+		if err != nil {
+			return err
+		}
+		if n >= int64(u.opts.MaxAPIKeys) {
+			return fmt.Errorf("%w: at most %d per sub-account", domain.ErrAPIKeyLimit, u.opts.MaxAPIKeys)
+		}
+
+		key = &domain.APIKey{
+			ID:           uuid.New(),
+			SubAccountID: sub.ID,
+			Label:        label,
+			Prefix:       secret[:apiKeyShown],
+			SecretHash:   hashAPIKey(secret),
+			Permissions:  permissions,
+			CreatedAt:    now(),
+		}
+		return u.repo.CreateAPIKey(ctx, key)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.From(ctx).Info().
+		Str("action", "api_key_create").
+		Str("status", "success").
+		Str("user_id", parentID.String()).
+		Str("sub_account_id", subAccountID.String()).
+		Str("api_key_id", key.ID.String()).
+		Msg("API key created")
+	return key, secret, nil
+}
+
+func (u *subAccountUC) ListAPIKeys(ctx context.Context, parentID, subAccountID uuid.UUID) ([]domain.APIKey, error) {
+	if _, err := u.owned(ctx, parentID, subAccountID); err != nil {
+		return nil, err
+	}
+	return u.repo.ListAPIKeys(ctx, subAccountID)
+}
+
+func (u *subAccountUC) RevokeAPIKey(ctx context.Context, parentID, subAccountID, keyID uuid.UUID) (*domain.APIKey, error) {
+	var key *domain.APIKey
+	err := u.tx.Do(ctx, func(ctx context.Context) error {
+		if _, err := u.owned(ctx, parentID, subAccountID); err != nil {
+			return err
+		}
+		var err error
+		if key, err = u.repo.GetAPIKey(ctx, keyID); err != nil {
+			return err
+		}
+		if key.SubAccountID != subAccountID {
+			return domain.ErrAPIKeyNotFound
+		}
+		if key.RevokedAt != nil {
+			return nil
+		}
+
+		revokedAt := now()
+		key.RevokedAt = &revokedAt
+		return u.repo.UpdateAPIKey(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.From(ctx).Info().
+		Str("action", "api_key_revoke").
+		Str("status", "success").
+		Str("user_id", parentID.String()).
+		Str("sub_account_id", subAccountID.String()).
+		Str("api_key_id", keyID.String()).
+		Msg("API key revoked")
+	return key, nil
+}
+
+func (u *subAccountUC) VerifyAPIKey(ctx context.Context, secret string) (*domain.ResolvedAPIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	key, err := u.repo.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		return nil, err
+	}
+	sub, err := u.repo.GetSubAccount(ctx, key.SubAccountID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ResolvedAPIKey{Key: key, SubAccount: sub}, nil
+}
+
+// owned loads the sub-account, hiding those of other users.
+func (u *subAccountUC) owned(ctx context.Context, parentID, id uuid.UUID) (*domain.SubAccount, error) {
+	sub, err := u.repo.GetSubAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.ParentID != parentID {
+		return nil, domain.ErrSubAccountNotFound
+	}
+	return sub, nil
+}
+
+func normalizeLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if label == "" || utf8.RuneCountInString(label) > domain.MaxSubAccountLabel {
+		return "", fmt.Errorf("%w: label must be 1 to %d characters", domain.ErrSubAccountInvalid, domain.MaxSubAccountLabel)
+	}
+	return label, nil
+}
+
+// normalizePermissions rejects unknown permissions and returns the rest
+// deduplicated, in the order of domain.SubAccountPermissions.
+func normalizePermissions(permissions []string) ([]string, error) {
+	for _, p := range permissions {
+		if !slices.Contains(domain.SubAccountPermissions, p) {
+			return nil, fmt.Errorf("%w: unknown permission %q", domain.ErrSubAccountInvalid, p)
+		}
+	}
+	out := []string{}
+	for _, p := range domain.SubAccountPermissions {
+		if slices.Contains(permissions, p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func newAPIKeySecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey needs no salt or key: the secret has 256 random bits, so its
+// hash cannot be reversed by guessing.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
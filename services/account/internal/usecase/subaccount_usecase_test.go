@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"bitka/pkg/database/databasetest"
+	"bitka/services/account/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeSubAccounts keeps sub-accounts and API keys in memory.
+type fakeSubAccounts struct {
+	subs map[uuid.UUID]domain.SubAccount
+	keys map[uuid.UUID]domain.APIKey
+}
+
+func newFakeSubAccounts() *fakeSubAccounts {
+	return &fakeSubAccounts{subs: map[uuid.UUID]domain.SubAccount{}, keys: map[uuid.UUID]domain.APIKey{}}
+}
+
+func (r *fakeSubAccounts) CreateSubAccount(_ context.Context, s *domain.SubAccount) error {
+	r.subs[s.ID] = *s
+	return nil
+}
+
+func (r *fakeSubAccounts) UpdateSubAccount(_ context.Context, s *domain.SubAccount) error {
+	r.subs[s.ID] = *s
+	return nil
+}
+
+func (r *fakeSubAccounts) GetSubAccount(_ context.Context, id uuid.UUID) (*domain.SubAccount, error) {
+	s, ok := r.subs[id]
+	if !ok {
+		return nil, domain.ErrSubAccountNotFound
+	}
+	return &s, nil
+}
+
+func (r *fakeSubAccounts) ListSubAccounts(_ context.Context, parentID uuid.UUID) ([]domain.SubAccount, error) {
+	var out []domain.SubAccount
+	for _, s := range r.subs {
+		if s.ParentID == parentID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeSubAccounts) CountSubAccounts(ctx context.Context, parentID uuid.UUID) (int64, error) {
+	subs, _ := r.ListSubAccounts(ctx, parentID)
+	return int64(len(subs)), nil
+}
+
+func (r *fakeSubAccounts) CreateAPIKey(_ context.Context, k *domain.APIKey) error {
+	r.keys[k.ID] = *k
+	return nil
+}
+
+func (r *fakeSubAccounts) UpdateAPIKey(_ context.Context, k *domain.APIKey) error {
+	r.keys[k.ID] = *k
+	return nil
+}
+
+func (r *fakeSubAccounts) GetAPIKey(_ context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	k, ok := r.keys[id]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	return &k, nil
+}
+
+func (r *fakeSubAccounts) GetAPIKeyByHash(_ context.Context, hash string) (*domain.APIKey, error) {
+	for _, k := range r.keys {
+		if k.SecretHash == hash && k.RevokedAt == nil {
+			return &k, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (r *fakeSubAccounts) ListAPIKeys(_ context.Context, subAccountID uuid.UUID) ([]domain.APIKey, error) {
+	var out []domain.APIKey
+	for _, k := range r.keys {
+		if k.SubAccountID == subAccountID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeSubAccounts) CountAPIKeys(ctx context.Context, subAccountID uuid.UUID) (int64, error) {
+	var n int64
+	for _, k := range r.keys {
+		if k.SubAccountID == subAccountID && k.RevokedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+var testSubAccountOptions = SubAccountOptions{MaxPerUser: 3, MaxAPIKeys: 2}
+
+func newTestSubAccountUsecase(userID uuid.UUID) (domain.SubAccountUsecase, *fakeSubAccounts, *singleProfile) {
+	repo := newFakeSubAccounts()
+	accounts := &singleProfile{profile: domain.Profile{UserID: userID}}
+	return NewSubAccountUsecase(repo, accounts, databasetest.NewTxManager(), testSubAccountOptions), repo, accounts
+}
+
+func TestCreateSubAccountLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing int
+		wantErr  error
+	}{
+		{name: "first", existing: 0},
+		{name: "below the limit", existing: testSubAccountOptions.MaxPerUser - 1},
+		{name: "at the limit", existing: testSubAccountOptions.MaxPerUser, wantErr: domain.ErrSubAccountLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uuid.New()
+			uc, repo, accounts := newTestSubAccountUsecase(userID)
+			for i := range tt.existing {
+				if _, err := uc.CreateSubAccount(ctx, userID, fmt.Sprintf("sub %d", i), nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Sub-accounts of other users do not count
+			other := domain.SubAccount{ID: uuid.New(), ParentID: uuid.New(), Enabled: true}
+			repo.subs[other.ID] = other
+
+			sub, err := uc.CreateSubAccount(ctx, userID, "new", nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if accounts.locked != tt.existing+1 {
+				t.Fatalf("profile locked %d times, want one per create", accounts.locked)
+			}
+			if err == nil && (sub.ParentID != userID || !sub.Enabled || strings.Join(sub.Permissions, " ") != "read") {
+				t.Fatalf("created %+v", sub)
+			}
+		})
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name        string
+		owner       uuid.UUID // parent making the request
+		permissions []string
+		existing    int // unrevoked keys already issued
+		revoked     int
+		wantErr     error
+	}{
+		{name: "read only by default", owner: userID},
+		{name: "granted permissions", owner: userID, permissions: []string{"trade", "read"}},
+		{name: "permission the sub-account lacks", owner: userID, permissions: []string{"withdraw"}, wantErr: domain.ErrSubAccountInvalid},
+		{name: "unknown permission", owner: userID, permissions: []string{"admin"}, wantErr: domain.ErrSubAccountInvalid},
+		{name: "someone else's sub-account", owner: uuid.New(), wantErr: domain.ErrSubAccountNotFound},
+		{name: "at the limit", owner: userID, existing: testSubAccountOptions.MaxAPIKeys, wantErr: domain.ErrAPIKeyLimit},
+		{name: "revoked keys do not count", owner: userID, existing: testSubAccountOptions.MaxAPIKeys - 1, revoked: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, _ := newTestSubAccountUsecase(userID)
+			sub, err := uc.CreateSubAccount(ctx, userID, "bot", []string{"read", "trade"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.existing + tt.revoked {
+				k, _, err := uc.CreateAPIKey(ctx, userID, sub.ID, fmt.Sprintf("key %d", i), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i < tt.revoked {
+					if _, err := uc.RevokeAPIKey(ctx, userID, sub.ID, k.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			key, secret, err := uc.CreateAPIKey(ctx, tt.owner, sub.ID, "trading bot", tt.permissions)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !strings.HasPrefix(secret, key.Prefix) || key.SecretHash == "" || strings.Contains(key.SecretHash, secret) {
+				t.Fatalf("prefix %q, hash %q for secret %q", key.Prefix, key.SecretHash, secret)
+			}
+			want := "read"
+			if tt.permissions != nil {
+				want = "read trade"
+			}
+			if got := strings.Join(key.Permissions, " "); got != want {
+				t.Fatalf("permissions = %q, want %q", got, want)
+			}
+
+			resolved, err := uc.VerifyAPIKey(ctx, secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved.Key.ID != key.ID || resolved.SubAccount.ID != sub.ID {
+				t.Fatalf("verified %+v", resolved)
+			}
+			if _, err := uc.VerifyAPIKey(ctx, secret+"x"); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+				t.Fatalf("wrong secret: err = %v", err)
+			}
+
+			if _, err := uc.RevokeAPIKey(ctx, uuid.New(), sub.ID, key.ID); !errors.Is(err, domain.ErrSubAccountNotFound) {
+				t.Fatalf("revoke by another user: err = %v", err)
+			}
+			if _, err := uc.RevokeAPIKey(ctx, userID, sub.ID, key.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := uc.VerifyAPIKey(ctx, secret); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+				t.Fatalf("revoked key: err = %v", err)
+			}
+		})
+	}
+}
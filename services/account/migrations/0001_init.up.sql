@@ -0,0 +1,11 @@
+-- IF NOT EXISTS: databases created by the old GORM AutoMigrate are adopted as-is.
+
+CREATE TABLE IF NOT EXISTS profiles (
+    user_id    UUID PRIMARY KEY,
+    email      TEXT,
+    username   TEXT,
+    full_name  TEXT,
+    avatar_url TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
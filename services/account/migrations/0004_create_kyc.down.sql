@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_applications;
+
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS kyc_level,
+    DROP COLUMN IF EXISTS kyc_status;
@@ -0,0 +1,50 @@
+-- KYC workflow: effective status on the profile, one row per submission,
+-- and the uploaded documents.
+
+ALTER TABLE profiles
+    ADD COLUMN kyc_status TEXT NOT NULL DEFAULT 'none',
+    ADD COLUMN kyc_level  INT  NOT NULL DEFAULT 0;
+
+CREATE TABLE kyc_applications (
+    id            UUID PRIMARY KEY,
+    user_id       UUID        NOT NULL,
+    level         INT         NOT NULL,
+    status        TEXT        NOT NULL,
+    first_name    TEXT        NOT NULL,
+    last_name     TEXT        NOT NULL,
+    date_of_birth DATE        NOT NULL,
+    country       TEXT        NOT NULL,
+    provider      TEXT        NOT NULL,
+    provider_ref  TEXT        NOT NULL DEFAULT '',
+    reason        TEXT        NOT NULL DEFAULT '',
+    reviewer_id   UUID,
+    reviewed_at   TIMESTAMPTZ,
+    expires_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_kyc_applications_user ON kyc_applications (user_id, created_at DESC);
+-- Reviewer queue
+CREATE INDEX idx_kyc_applications_status ON kyc_applications (status, created_at);
+-- Expiry sweep
+CREATE INDEX idx_kyc_applications_expires_at
+    ON kyc_applications (expires_at)
+    WHERE status = 'approved';
+
+CREATE TABLE kyc_documents (
+    id             UUID PRIMARY KEY,
+    user_id        UUID        NOT NULL,
+    application_id UUID REFERENCES kyc_applications (id),
+    type           TEXT        NOT NULL,
+    file_name      TEXT        NOT NULL,
+    content_type   TEXT        NOT NULL,
+    size           BIGINT      NOT NULL,
+    sha256         TEXT        NOT NULL,
+    content        BYTEA       NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_kyc_documents_application ON kyc_documents (application_id);
+-- Uploads not yet attached to a submission
+CREATE INDEX idx_kyc_documents_unattached ON kyc_documents (user_id) WHERE application_id IS NULL;
@@ -0,0 +1,9 @@
+-- Documents stored only as objects cannot be restored inline
+DELETE FROM kyc_documents WHERE content IS NULL;
+ALTER TABLE kyc_documents
+    ALTER COLUMN content SET NOT NULL,
+    DROP COLUMN IF EXISTS storage_key;
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS avatar_thumb_key;
+ALTER TABLE profiles ALTER COLUMN avatar_key DROP DEFAULT;
+ALTER TABLE profiles RENAME COLUMN avatar_key TO avatar_url;
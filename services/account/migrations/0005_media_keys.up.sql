@@ -0,0 +1,13 @@
+-- Uploads live in the media store (bitka/pkg/storage); rows keep object keys.
+
+-- avatar_url held arbitrary client URLs; they are not keys, so drop them
+ALTER TABLE profiles RENAME COLUMN avatar_url TO avatar_key;
+UPDATE profiles SET avatar_key = '';
+ALTER TABLE profiles
+    ALTER COLUMN avatar_key SET DEFAULT '',
+    ADD COLUMN avatar_thumb_key TEXT NOT NULL DEFAULT '';
+
+-- New documents are stored as objects; older rows keep their inline bytes
+ALTER TABLE kyc_documents
+    ADD COLUMN storage_key TEXT NOT NULL DEFAULT '',
+    ALTER COLUMN content DROP NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS profile_changes;
+DROP FUNCTION IF EXISTS profile_changes_append_only();
@@ -0,0 +1,26 @@
+-- Field-level profile history. Append-only: rows are never updated or
+-- deleted by the application, and the trigger below enforces it.
+
+CREATE TABLE profile_changes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    UUID        NOT NULL,
+    field      TEXT        NOT NULL,
+    old_value  JSONB,
+    new_value  JSONB,
+    actor_id   UUID,
+    source     TEXT        NOT NULL,
+    trace_id   TEXT        NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_profile_changes_user ON profile_changes (user_id, id DESC);
+
+CREATE FUNCTION profile_changes_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'profile_changes is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER profile_changes_append_only
+    BEFORE UPDATE OR DELETE ON profile_changes
+    FOR EACH ROW EXECUTE FUNCTION profile_changes_append_only();
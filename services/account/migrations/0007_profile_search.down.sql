@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_profiles_full_name_trgm;
+DROP INDEX IF EXISTS idx_profiles_username_trgm;
+DROP INDEX IF EXISTS idx_profiles_email_trgm;
+DROP INDEX IF EXISTS idx_profiles_username_lower;
+
+-- pg_trgm is left installed; other schemas may use it
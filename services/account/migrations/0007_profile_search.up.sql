@@ -0,0 +1,10 @@
+-- Admin search matches email, username and full name by prefix, substring
+-- and trigram similarity; public lookups match usernames case-insensitively.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_profiles_username_lower ON profiles (lower(username));
+
+CREATE INDEX idx_profiles_email_trgm ON profiles USING gin (lower(email) gin_trgm_ops);
+CREATE INDEX idx_profiles_username_trgm ON profiles USING gin (lower(username) gin_trgm_ops);
+CREATE INDEX idx_profiles_full_name_trgm ON profiles USING gin (lower(full_name) gin_trgm_ops);
@@ -0,0 +1,12 @@
+-- Rows exist only for users who changed something; everyone else reads
+-- the defaults from the service.
+
+CREATE TABLE preferences (
+    user_id          UUID PRIMARY KEY,
+    locale           TEXT        NOT NULL,
+    timezone         TEXT        NOT NULL,
+    display_currency TEXT        NOT NULL,
+    theme            TEXT        NOT NULL,
+    notifications    JSONB       NOT NULL,
+    updated_at       TIMESTAMPTZ NOT NULL
+);
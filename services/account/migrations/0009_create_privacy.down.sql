@@ -0,0 +1,8 @@
+CREATE OR REPLACE FUNCTION profile_changes_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'profile_changes is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS deletion_requests;
+DROP TABLE IF EXISTS data_exports;
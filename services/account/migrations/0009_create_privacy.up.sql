@@ -0,0 +1,51 @@
+-- Personal data exports and account deletion requests.
+
+CREATE TABLE data_exports (
+    id           UUID PRIMARY KEY,
+    user_id      UUID        NOT NULL,
+    status       TEXT        NOT NULL,
+    storage_key  TEXT        NOT NULL DEFAULT '',
+    size         BIGINT      NOT NULL DEFAULT 0,
+    error        TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_data_exports_user ON data_exports (user_id, created_at DESC);
+CREATE INDEX idx_data_exports_pending ON data_exports (created_at) WHERE status = 'pending';
+CREATE INDEX idx_data_exports_expiry ON data_exports (expires_at) WHERE status = 'ready';
+
+CREATE TABLE deletion_requests (
+    id            UUID PRIMARY KEY,
+    user_id       UUID        NOT NULL,
+    status        TEXT        NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL,
+    cancelled_at  TIMESTAMPTZ,
+    erased_at     TIMESTAMPTZ,
+    confirmed_by  JSONB       NOT NULL DEFAULT '[]',
+    completed_at  TIMESTAMPTZ
+);
+
+-- At most one open request per user
+CREATE UNIQUE INDEX idx_deletion_requests_open
+    ON deletion_requests (user_id)
+    WHERE status IN ('scheduled', 'erasing');
+CREATE INDEX idx_deletion_requests_due
+    ON deletion_requests (scheduled_for)
+    WHERE status = 'scheduled';
+
+-- Erasure may blank the values of change log rows, and nothing else.
+CREATE OR REPLACE FUNCTION profile_changes_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+       AND NEW.old_value IS NULL AND NEW.new_value IS NULL
+       AND (NEW.id, NEW.user_id, NEW.field, NEW.actor_id, NEW.source, NEW.trace_id, NEW.changed_at)
+           IS NOT DISTINCT FROM
+           (OLD.id, OLD.user_id, OLD.field, OLD.actor_id, OLD.source, OLD.trace_id, OLD.changed_at) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'profile_changes is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS phone_verifications;
+
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS address,
+    DROP COLUMN IF EXISTS nationality,
+    DROP COLUMN IF EXISTS date_of_birth,
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS phone_verified_at;
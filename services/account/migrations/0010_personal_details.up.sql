@@ -0,0 +1,22 @@
+-- Personal details. Every column but phone_verified_at holds ciphertext
+-- written by the application (pkg/encryption); '' means unset.
+
+ALTER TABLE profiles
+    ADD COLUMN address           TEXT NOT NULL DEFAULT '',
+    ADD COLUMN nationality       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN date_of_birth     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN phone             TEXT NOT NULL DEFAULT '',
+    ADD COLUMN phone_verified_at TIMESTAMPTZ;
+
+CREATE TABLE phone_verifications (
+    id          UUID PRIMARY KEY,
+    user_id     UUID        NOT NULL,
+    phone       TEXT        NOT NULL,
+    code_hash   TEXT        NOT NULL,
+    attempts    INTEGER     NOT NULL DEFAULT 0,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    verified_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_phone_verifications_user ON phone_verifications (user_id, created_at DESC);
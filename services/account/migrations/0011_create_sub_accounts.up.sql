@@ -0,0 +1,14 @@
+-- Sub-accounts owned by a user. Labels are unique per parent, ignoring case.
+
+CREATE TABLE sub_accounts (
+    id          UUID PRIMARY KEY,
+    parent_id   UUID        NOT NULL,
+    label       TEXT        NOT NULL,
+    permissions JSONB       NOT NULL DEFAULT '[]',
+    enabled     BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    disabled_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_sub_accounts_parent_label ON sub_accounts (parent_id, lower(label));
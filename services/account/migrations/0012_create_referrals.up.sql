@@ -0,0 +1,19 @@
+-- Referral codes (one per user, created on first use) and the referrals
+-- attributed at registration. A user is referred at most once.
+
+CREATE TABLE referral_codes (
+    user_id    UUID PRIMARY KEY,
+    code       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_referral_codes_code ON referral_codes (code);
+
+CREATE TABLE referrals (
+    referee_id  UUID PRIMARY KEY,
+    referrer_id UUID        NOT NULL,
+    code        TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_referrals_referrer ON referrals (referrer_id, created_at DESC);
@@ -0,0 +1,5 @@
+-- Relay scan: a dead row (failed_at set) holds back the rest of its
+-- aggregate until an operator requeues it
+CREATE INDEX idx_outbox_messages_dead_aggregate
+    ON outbox_messages (aggregate_type, aggregate_id, id)
+    WHERE sent_at IS NULL AND failed_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_data_exports_pending;
+CREATE INDEX idx_data_exports_pending ON data_exports (created_at) WHERE status = 'pending';
+
+UPDATE data_exports SET status = 'pending' WHERE status = 'processing';
+ALTER TABLE data_exports DROP COLUMN IF EXISTS claimed_until;
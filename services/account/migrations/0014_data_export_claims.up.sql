@@ -0,0 +1,7 @@
+-- Exports are built outside the claiming transaction: a worker marks the
+-- row processing until claimed_until, after which another worker may
+-- take it over (the first one crashed).
+ALTER TABLE data_exports ADD COLUMN claimed_until TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_data_exports_pending;
+CREATE INDEX idx_data_exports_pending ON data_exports (created_at) WHERE status IN ('pending', 'processing');
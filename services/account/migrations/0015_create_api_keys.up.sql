@@ -0,0 +1,16 @@
+-- API keys of sub-accounts. Only the SHA-256 hash of a secret is stored;
+-- verification looks keys up by it.
+
+CREATE TABLE api_keys (
+    id             UUID PRIMARY KEY,
+    sub_account_id UUID        NOT NULL REFERENCES sub_accounts (id),
+    label          TEXT        NOT NULL,
+    prefix         TEXT        NOT NULL,
+    secret_hash    TEXT        NOT NULL,
+    permissions    JSONB       NOT NULL DEFAULT '[]',
+    created_at     TIMESTAMPTZ NOT NULL,
+    revoked_at     TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_api_keys_secret_hash ON api_keys (secret_hash);
+CREATE INDEX idx_api_keys_sub_account ON api_keys (sub_account_id, created_at);
@@ -0,0 +1,8 @@
+// Package migrations embeds the account service SQL migrations.
+// Add new files with: go run ./services/account/cmd/migrate create <name>
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
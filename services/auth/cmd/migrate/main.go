@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"bitka/pkg/database"
+	"bitka/pkg/database/migrate"
+	"bitka/pkg/logger"
+	"bitka/services/auth/internal/config"
+	"bitka/services/auth/migrations"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Usage (from the repo root):
+//
+//	go run ./services/auth/cmd/migrate up
+//	go run ./services/auth/cmd/migrate down 1
+//	go run ./services/auth/cmd/migrate status
+//	go run ./services/auth/cmd/migrate create add_something
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+
+	logger.Init(logger.Config{
+		Environment: cfg.AppEnv,
+		LogLevel:    cfg.LogLevel,
+		ServiceName: cfg.ServiceName,
+		InstanceID:  cfg.InstanceID,
+	})
+
+	err = migrate.Run(context.Background(), os.Args[1:], "services/auth/migrations", func() (*migrate.Migrator, error) {
+		db, err := database.Connect(cfg.DB)
+		if err != nil {
+			return nil, err
+		}
+		return migrate.New(db, migrations.FS, "auth")
+	})
+	if err != nil {
+		log.Fatal().Err(err).Str("action", "db_migrate").Msg("Migration failed")
+	}
+}
@@ -1,28 +1,51 @@
 package main
 
 import (
-	"bitka/pkg/config"
+	"context"
+	"os"
+
 	"bitka/pkg/logger"
+	"bitka/pkg/shutdown"
+	"bitka/pkg/tracing"
 	"bitka/services/auth/internal/app"
+	"bitka/services/auth/internal/config"
 
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
 	// 1. Global Init
-	cfg := config.Load("AUTH_DB_NAME")
+	// Fails fast (before anything connects) on missing/invalid settings
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
 
 	logger.Init(logger.Config{
 		Environment: cfg.AppEnv,
 		LogLevel:    cfg.LogLevel,
 		ServiceName: cfg.ServiceName,
 		InstanceID:  cfg.InstanceID,
+
+		SuccessSampleEvery: cfg.LogSuccessSampleEvery,
 	})
 
 	// Now you can use the global logger immediately
 	log.Info().Msg("Application starting...")
 
-	logger.LogConfigSafe(*cfg)
+	logger.LogConfigSafe(cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  cfg.ServiceName,
+		InstanceID:   cfg.InstanceID,
+		Environment:  cfg.AppEnv,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.Endpoint,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
 
 	// 2. Create Server (Wiring)
 	server, err := app.NewServer(cfg)
@@ -37,7 +60,22 @@ func main() {
 		Str("port", cfg.HTTPPort).
 		Msg("Starting Auth Service")
 
-	if err := server.Listen(addr); err != nil {
-		log.Fatal().Err(err).Msg("Server failed to start")
+	// 4. Graceful Shutdown (HTTP -> producer -> DB -> tracing)
+	stopper := shutdown.New(cfg.ShutdownTimeout)
+	server.RegisterShutdown(stopper)
+	stopper.Add("tracing", shutdownTracing)
+
+	go func() {
+		if err := server.Listen(addr); err != nil {
+			log.Error().Err(err).Msg("Server failed to start")
+			stopper.Run()
+			os.Exit(1)
+		}
+	}()
+
+	if err := stopper.Wait(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Shutdown finished with errors")
+		os.Exit(1)
 	}
+	log.Info().Msg("Auth Service stopped")
 }
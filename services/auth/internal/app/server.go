@@ -1,37 +1,65 @@
 package app
 
 import (
-	"bitka/pkg/config"
 	"bitka/pkg/database"
+	"bitka/pkg/database/migrate"
+	"bitka/pkg/health"
+	"bitka/pkg/kafka"
+	"bitka/pkg/kafka/transport"
 	"bitka/pkg/logger"
+	"bitka/pkg/metrics"
+	"bitka/pkg/middleware"
+	"bitka/pkg/shutdown"
 	"bitka/pkg/token"
+	"bitka/pkg/tracing"
+	"bitka/services/auth/internal/config"
+	"bitka/services/auth/internal/delivery/event"
 	"bitka/services/auth/internal/delivery/http"
-	"bitka/services/auth/internal/domain"
-	"bitka/services/auth/internal/repository/kafka"
+	"bitka/services/auth/internal/repository/accountclient"
+	"bitka/services/auth/internal/repository/outbox"
 	"bitka/services/auth/internal/repository/postgres"
 	"bitka/services/auth/internal/usecase"
-	"log"
+	"bitka/services/auth/migrations"
+	"context"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"gorm.io/gorm"
 )
 
-func NewServer(cfg *config.Config) (*fiber.App, error) {
+type Server struct {
+	FiberServer *fiber.App
+	KafkaServer *event.KafkaServer
+	Health      *health.Checker
+
+	db *gorm.DB
+}
+
+func NewServer(cfg *config.Config) (*Server, error) {
 	// 1. Infrastructure
-	db, err := database.Connect(database.Config{
-		Host:     cfg.DBHost,
-		Port:     cfg.DBPort,
-		User:     cfg.DBUser,
-		Password: cfg.DBPass,
-		DBName:   cfg.DBName,
-		SSLMode:  "disable",
-	})
+	db, err := database.Connect(cfg.DB)
 	if err != nil {
 		return nil, err
 	}
 
-	// Auto-Migrate auth tables
-	db.AutoMigrate(&domain.User{}, &domain.RefreshToken{})
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := metrics.RegisterDBStats(db, cfg.DB.DBName); err != nil {
+		return nil, err
+	}
+
+	// Versioned SQL migrations (see services/auth/migrations)
+	if cfg.DB.MigrateOnStart {
+		if err := runMigrations(db); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := transport.Validate(cfg.Kafka, cfg.IsProduction()); err != nil {
+		return nil, err
+	}
 
 	// 2. Shared Components (Now using DB persistence)
 	// We pass 'db' here so the manager can store keys in the database
@@ -42,25 +70,78 @@ func NewServer(cfg *config.Config) (*fiber.App, error) {
 
 	// 3. Layer Dependency Injection
 	repo := postgres.NewDatabaseRepo(db)
-	broker := config.GetEnv("KAFKA_BROKER", "kafka:9092")
-	
-	kafkaProducer, Err := kafka.NewProducer([]string{broker})
-	if Err != nil {
-		log.Fatal("Kafka producer failed:", Err)
+	publisher := outbox.NewPublisher(db) // relayed to Kafka by services/outbox
+	txManager := database.NewTxManager(db)
+	uc := usecase.NewAuthUsecase(repo, txManager, tokenMgr, publisher)
+	privacyUC := usecase.NewPrivacyUsecase(repo, txManager, publisher)
+	directoryUC := usecase.NewDirectoryUsecase(repo)
+	accounts := accountclient.New(cfg.AccountInternalURL, cfg.InternalAPIToken, 10*time.Second)
+	subAccountUC := usecase.NewSubAccountUsecase(accounts, tokenMgr)
+	handler := http.NewAuthHandler(uc, privacyUC, directoryUC, subAccountUC)
+
+	// 4. Health Checks
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("postgres", health.Postgres(db))
+	if !cfg.Kafka.InMemory() {
+		checker.Add("kafka", health.Kafka(cfg.Kafka.Brokers))
 	}
-	uc := usecase.NewAuthUsecase(repo, tokenMgr, kafkaProducer)
-	handler := http.NewAuthHandler(uc)
 
-	// 4. Framework Setup
+	// 5. Framework Setup
 	app := fiber.New(fiber.Config{
 		AppName: "Bitka Auth Service",
 	})
 
 	app.Use(recover.New())
+	app.Use(tracing.FiberMiddleware()) // must run before the logger to share the trace ID
 	app.Use(logger.FiberMiddleware())
+	app.Use(metrics.FiberMiddleware())
+
+	// Operational endpoints (not under /api, not authenticated)
+	app.Get("/metrics", metrics.Handler())
+	checker.Register(app)
+
+	// 6. Route Mapping
+	http.MapRoutes(app, handler, middleware.Protected(tokenMgr), middleware.InternalOnly(cfg.InternalAPIToken))
+
+	// 7. Kafka consumer (started by Listen)
+	inbox := kafka.NewInbox(db, txManager, cfg.Kafka.GroupID, cfg.Kafka.InboxRetention)
+	kafkaConsumer := event.NewKafkaServer(privacyUC, cfg.Kafka, inbox)
 
-	// 5. Route Mapping
-	http.MapRoutes(app, handler)
+	return &Server{
+		FiberServer: app,
+		KafkaServer: kafkaConsumer,
+		Health:      checker,
+		db:          db,
+	}, nil
+}
+
+// Listen starts the Kafka consumer in the background and serves HTTP on addr.
+func (s *Server) Listen(addr string) error {
+	go s.KafkaServer.Start()
+	return s.FiberServer.Listen(addr)
+}
 
-	return app, nil
+// RegisterShutdown adds the server's resources to m in the order they must stop:
+// stop accepting + drain HTTP, stop the consumer, then close the DB.
+func (s *Server) RegisterShutdown(m *shutdown.Manager) {
+	m.Add("http", func(ctx context.Context) error {
+		s.Health.SetShuttingDown()
+		return s.FiberServer.ShutdownWithContext(ctx)
+	})
+	m.Add("kafka-consumer", s.KafkaServer.Shutdown)
+	m.Add("postgres", func(context.Context) error {
+		sqlDB, err := s.db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+}
+
+func runMigrations(db *gorm.DB) error {
+	m, err := migrate.New(db, migrations.FS, "auth")
+	if err != nil {
+		return err
+	}
+	return m.Up(context.Background())
 }
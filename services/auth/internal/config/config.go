@@ -0,0 +1,36 @@
+package config
+
+import (
+	"bitka/pkg/config"
+	"bitka/pkg/database"
+)
+
+// Config is everything the auth service reads at boot.
+// Keys are looked up as AUTH_<KEY> first, then <KEY> (see Load).
+type Config struct {
+	config.Base `yaml:",inline"`
+
+	// Events leave through the outbox table; services/outbox talks to Kafka.
+	DB database.Config `yaml:"db"`
+	// Consumer of the events auth reacts to (erasure requests)
+	Kafka config.Kafka `yaml:"kafka"`
+
+	// Shared secret of the /internal endpoints, also sent on calls to them
+	InternalAPIToken string `env:"INTERNAL_API_TOKEN" yaml:"internal_api_token"`
+
+	// Account service internal API (sub-account ownership)
+	AccountInternalURL string `env:"ACCOUNT_INTERNAL_URL" default:"http://localhost:3001" yaml:"account_internal_url"`
+}
+
+// Load reads the auth service configuration and validates it.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Base:  config.Base{ServiceName: "auth-service", HTTPPort: "3000"},
+		DB:    database.Config{DBName: "bitka_auth"},
+		Kafka: config.Kafka{GroupID: "auth-service"},
+	}
+	if err := config.Load(cfg, config.WithPrefix("AUTH")); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
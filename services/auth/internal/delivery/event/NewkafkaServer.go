@@ -0,0 +1,62 @@
+package event
+
+import (
+	"bitka/pkg/config"
+	"bitka/pkg/events"
+	"bitka/pkg/kafka"
+	"bitka/pkg/kafka/transport"
+	"bitka/services/auth/internal/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// inboxPruneInterval is how often expired inbox rows are deleted.
+const inboxPruneInterval = time.Hour
+
+type KafkaServer struct {
+	consumer kafka.Consumer
+	inbox    *kafka.Inbox
+
+	mu     sync.Mutex // guards cancel: Start runs in its own goroutine
+	cancel context.CancelFunc
+}
+
+// NewKafkaServer wires the handlers into a consumer group. Every handler
+// runs inside the inbox transaction, so each event is applied once.
+// Call Start to begin consuming.
+func NewKafkaServer(privacy domain.PrivacyUsecase, cfg config.Kafka, inbox *kafka.Inbox) *KafkaServer {
+	handler := NewHandler(privacy)
+
+	mux := kafka.NewMux()
+	mux.Handle(events.TopicUserErasureRequested, handler.HandleUserErasureRequested)
+
+	consumer := transport.NewConsumer(cfg, kafka.ConsumerConfig{
+		GroupID:      cfg.GroupID,
+		Topics:       mux.Topics(),
+		MaxRetries:   cfg.ConsumerRetries,
+		RetryBackoff: cfg.ConsumerRetryBackoff,
+	}, inbox.Wrap(mux.Handler()), kafka.DefaultMiddleware()...)
+
+	return &KafkaServer{consumer: consumer, inbox: inbox}
+}
+
+func (s *KafkaServer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+	go s.inbox.RunPruner(ctx, inboxPruneInterval)
+
+	s.consumer.Start()
+}
+
+func (s *KafkaServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return s.consumer.Close(ctx)
+}
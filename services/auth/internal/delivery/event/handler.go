@@ -0,0 +1,53 @@
+package event
+
+import (
+	"bitka/pkg/events"
+	"bitka/pkg/kafka"
+	"bitka/pkg/logger"
+	"bitka/services/auth/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	privacy domain.PrivacyUsecase
+}
+
+func NewHandler(privacy domain.PrivacyUsecase) *Handler {
+	return &Handler{privacy: privacy}
+}
+
+func (h *Handler) HandleUserErasureRequested(ctx context.Context, msg *kafka.Message) error {
+	l := logger.From(ctx)
+
+	_, evt, err := events.Decode[events.UserErasureRequested](msg.Value)
+	if err != nil {
+		l.Error().Err(err).
+			Str("action", "user_erasure").
+			Str("status", "error").
+			Msg("Failed to decode Kafka message")
+		// Redelivery cannot fix a malformed payload
+		return kafka.Permanent(err)
+	}
+	userID, err := uuid.Parse(evt.UserID)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+
+	if err := h.privacy.EraseUser(ctx, userID, evt.RequestID); err != nil {
+		l.Error().Err(err).
+			Str("action", "user_erasure").
+			Str("status", "error").
+			Str("user_id", evt.UserID).
+			Msg("Failed to erase user")
+		return err
+	}
+
+	l.Info().
+		Str("action", "user_erasure").
+		Str("status", "success").
+		Str("user_id", evt.UserID).
+		Msg("User erased")
+	return nil
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "bitka/services/auth/internal/domain"
+
+// UserList is a page of the internal user directory. Pass Next as ?after=
+// to fetch the following page; it is empty on the last one.
+type UserList struct {
+	Items []domain.UserSummary `json:"items"`
+	Next  string               `json:"next,omitempty"`
+}
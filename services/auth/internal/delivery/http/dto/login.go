@@ -6,9 +6,10 @@ type LoginRequest struct {
 }
 
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Email        string `json:"email"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ReferralCode string `json:"referral_code,omitempty"` // code of the inviting user
 }
 
 type LoginResponse struct {
@@ -16,3 +17,19 @@ type LoginResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	UserID       string `json:"user_id"`
 }
+
+// SubAccountTokenRequest selects the sub-account to act as; an empty ID
+// switches back to the user.
+type SubAccountTokenRequest struct {
+	SubAccountID string `json:"sub_account_id"`
+}
+
+type SubAccountTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	SubAccountID string `json:"sub_account_id,omitempty"`
+}
+
+// APIKeyTokenRequest carries a sub-account API key secret.
+type APIKeyTokenRequest struct {
+	APIKey string `json:"api_key"`
+}
@@ -1,18 +1,37 @@
 package http
 
 import (
+	"errors"
+	"strings"
+
 	"bitka/pkg/response"
+	"bitka/pkg/token"
 	"bitka/services/auth/internal/delivery/http/dto"
 	"bitka/services/auth/internal/domain"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// Page size bounds of the internal user directory
+const (
+	defaultUserPage = 500
+	maxUserPage     = 1000
 )
 
+// maxReferralCode bounds the referral code passed on to account, which
+// owns the codes and validates them.
+const maxReferralCode = 32
+
 type AuthHandler struct {
-	uc domain.AuthUsecase
+	uc        domain.AuthUsecase
+	privacy   domain.PrivacyUsecase
+	directory domain.DirectoryUsecase
+	subs      domain.SubAccountUsecase
 }
 
-func NewAuthHandler(uc domain.AuthUsecase) *AuthHandler {
-	return &AuthHandler{uc: uc}
+func NewAuthHandler(uc domain.AuthUsecase, privacy domain.PrivacyUsecase, directory domain.DirectoryUsecase, subs domain.SubAccountUsecase) *AuthHandler {
+	return &AuthHandler{uc: uc, privacy: privacy, directory: directory, subs: subs}
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -21,7 +40,10 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	tokens, err := h.uc.Login(req.Identifier, req.Password)
+	tokens, err := h.uc.Login(c.UserContext(), req.Identifier, req.Password, domain.LoginMeta{
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
 	if err != nil {
 		return response.Error(c, fiber.StatusUnauthorized, err.Error())
 	}
@@ -46,13 +68,83 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	if req.Password == "" {
 		return response.Error(c, fiber.StatusBadRequest, "Password is required")
 	}
-	if err := h.uc.Register(req.Email, req.Username, req.Password); err != nil {
+	req.ReferralCode = strings.TrimSpace(req.ReferralCode)
+	if len(req.ReferralCode) > maxReferralCode {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid referral code")
+	}
+	if err := h.uc.Register(c.UserContext(), req.Email, req.Username, req.Password, req.ReferralCode); err != nil {
 
 		return response.Error(c, fiber.StatusConflict, err.Error())
 	}
 	return response.Success(c, "User registered successfully")
 }
 
+// SubAccountToken exchanges the caller's access token for one acting as
+// a sub-account they own. A token already acting as a sub-account cannot
+// be exchanged, or it could widen its own scope.
+func (h *AuthHandler) SubAccountToken(c *fiber.Ctx) error {
+	claims, _ := c.Locals("claims").(jwt.Token)
+	if claims == nil || !token.HasAudience(claims, token.AudienceAccess) {
+		return response.Error(c, fiber.StatusUnauthorized, "Access token required")
+	}
+	if _, ok := token.SubAccountOf(claims); ok {
+		return response.Error(c, fiber.StatusForbidden, "Not allowed while acting as a sub-account")
+	}
+	userIDStr, _ := c.Locals("user_id").(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req dto.SubAccountTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	subAccountID := uuid.Nil
+	if req.SubAccountID != "" {
+		if subAccountID, err = uuid.Parse(req.SubAccountID); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, "Invalid sub-account ID")
+		}
+	}
+
+	access, err := h.subs.ActAs(c.UserContext(), userID, subAccountID)
+	switch {
+	case errors.Is(err, domain.ErrSubAccountNotFound):
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrSubAccountDisabled):
+		return response.Error(c, fiber.StatusForbidden, err.Error())
+	case err != nil:
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, dto.SubAccountTokenResponse{
+		AccessToken:  access,
+		SubAccountID: req.SubAccountID,
+	})
+}
+
+// APIKeyToken exchanges a sub-account API key for an access token acting
+// as the sub-account.
+func (h *AuthHandler) APIKeyToken(c *fiber.Ctx) error {
+	var req dto.APIKeyTokenRequest
+	if err := c.BodyParser(&req); err != nil || req.APIKey == "" {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	access, subAccountID, err := h.subs.ExchangeAPIKey(c.UserContext(), req.APIKey)
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyInvalid):
+		return response.Error(c, fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrSubAccountDisabled):
+		return response.Error(c, fiber.StatusForbidden, err.Error())
+	case err != nil:
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, dto.SubAccountTokenResponse{
+		AccessToken:  access,
+		SubAccountID: subAccountID.String(),
+	})
+}
+
 func (h *AuthHandler) GetJWKS(c *fiber.Ctx) error {
 	keys, err := h.uc.GetJWKS()
 	if err != nil {
@@ -61,3 +153,48 @@ func (h *AuthHandler) GetJWKS(c *fiber.Ctx) error {
 	c.Set("Content-Type", "application/json")
 	return c.Send(keys)
 }
+
+// ExportUser returns the auth part of a personal data export (internal).
+func (h *AuthHandler) ExportUser(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	export, err := h.privacy.ExportUser(c.UserContext(), userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return response.Error(c, fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+	return response.Success(c, export)
+}
+
+// ListUsers pages through the user directory by ID: ?after=<cursor>&limit=N.
+func (h *AuthHandler) ListUsers(c *fiber.Ctx) error {
+	after := uuid.Nil
+	if raw := c.Query("after"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, "Invalid cursor")
+		}
+		after = id
+	}
+
+	limit := c.QueryInt("limit", defaultUserPage)
+	if limit <= 0 || limit > maxUserPage {
+		limit = defaultUserPage
+	}
+
+	users, next, err := h.directory.ListUsers(c.UserContext(), after, limit)
+	if err != nil {
+		return response.InternalError(c, err)
+	}
+
+	list := dto.UserList{Items: users}
+	if next != uuid.Nil {
+		list.Next = next.String()
+	}
+	return response.Success(c, list)
+}
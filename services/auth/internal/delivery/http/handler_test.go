@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bitka/pkg/token"
+	"bitka/services/auth/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// fakeSubAccounts answers ActAs with err and counts the calls.
+type fakeSubAccounts struct {
+	domain.SubAccountUsecase
+	err   error
+	calls int
+}
+
+func (f *fakeSubAccounts) ActAs(context.Context, uuid.UUID, uuid.UUID) (string, error) {
+	f.calls++
+	return "token", f.err
+}
+
+func TestSubAccountToken(t *testing.T) {
+	userID := uuid.New()
+	claims := func(audience string, subAccount string) jwt.Token {
+		b := jwt.NewBuilder().Subject(userID.String()).Audience([]string{audience})
+		if subAccount != "" {
+			b.Claim(token.ClaimSubAccount, subAccount).Claim(token.ClaimScope, "read")
+		}
+		tok, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	tests := []struct {
+		name      string
+		claims    jwt.Token
+		body      string
+		actAsErr  error
+		want      int
+		wantCalls int
+	}{
+		{name: "user token", claims: claims(token.AudienceAccess, ""), body: `{"sub_account_id":"` + uuid.NewString() + `"}`, want: fiber.StatusOK, wantCalls: 1},
+		{name: "sub-account token back to the user", claims: claims(token.AudienceAccess, uuid.NewString()), body: `{"sub_account_id":""}`, want: fiber.StatusForbidden},
+		{name: "sub-account token to a sibling", claims: claims(token.AudienceAccess, uuid.NewString()), body: `{"sub_account_id":"` + uuid.NewString() + `"}`, want: fiber.StatusForbidden},
+		{name: "refresh token", claims: claims(token.AudienceRefresh, ""), body: `{}`, want: fiber.StatusUnauthorized},
+		{name: "disabled sub-account", claims: claims(token.AudienceAccess, ""), body: `{"sub_account_id":"` + uuid.NewString() + `"}`, actAsErr: domain.ErrSubAccountDisabled, want: fiber.StatusForbidden, wantCalls: 1},
+		{name: "someone else's sub-account", claims: claims(token.AudienceAccess, ""), body: `{"sub_account_id":"` + uuid.NewString() + `"}`, actAsErr: domain.ErrSubAccountNotFound, want: fiber.StatusNotFound, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs := &fakeSubAccounts{err: tt.actAsErr}
+			h := NewAuthHandler(nil, nil, nil, subs)
+
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				c.Locals("user_id", userID.String())
+				c.Locals("claims", tt.claims)
+				return c.Next()
+			}, h.SubAccountToken)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if subs.calls != tt.wantCalls {
+				t.Errorf("ActAs called %d times, want %d", subs.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -2,11 +2,18 @@ package http
 
 import "github.com/gofiber/fiber/v2"
 
-func MapRoutes(app *fiber.App, h *AuthHandler) {
+func MapRoutes(app *fiber.App, h *AuthHandler, authMiddleware, internalMiddleware fiber.Handler) {
 	api := app.Group("/api/v1")
 
 	api.Post("/login", h.Login)
 	api.Post("/register", h.Register)
+	api.Post("/token/sub-account", authMiddleware, h.SubAccountToken)
+	api.Post("/token/api-key", h.APIKeyToken)
+
+	// Service-to-service endpoints, not exposed through the gateway
+	internal := app.Group("/internal/v1", internalMiddleware)
+	internal.Get("/users", h.ListUsers)
+	internal.Get("/users/:id/export", h.ExportUser)
 
 	// JWKS endpoint often lives at root or .well-known
 	app.Get("/.well-known/jwks.json", h.GetJWKS)
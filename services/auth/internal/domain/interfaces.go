@@ -1,26 +1,74 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"bitka/pkg/events"
+
+	"github.com/google/uuid"
+)
 
 // TODO: Decide if these interface should be here or not.
 
 // AuthRepository defines data access methods
 type AuthRepository interface {
-	CreateUser(user *User) error
-	FindByEmailOrUser(identifier string) (*User, error)
-	SaveRefreshToken(token *RefreshToken) error
+	CreateUser(ctx context.Context, user *User) error
+	FindByEmailOrUser(ctx context.Context, identifier string) (*User, error)
+	SaveRefreshToken(ctx context.Context, token *RefreshToken) error
+
+	FindByID(ctx context.Context, id uuid.UUID) (*User, error)
+	// ListUsers returns up to limit users with an ID greater than after,
+	// ordered by ID.
+	ListUsers(ctx context.Context, after uuid.UUID, limit int) ([]User, error)
+	RecordLogin(ctx context.Context, record *LoginRecord) error
+	ListLogins(ctx context.Context, userID uuid.UUID) ([]LoginRecord, error)
+	// EraseUser pseudonymises the user row, revokes its refresh tokens and
+	// deletes its login history. It locks the row inside a transaction.
+	EraseUser(ctx context.Context, id uuid.UUID, erasedAt time.Time) error
 }
 
 // AuthUsecase defines business logic methods
 type AuthUsecase interface {
-	Login(email, password string) (*TokenPair, error)
-	Register(email, username, password string) error
+	Login(ctx context.Context, email, password string, meta LoginMeta) (*TokenPair, error)
+	// Register creates the user; referralCode is passed on in UserRegistered.
+	Register(ctx context.Context, email, username, password, referralCode string) error
 	GetJWKS() ([]byte, error)
 }
 
+// PrivacyUsecase serves personal data exports and erasure.
+type PrivacyUsecase interface {
+	ExportUser(ctx context.Context, userID uuid.UUID) (*UserExport, error)
+	// EraseUser pseudonymises the user and confirms with UserErased.
+	// Erasing an erased user only confirms again.
+	EraseUser(ctx context.Context, userID uuid.UUID, requestID string) error
+}
+
+// DirectoryUsecase lists users for other services (e.g. reconciliation).
+type DirectoryUsecase interface {
+	// ListUsers pages through all users in ID order. next is the cursor of
+	// the following page, or uuid.Nil after the last one.
+	ListUsers(ctx context.Context, after uuid.UUID, limit int) (users []UserSummary, next uuid.UUID, err error)
+}
+
+// Transactor runs fn as one atomic unit of work (implemented by database.TxManager).
+// Repository calls made with the ctx passed to fn join the transaction.
+type Transactor interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// EventPublisher records integration events for other services.
+// Events are written to the outbox inside the caller's transaction and only
+// reach Kafka after it commits, so they can never describe a rolled-back change.
+type EventPublisher interface {
+	PublishUserRegistered(ctx context.Context, event events.UserRegistered) error
+	PublishUserErased(ctx context.Context, event events.UserErased) error
+}
+
 // TokenGenerator defines the behavior we need from pkg/token
 // This allows us to mock the complex JWX library in tests
 type TokenGenerator interface {
 	Generate(userID string, duration time.Duration, audience string, jti string) (string, error)
+	GenerateWithClaims(userID string, duration time.Duration, audience string, jti string, claims map[string]any) (string, error)
 	GetJWKS() ([]byte, error)
 }
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// SubAccount is the account service's view of a sub-account, as far as
+// token issuance needs it.
+type SubAccount struct {
+	ID          uuid.UUID `json:"id"`
+	ParentID    uuid.UUID `json:"parent_id"`
+	Permissions []string  `json:"permissions"`
+	Enabled     bool      `json:"enabled"`
+}
+
+// APIKey is the account service's view of a sub-account API key.
+type APIKey struct {
+	ID          uuid.UUID `json:"id"`
+	Permissions []string  `json:"permissions"`
+}
+
+// ResolvedAPIKey is a verified API key and the sub-account it acts as.
+type ResolvedAPIKey struct {
+	Key        APIKey     `json:"key"`
+	SubAccount SubAccount `json:"sub_account"`
+}
+
+var (
+	ErrSubAccountNotFound = errors.New("sub-account not found")
+	ErrSubAccountDisabled = errors.New("sub-account is disabled")
+	ErrAPIKeyInvalid      = errors.New("API key is invalid or revoked")
+)
+
+// SubAccountDirectory resolves sub-accounts through the account service.
+type SubAccountDirectory interface {
+	// ResolveSubAccount returns ErrSubAccountNotFound for an unknown ID.
+	ResolveSubAccount(ctx context.Context, id uuid.UUID) (*SubAccount, error)
+	// VerifyAPIKey returns ErrAPIKeyInvalid for an unknown or revoked secret.
+	VerifyAPIKey(ctx context.Context, secret string) (*ResolvedAPIKey, error)
+}
+
+// SubAccountUsecase issues access tokens acting as a sub-account.
+type SubAccountUsecase interface {
+	// ActAs returns an access token for userID acting as subAccountID, or a
+	// plain access token when subAccountID is uuid.Nil. The sub-account
+	// must belong to the user and be enabled.
+	ActAs(ctx context.Context, userID, subAccountID uuid.UUID) (string, error)
+	// ExchangeAPIKey returns an access token acting as the key's
+	// sub-account, scoped to the key's permissions the sub-account still
+	// holds, and the sub-account's ID.
+	ExchangeAPIKey(ctx context.Context, secret string) (string, uuid.UUID, error)
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,12 +13,56 @@ type User struct {
 	Email        string    `gorm:"uniqueIndex;not null"`
 	Username     string    `gorm:"uniqueIndex;not null"`
 	PasswordHash string    `gorm:"not null"`
+	ReferralCode string    // code entered at registration, if any
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	// Set once the user is pseudonymised; the row can no longer log in
+	ErasedAt *time.Time
 }
 
-type UserRegisterEvent struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Email    string    `json:"email"`
-	Username string    `json:"username"`
+// LoginRecord is one login attempt against a known account.
+type LoginRecord struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid" json:"user_id"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at"`
 }
+
+func (LoginRecord) TableName() string { return "login_history" }
+
+// LoginMeta describes where a login attempt came from.
+type LoginMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+// UserExport is the auth part of a personal data export. The password
+// hash is never included.
+type UserExport struct {
+	UserID    uuid.UUID     `json:"user_id"`
+	Email     string        `json:"email"`
+	Username  string        `json:"username"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Logins    []LoginRecord `json:"logins"`
+}
+
+// UserSummary is how other services see a user in the internal directory.
+type UserSummary struct {
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	Username     string    `json:"username"`
+	ReferralCode string    `json:"referral_code,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Erased       bool      `json:"erased"`
+}
+
+// Pseudonym is the username an erased user is left with. It is derived
+// from the ID, so it is stable and unique.
+func Pseudonym(id uuid.UUID) string {
+	return "deleted_" + strings.ReplaceAll(id.String(), "-", "")
+}
+
+var ErrUserNotFound = errors.New("user not found")
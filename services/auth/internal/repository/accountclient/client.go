@@ -0,0 +1,108 @@
+// Package accountclient calls the account service's internal API.
+package accountclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"bitka/pkg/middleware"
+	"bitka/services/auth/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxResponseSize bounds what is read from account.
+const maxResponseSize = 1 << 20
+
+// Client implements domain.SubAccountDirectory.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New returns a client for the account service at baseURL (e.g.
+// "http://account-service:3001"), authenticating with the internal API token.
+func New(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) ResolveSubAccount(ctx context.Context, id uuid.UUID) (*domain.SubAccount, error) {
+	var sub domain.SubAccount
+	status, err := c.get(ctx, fmt.Sprintf("/internal/v1/subaccounts/%s", id), &sub)
+	if status == http.StatusNotFound {
+		return nil, domain.ErrSubAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (c *Client) VerifyAPIKey(ctx context.Context, secret string) (*domain.ResolvedAPIKey, error) {
+	var resolved domain.ResolvedAPIKey
+	status, err := c.do(ctx, http.MethodPost, "/internal/v1/api-keys/verify", map[string]string{"secret": secret}, &resolved)
+	if status == http.StatusNotFound {
+		return nil, domain.ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// get fetches path and decodes the data of the response envelope into out.
+// It returns the response status alongside any error.
+func (c *Client) get(ctx context.Context, path string, out any) (int, error) {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// do sends body, if any, as JSON and decodes the data of the response
+// envelope into out. It returns the response status alongside any error.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(middleware.InternalTokenHeader, c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("account: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var env struct {
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&env); err != nil {
+		return resp.StatusCode, fmt.Errorf("account: %s %s: %s: %w", method, path, resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("account: %s %s: %s: %s", method, path, resp.Status, env.Error)
+	}
+	return resp.StatusCode, json.Unmarshal(env.Data, out)
+}
@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+
+	"bitka/pkg/events"
+	"bitka/pkg/outbox"
+	"gorm.io/gorm"
+)
+
+type Publisher struct {
+	writer *outbox.Writer
+}
+
+// NewPublisher creates an EventPublisher backed by the auth outbox table.
+func NewPublisher(db *gorm.DB) *Publisher {
+	return &Publisher{writer: outbox.NewWriter(db)}
+}
+
+// PublishUserRegistered enqueues the event in the current transaction.
+func (p *Publisher) PublishUserRegistered(ctx context.Context, event events.UserRegistered) error {
+	return p.enqueue(ctx, events.TopicUserRegistered, "user", event.UserID.String(), event)
+}
+
+// PublishUserErased enqueues the event in the current transaction.
+func (p *Publisher) PublishUserErased(ctx context.Context, event events.UserErased) error {
+	return p.enqueue(ctx, events.TopicUserErased, "user", event.UserID, event)
+}
+
+func (p *Publisher) enqueue(ctx context.Context, topic, aggregateType, aggregateID string, e events.Event) error {
+	env, err := events.New(e)
+	if err != nil {
+		return err
+	}
+	_, err = p.writer.Enqueue(ctx, outbox.Event{
+		EventID:       env.EventID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Topic:         topic,
+		EventType:     env.Type,
+		Payload:       env,
+		Headers:       env.Headers(),
+	})
+	return err
+}
@@ -1,10 +1,16 @@
 package postgres
 
 import (
+	"bitka/pkg/database"
 	"bitka/services/auth/internal/domain"
+	"context"
 	"errors"
-	"gorm.io/gorm"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TODO: Complete all methods of AuthRepository
@@ -17,8 +23,8 @@ func NewDatabaseRepo(db *gorm.DB) domain.AuthRepository {
 	return &databaseRepo{db: db}
 }
 
-func (r *databaseRepo) CreateUser(user *domain.User) error {
-	err := r.db.Create(user).Error
+func (r *databaseRepo) CreateUser(ctx context.Context, user *domain.User) error {
+	err := database.Conn(ctx, r.db).Create(user).Error
 	if err != nil {
 		if strings.Contains(err.Error(), "users_email_key") {
 			return errors.New("email already in use")
@@ -31,14 +37,82 @@ func (r *databaseRepo) CreateUser(user *domain.User) error {
 	return nil
 }
 
-func (r *databaseRepo) FindByEmailOrUser(identifier string) (*domain.User, error) {
+func (r *databaseRepo) FindByEmailOrUser(ctx context.Context, identifier string) (*domain.User, error) {
+	var user domain.User
+	if err := database.Conn(ctx, r.db).Where("email = ? OR username = ?", identifier, identifier).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *databaseRepo) SaveRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	return database.Conn(ctx, r.db).Create(token).Error
+}
+
+func (r *databaseRepo) FindByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	q := database.Conn(ctx, r.db)
+	if database.InTx(ctx) {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
 	var user domain.User
-	if err := r.db.Where("email = ? OR username = ?", identifier, identifier).First(&user).Error; err != nil {
+	if err := q.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *databaseRepo) SaveRefreshToken(token *domain.RefreshToken) error {
-	return r.db.Create(token).Error
+func (r *databaseRepo) RecordLogin(ctx context.Context, record *domain.LoginRecord) error {
+	return database.Conn(ctx, r.db).Create(record).Error
+}
+
+func (r *databaseRepo) ListUsers(ctx context.Context, after uuid.UUID, limit int) ([]domain.User, error) {
+	var users []domain.User
+	err := database.Conn(ctx, r.db).
+		Where("id > ?", after).
+		Order("id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *databaseRepo) ListLogins(ctx context.Context, userID uuid.UUID) ([]domain.LoginRecord, error) {
+	var logins []domain.LoginRecord
+	err := database.Conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&logins).Error
+	return logins, err
+}
+
+func (r *databaseRepo) EraseUser(ctx context.Context, id uuid.UUID, erasedAt time.Time) error {
+	db := database.Conn(ctx, r.db)
+
+	// An empty hash matches no password, so the account can no longer log in
+	res := db.Model(&domain.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"email":         domain.Pseudonym(id) + "@erased.invalid",
+			"username":      domain.Pseudonym(id),
+			"password_hash": "",
+			"referral_code": "",
+			"erased_at":     erasedAt,
+			"updated_at":    erasedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	if err := db.Model(&domain.RefreshToken{}).
+		Where("user_id = ?", id).
+		Update("is_revoked", true).Error; err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", id).Delete(&domain.LoginRecord{}).Error
 }
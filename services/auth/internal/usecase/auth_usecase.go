@@ -1,31 +1,42 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"log"
 	"time"
 
+	"bitka/pkg/events"
+	"bitka/pkg/logger"
+	"bitka/pkg/token"
 	"bitka/services/auth/internal/domain"
-	"bitka/services/auth/internal/repository/kafka"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Lifetimes of the issued tokens
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type authUsecase struct {
-	repo          domain.AuthRepository
-	tokenGen      domain.TokenGenerator
-	kafkaProducer *kafka.Producer
+	repo     domain.AuthRepository
+	tx       domain.Transactor
+	tokenGen domain.TokenGenerator
+	events   domain.EventPublisher
 }
 
 func NewAuthUsecase(
 	repo domain.AuthRepository,
+	tx domain.Transactor,
 	tg domain.TokenGenerator,
-	kp *kafka.Producer,
+	ep domain.EventPublisher,
 ) domain.AuthUsecase {
 	return &authUsecase{
-		repo:          repo,
-		tokenGen:      tg,
-		kafkaProducer: kp,
+		repo:     repo,
+		tx:       tx,
+		tokenGen: tg,
+		events:   ep,
 	}
 }
 
@@ -39,44 +50,65 @@ func checkPassword(password, hash string) bool {
 	return err == nil
 }
 
-func (u *authUsecase) Login(identifier, password string) (*domain.TokenPair, error) {
-	user, err := u.repo.FindByEmailOrUser(identifier)
+func (u *authUsecase) Login(ctx context.Context, identifier, password string, meta domain.LoginMeta) (*domain.TokenPair, error) {
+	user, err := u.repo.FindByEmailOrUser(ctx, identifier)
 	if err != nil {
 		return nil, errors.New("invalid credentials")
 	}
 
 	if !checkPassword(password, user.PasswordHash) {
+		u.recordLogin(ctx, user.ID, meta, false)
 		return nil, errors.New("invalid credentials")
 	}
 
 	// 1. Access Token (15 mins)
-	access, err := u.tokenGen.Generate(user.ID.String(), 15*time.Minute, "api:access", "")
+	access, err := u.tokenGen.Generate(user.ID.String(), accessTokenTTL, token.AudienceAccess, "")
 	if err != nil {
 		return nil, err
 	}
 
 	// 2. Refresh Token (7 days)
 	refreshJTI := uuid.New().String()
-	refresh, err := u.tokenGen.Generate(user.ID.String(), 7*24*time.Hour, "api:refresh", refreshJTI)
+	refresh, err := u.tokenGen.Generate(user.ID.String(), refreshTokenTTL, token.AudienceRefresh, refreshJTI)
 	if err != nil {
 		return nil, err
 	}
 
 	// 3. Persist Refresh Token
-	err = u.repo.SaveRefreshToken(&domain.RefreshToken{
+	err = u.repo.SaveRefreshToken(ctx, &domain.RefreshToken{
 		ID:        uuid.New(),
 		UserID:    user.ID,
 		TokenJTI:  refreshJTI,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	u.recordLogin(ctx, user.ID, meta, true)
 	return &domain.TokenPair{AccessToken: access, RefreshToken: refresh}, nil
 }
 
-func (u *authUsecase) Register(email, username, password string) error {
+// recordLogin appends to the login history. It is best-effort: a failed
+// write must not turn into a failed login.
+func (u *authUsecase) recordLogin(ctx context.Context, userID uuid.UUID, meta domain.LoginMeta, success bool) {
+	err := u.repo.RecordLogin(ctx, &domain.LoginRecord{
+		UserID:    userID,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+		Success:   success,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		logger.From(ctx).Warn().Err(err).
+			Str("action", "login_record").
+			Str("status", "error").
+			Str("user_id", userID.String()).
+			Msg("Failed to record login")
+	}
+}
+
+func (u *authUsecase) Register(ctx context.Context, email, username, password, referralCode string) error {
 	hash_password, err := hashPassword(password)
 	if err != nil {
 		return err
@@ -86,23 +118,22 @@ func (u *authUsecase) Register(email, username, password string) error {
 		Email:        email,
 		Username:     username,
 		PasswordHash: hash_password,
+		ReferralCode: referralCode,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
-	if err := u.repo.CreateUser(user); err != nil {
-		return err
-	}
-	event := domain.UserRegisterEvent{
-		UserID:   user.ID,
-		Email:    user.Email,
-		Username: user.Username,
-	}
-	err = u.kafkaProducer.PublishUserRegister(event)
-	if err != nil {
-		log.Println("Failed to publish Kafka event:", err)
-		return err
-	}
-	return u.kafkaProducer.PublishUserRegister(event)
+	// User row and outbox event commit together; the relay publishes it once
+	return u.tx.Do(ctx, func(ctx context.Context) error {
+		if err := u.repo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return u.events.PublishUserRegistered(ctx, events.UserRegistered{
+			UserID:       user.ID,
+			Email:        user.Email,
+			Username:     user.Username,
+			ReferralCode: user.ReferralCode,
+		})
+	})
 }
 
 func (u *authUsecase) GetJWKS() ([]byte, error) {
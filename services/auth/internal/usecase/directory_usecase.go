@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+
+	"bitka/services/auth/internal/domain"
+	"github.com/google/uuid"
+)
+
+type directoryUsecase struct {
+	repo domain.AuthRepository
+}
+
+func NewDirectoryUsecase(repo domain.AuthRepository) domain.DirectoryUsecase {
+	return &directoryUsecase{repo: repo}
+}
+
+func (u *directoryUsecase) ListUsers(ctx context.Context, after uuid.UUID, limit int) ([]domain.UserSummary, uuid.UUID, error) {
+	users, err := u.repo.ListUsers(ctx, after, limit)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	summaries := make([]domain.UserSummary, len(users))
+	for i, user := range users {
+		summaries[i] = domain.UserSummary{
+			ID:           user.ID,
+			Email:        user.Email,
+			Username:     user.Username,
+			ReferralCode: user.ReferralCode,
+			CreatedAt:    user.CreatedAt,
+			Erased:       user.ErasedAt != nil,
+		}
+	}
+
+	// A short page is the last one
+	next := uuid.Nil
+	if len(users) == limit {
+		next = users[len(users)-1].ID
+	}
+	return summaries, next, nil
+}
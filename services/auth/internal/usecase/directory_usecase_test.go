@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"bitka/services/auth/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeUsers lists users by ID like the Postgres repository.
+type fakeUsers struct {
+	domain.AuthRepository
+	users []domain.User // sorted by ID
+}
+
+func (r *fakeUsers) ListUsers(_ context.Context, after uuid.UUID, limit int) ([]domain.User, error) {
+	var page []domain.User
+	for _, u := range r.users {
+		if bytes.Compare(u.ID[:], after[:]) > 0 && len(page) < limit {
+			page = append(page, u)
+		}
+	}
+	return page, nil
+}
+
+func TestDirectoryListUsersPages(t *testing.T) {
+	erasedAt := time.Now()
+	var users []domain.User
+	for i := 1; i <= 5; i++ {
+		users = append(users, domain.User{
+			ID:       uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i)),
+			Email:    fmt.Sprintf("u%d@x.io", i),
+			Username: fmt.Sprintf("u%d", i),
+		})
+	}
+	users[1].ReferralCode = "FRIEND22"
+	users[2].ErasedAt = &erasedAt
+
+	for _, limit := range []int{1, 2, 5, 6} {
+		t.Run(fmt.Sprintf("limit=%d", limit), func(t *testing.T) {
+			uc := NewDirectoryUsecase(&fakeUsers{users: users})
+
+			var seen []domain.UserSummary
+			after := uuid.Nil
+			for pages := 0; ; pages++ {
+				if pages > len(users)+1 {
+					t.Fatal("cursor never ended")
+				}
+				page, next, err := uc.ListUsers(context.Background(), after, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(page) > limit {
+					t.Fatalf("page of %d, limit %d", len(page), limit)
+				}
+				seen = append(seen, page...)
+				if next == uuid.Nil {
+					if len(page) == limit {
+						t.Error("a full page must not end the walk")
+					}
+					break
+				}
+				if next != page[len(page)-1].ID {
+					t.Errorf("cursor %s, want the last ID of the page", next)
+				}
+				after = next
+			}
+
+			if len(seen) != len(users) {
+				t.Fatalf("listed %d users, want %d", len(seen), len(users))
+			}
+			for i, s := range seen {
+				if s.ID != users[i].ID || s.Email != users[i].Email || s.Erased != (users[i].ErasedAt != nil) ||
+					s.ReferralCode != users[i].ReferralCode {
+					t.Errorf("user %d = %+v", i, s)
+				}
+			}
+		})
+	}
+}
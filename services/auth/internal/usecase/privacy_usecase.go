@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bitka/pkg/events"
+	"bitka/services/auth/internal/domain"
+	"github.com/google/uuid"
+)
+
+// erasedBy names this service in UserErased.
+const erasedBy = "auth"
+
+type privacyUsecase struct {
+	repo   domain.AuthRepository
+	tx     domain.Transactor
+	events domain.EventPublisher
+}
+
+func NewPrivacyUsecase(repo domain.AuthRepository, tx domain.Transactor, ep domain.EventPublisher) domain.PrivacyUsecase {
+	return &privacyUsecase{repo: repo, tx: tx, events: ep}
+}
+
+func (u *privacyUsecase) ExportUser(ctx context.Context, userID uuid.UUID) (*domain.UserExport, error) {
+	user, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	logins, err := u.repo.ListLogins(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UserExport{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Logins:    logins,
+	}, nil
+}
+
+func (u *privacyUsecase) EraseUser(ctx context.Context, userID uuid.UUID, requestID string) error {
+	return u.tx.Do(ctx, func(ctx context.Context) error {
+		// A user auth never had has nothing to erase, but still confirms
+		user, err := u.repo.FindByID(ctx, userID)
+		if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+			return err
+		}
+		if user != nil && user.ErasedAt == nil {
+			if err := u.repo.EraseUser(ctx, userID, time.Now()); err != nil {
+				return err
+			}
+		}
+		return u.events.PublishUserErased(ctx, events.UserErased{
+			UserID:    userID.String(),
+			RequestID: requestID,
+			Service:   erasedBy,
+		})
+	})
+}
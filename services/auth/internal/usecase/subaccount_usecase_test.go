@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bitka/pkg/token"
+	"bitka/services/auth/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeSubAccountDirectory resolves sub-accounts and API keys from maps.
+type fakeSubAccountDirectory struct {
+	subs map[uuid.UUID]domain.SubAccount
+	keys map[string]domain.ResolvedAPIKey // by secret
+}
+
+func (d *fakeSubAccountDirectory) ResolveSubAccount(_ context.Context, id uuid.UUID) (*domain.SubAccount, error) {
+	s, ok := d.subs[id]
+	if !ok {
+		return nil, domain.ErrSubAccountNotFound
+	}
+	return &s, nil
+}
+
+func (d *fakeSubAccountDirectory) VerifyAPIKey(_ context.Context, secret string) (*domain.ResolvedAPIKey, error) {
+	k, ok := d.keys[secret]
+	if !ok {
+		return nil, domain.ErrAPIKeyInvalid
+	}
+	return &k, nil
+}
+
+// recordingTokens remembers the subject and claims of the last token.
+type recordingTokens struct {
+	domain.TokenGenerator
+	subject string
+	claims  map[string]any
+}
+
+func (g *recordingTokens) Generate(userID string, _ time.Duration, _ string, _ string) (string, error) {
+	g.subject, g.claims = userID, nil
+	return "token", nil
+}
+
+func (g *recordingTokens) GenerateWithClaims(userID string, _ time.Duration, _ string, _ string, claims map[string]any) (string, error) {
+	g.subject, g.claims = userID, claims
+	return "token", nil
+}
+
+func TestActAs(t *testing.T) {
+	userID := uuid.New()
+	enabled := domain.SubAccount{ID: uuid.New(), ParentID: userID, Permissions: []string{"read", "trade"}, Enabled: true}
+	disabled := domain.SubAccount{ID: uuid.New(), ParentID: userID, Permissions: []string{"read"}}
+	foreign := domain.SubAccount{ID: uuid.New(), ParentID: uuid.New(), Permissions: []string{"read"}, Enabled: true}
+	directory := &fakeSubAccountDirectory{subs: map[uuid.UUID]domain.SubAccount{
+		enabled.ID: enabled, disabled.ID: disabled, foreign.ID: foreign,
+	}}
+
+	tests := []struct {
+		name       string
+		subAccount uuid.UUID
+		wantErr    error
+		wantClaims map[string]any
+	}{
+		{name: "own sub-account", subAccount: enabled.ID, wantClaims: map[string]any{
+			token.ClaimSubAccount: enabled.ID.String(),
+			token.ClaimScope:      "read trade",
+		}},
+		{name: "back to the user", subAccount: uuid.Nil},
+		{name: "disabled", subAccount: disabled.ID, wantErr: domain.ErrSubAccountDisabled},
+		{name: "another user's", subAccount: foreign.ID, wantErr: domain.ErrSubAccountNotFound},
+		{name: "unknown", subAccount: uuid.New(), wantErr: domain.ErrSubAccountNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &recordingTokens{}
+			uc := NewSubAccountUsecase(directory, tokens)
+
+			_, err := uc.ActAs(context.Background(), userID, tt.subAccount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if tokens.subject != "" {
+					t.Fatal("a token was issued")
+				}
+				return
+			}
+			if tokens.subject != userID.String() {
+				t.Fatalf("subject = %q, want the user", tokens.subject)
+			}
+			assertClaims(t, tokens.claims, tt.wantClaims)
+		})
+	}
+}
+
+func TestExchangeAPIKey(t *testing.T) {
+	userID := uuid.New()
+	sub := domain.SubAccount{ID: uuid.New(), ParentID: userID, Permissions: []string{"read"}, Enabled: true}
+	disabled := sub
+	disabled.Enabled = false
+	key := func(s domain.SubAccount, permissions ...string) domain.ResolvedAPIKey {
+		return domain.ResolvedAPIKey{Key: domain.APIKey{ID: uuid.New(), Permissions: permissions}, SubAccount: s}
+	}
+	directory := &fakeSubAccountDirectory{keys: map[string]domain.ResolvedAPIKey{
+		"bk_read":     key(sub, "read"),
+		"bk_narrowed": key(sub, "read", "trade"), // trade was taken from the sub-account later
+		"bk_disabled": key(disabled, "read"),
+	}}
+
+	tests := []struct {
+		name      string
+		secret    string
+		wantErr   error
+		wantScope string
+	}{
+		{name: "key permissions", secret: "bk_read", wantScope: "read"},
+		{name: "limited to the sub-account's permissions", secret: "bk_narrowed", wantScope: "read"},
+		{name: "disabled sub-account", secret: "bk_disabled", wantErr: domain.ErrSubAccountDisabled},
+		{name: "unknown key", secret: "bk_unknown", wantErr: domain.ErrAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &recordingTokens{}
+			uc := NewSubAccountUsecase(directory, tokens)
+
+			_, subAccountID, err := uc.ExchangeAPIKey(context.Background(), tt.secret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if tokens.subject != "" {
+					t.Fatal("a token was issued")
+				}
+				return
+			}
+			if subAccountID != sub.ID || tokens.subject != userID.String() {
+				t.Fatalf("sub-account %s, subject %q", subAccountID, tokens.subject)
+			}
+			assertClaims(t, tokens.claims, map[string]any{
+				token.ClaimSubAccount: sub.ID.String(),
+				token.ClaimScope:      tt.wantScope,
+			})
+		})
+	}
+}
+
+func assertClaims(t *testing.T, got, want map[string]any) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("claims = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Fatalf("claim %s = %v, want %v", name, got[name], value)
+		}
+	}
+}
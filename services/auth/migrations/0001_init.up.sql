@@ -0,0 +1,20 @@
+-- IF NOT EXISTS: databases created by the old GORM AutoMigrate are adopted as-is.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            UUID PRIMARY KEY,
+    email         TEXT NOT NULL CONSTRAINT users_email_key UNIQUE,
+    username      TEXT NOT NULL CONSTRAINT users_username_key UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         UUID PRIMARY KEY,
+    user_id    UUID,
+    token_jti  TEXT,
+    expires_at TIMESTAMPTZ,
+    is_revoked BOOLEAN DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_jti ON refresh_tokens (token_jti);
@@ -0,0 +1,9 @@
+-- Signing keys for pkg/token.Manager (previously migrated by token.NewManager itself).
+CREATE TABLE IF NOT EXISTS rsa_keys (
+    kid         TEXT PRIMARY KEY,
+    algorithm   VARCHAR(10),
+    public_pem  TEXT,
+    private_pem TEXT,
+    created_at  TIMESTAMPTZ,
+    expires_at  TIMESTAMPTZ
+);
@@ -0,0 +1,30 @@
+-- Transactional outbox (bitka/pkg/outbox): rows are written in the same
+-- transaction as the domain change and relayed to Kafka by services/outbox.
+
+CREATE TABLE outbox_messages (
+    id              BIGSERIAL PRIMARY KEY,
+    event_id        UUID        NOT NULL CONSTRAINT outbox_messages_event_id_key UNIQUE,
+    aggregate_type  TEXT        NOT NULL,
+    aggregate_id    TEXT        NOT NULL,
+    topic           TEXT        NOT NULL,
+    event_type      TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    headers         JSONB       NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts        INT         NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at         TIMESTAMPTZ,
+    failed_at       TIMESTAMPTZ
+);
+
+-- Relay scan: unsent rows in global order, and per aggregate in order
+CREATE INDEX idx_outbox_messages_pending
+    ON outbox_messages (id)
+    WHERE sent_at IS NULL AND failed_at IS NULL;
+CREATE INDEX idx_outbox_messages_pending_aggregate
+    ON outbox_messages (aggregate_type, aggregate_id, id)
+    WHERE sent_at IS NULL AND failed_at IS NULL;
+
+-- Pruning of sent rows
+CREATE INDEX idx_outbox_messages_sent_at ON outbox_messages (sent_at) WHERE sent_at IS NOT NULL;
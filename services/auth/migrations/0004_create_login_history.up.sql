@@ -0,0 +1,13 @@
+-- Login attempts against known accounts, for the user's own history and
+-- data exports. Attempts on unknown identifiers are not recorded.
+
+CREATE TABLE login_history (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    UUID        NOT NULL,
+    ip_address TEXT        NOT NULL DEFAULT '',
+    user_agent TEXT        NOT NULL DEFAULT '',
+    success    BOOLEAN     NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_login_history_user ON login_history (user_id, id DESC);
@@ -0,0 +1,13 @@
+-- Processed-event log for bitka/pkg/kafka.Inbox: one row per handled event,
+-- written in the same transaction as the handler's changes.
+
+CREATE TABLE inbox_messages (
+    consumer     TEXT        NOT NULL,
+    event_id     TEXT        NOT NULL,
+    topic        TEXT        NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (consumer, event_id)
+);
+
+-- Pruning by retention window
+CREATE INDEX idx_inbox_messages_processed_at ON inbox_messages (processed_at);
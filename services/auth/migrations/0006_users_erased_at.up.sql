@@ -0,0 +1,3 @@
+-- Set when the user is pseudonymised after an account deletion. The row
+-- stays so the ID keeps resolving for retained financial records.
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMPTZ;
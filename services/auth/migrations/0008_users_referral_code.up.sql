@@ -0,0 +1,4 @@
+-- The referral code entered at registration. Kept with the user so the
+-- account reconcile can attribute a profile it backfills; the
+-- UserRegistered event that normally carries it may have been lost.
+ALTER TABLE users ADD COLUMN referral_code TEXT NOT NULL DEFAULT '';
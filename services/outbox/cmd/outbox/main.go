@@ -1 +1,77 @@
-package outbox
+package main
+
+import (
+	"context"
+	"os"
+
+	"bitka/pkg/logger"
+	"bitka/pkg/shutdown"
+	"bitka/pkg/tracing"
+	"bitka/services/outbox/internal/app"
+	"bitka/services/outbox/internal/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	// 1. Load Configuration
+	// OUTBOX_<KEY> overrides <KEY>, e.g. OUTBOX_DB_NAME over DB_NAME
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+
+	logger.Init(logger.Config{
+		Environment: cfg.AppEnv,
+		LogLevel:    cfg.LogLevel,
+		ServiceName: cfg.ServiceName,
+		InstanceID:  cfg.InstanceID,
+
+		SuccessSampleEvery: cfg.LogSuccessSampleEvery,
+	})
+
+	log.Info().Msg("Application starting...")
+
+	logger.LogConfigSafe(cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  cfg.ServiceName,
+		InstanceID:   cfg.InstanceID,
+		Environment:  cfg.AppEnv,
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.Endpoint,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
+
+	server, err := app.NewServer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize server")
+	}
+
+	log.Info().
+		Str("port", cfg.HTTPPort).
+		Str("source_db", cfg.DB.DBName).
+		Msg("Starting Outbox Relay")
+
+	// Graceful Shutdown (HTTP -> relay -> producer -> DB -> tracing)
+	stopper := shutdown.New(cfg.ShutdownTimeout)
+	server.RegisterShutdown(stopper)
+	stopper.Add("tracing", shutdownTracing)
+
+	go func() {
+		if err := server.Listen(":" + cfg.HTTPPort); err != nil {
+			log.Error().Err(err).Msg("Server failed to start")
+			stopper.Run()
+			os.Exit(1)
+		}
+	}()
+
+	if err := stopper.Wait(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Shutdown finished with errors")
+		os.Exit(1)
+	}
+	log.Info().Msg("Outbox Relay stopped")
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"bitka/pkg/database"
+	"bitka/pkg/events/contract"
+	"bitka/pkg/health"
+	"bitka/pkg/kafka"
+	"bitka/pkg/kafka/transport"
+	"bitka/pkg/logger"
+	"bitka/pkg/metrics"
+	"bitka/pkg/outbox"
+	"bitka/pkg/shutdown"
+	"bitka/pkg/tracing"
+	"bitka/services/outbox/internal/config"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type Server struct {
+	FiberServer *fiber.App
+	Health      *health.Checker
+	Relay       *outbox.Relay
+
+	db       *gorm.DB
+	dsn      string
+	listen   bool
+	producer kafka.Producer
+
+	mu     sync.Mutex // guards cancel: Listen runs in its own goroutine
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewServer(cfg *config.Config) (*Server, error) {
+	// 1. Infrastructure
+	db, err := database.Connect(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := metrics.RegisterDBStats(db, cfg.DB.DBName); err != nil {
+		return nil, err
+	}
+
+	// The relay's consumers are the other services, never itself
+	if err := transport.ValidatePublisher(cfg.Kafka); err != nil {
+		return nil, err
+	}
+
+	var producerCfg kafka.ProducerConfig
+	if cfg.Kafka.ValidateEvents {
+		if cfg.IsProduction() {
+			log.Warn().Msg("KAFKA_VALIDATE_EVENTS is ignored in production")
+		} else {
+			spec, err := contract.Load(cfg.Kafka.ContractSpec)
+			if err != nil {
+				return nil, err
+			}
+			producerCfg.Validate = contract.Validator(spec)
+		}
+	}
+	producer, err := transport.NewProducer(cfg.Kafka, producerCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Relay
+	relay := outbox.NewRelay(db, outbox.KafkaPublisher(producer), outbox.RelayConfig{
+		BatchSize:     cfg.Relay.BatchSize,
+		PollInterval:  cfg.Relay.PollInterval,
+		MaxAttempts:   cfg.Relay.MaxAttempts,
+		Lease:         cfg.Relay.Lease,
+		RetryBackoff:  cfg.Relay.RetryBackoff,
+		MaxBackoff:    cfg.Relay.MaxBackoff,
+		Retention:     cfg.Relay.Retention,
+		PruneInterval: cfg.Relay.PruneInterval,
+	})
+
+	// 3. Health Checks
+	checker := health.NewChecker(2 * time.Second)
+	checker.Add("postgres", health.Postgres(db))
+	checker.Add("kafka", health.Kafka(cfg.Kafka.Brokers))
+
+	// 4. Operational endpoints only; the relay has no public API
+	app := fiber.New(fiber.Config{
+		AppName: "Bitka Outbox Relay",
+	})
+
+	app.Use(recover.New())
+	app.Use(tracing.FiberMiddleware())
+	app.Use(logger.FiberMiddleware())
+	app.Use(metrics.FiberMiddleware())
+
+	app.Get("/metrics", metrics.Handler())
+	checker.Register(app)
+
+	return &Server{
+		FiberServer: app,
+		Health:      checker,
+		Relay:       relay,
+		db:          db,
+		dsn:         cfg.DB.DSN(),
+		listen:      cfg.Relay.Listen,
+		producer:    producer,
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Listen starts the relay in the background and serves HTTP on addr (e.g. ":3002").
+func (s *Server) Listen(addr string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	if s.listen {
+		go outbox.Listen(ctx, s.dsn, s.Relay)
+	}
+	go func() {
+		defer close(s.done)
+		_ = s.Relay.Run(ctx)
+	}()
+
+	return s.FiberServer.Listen(addr)
+}
+
+// RegisterShutdown adds the server's resources to m in the order they must stop:
+// stop HTTP, finish the current relay batch, flush the producer, then close the DB.
+func (s *Server) RegisterShutdown(m *shutdown.Manager) {
+	m.Add("http", func(ctx context.Context) error {
+		s.Health.SetShuttingDown()
+		return s.FiberServer.ShutdownWithContext(ctx)
+	})
+	m.Add("relay", func(ctx context.Context) error {
+		s.mu.Lock()
+		cancel := s.cancel
+		s.mu.Unlock()
+		if cancel == nil {
+			return nil // never started
+		}
+		cancel()
+		select {
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	m.Add("kafka-producer", func(context.Context) error {
+		return s.producer.Close()
+	})
+	m.Add("postgres", func(context.Context) error {
+		sqlDB, err := s.db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"time"
+
+	"bitka/pkg/config"
+	"bitka/pkg/database"
+)
+
+// Config is everything the outbox relay reads at boot.
+// Keys are looked up as OUTBOX_<KEY> first, then <KEY> (see Load).
+// One relay instance serves one source database (OUTBOX_DB_NAME).
+type Config struct {
+	config.Base `yaml:",inline"`
+
+	DB    database.Config `yaml:"db"`
+	Kafka config.Kafka    `yaml:"kafka"`
+	Relay Relay           `yaml:"relay"`
+}
+
+// Relay tunes the polling loop (see outbox.RelayConfig).
+type Relay struct {
+	BatchSize     int           `env:"RELAY_BATCH_SIZE" default:"100" yaml:"batch_size"`
+	PollInterval  time.Duration `env:"RELAY_POLL_INTERVAL" default:"1s" yaml:"poll_interval"`
+	Listen        bool          `env:"RELAY_LISTEN" default:"true" yaml:"listen"` // LISTEN/NOTIFY wake-ups
+	MaxAttempts   int           `env:"RELAY_MAX_ATTEMPTS" default:"20" yaml:"max_attempts"`
+	Lease         time.Duration `env:"RELAY_LEASE" default:"1m" yaml:"lease"` // must exceed the producer timeout
+	RetryBackoff  time.Duration `env:"RELAY_RETRY_BACKOFF" default:"1s" yaml:"retry_backoff"`
+	MaxBackoff    time.Duration `env:"RELAY_MAX_BACKOFF" default:"5m" yaml:"max_backoff"`
+	Retention     time.Duration `env:"RELAY_RETENTION" default:"168h" yaml:"retention"`
+	PruneInterval time.Duration `env:"RELAY_PRUNE_INTERVAL" default:"1h" yaml:"prune_interval"`
+}
+
+// Load reads the outbox relay configuration and validates it.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Base: config.Base{ServiceName: "outbox-service", HTTPPort: "3002"},
+		// Source database; its owner (auth) creates the outbox table
+		DB: database.Config{DBName: "bitka_auth"},
+	}
+	if err := config.Load(cfg, config.WithPrefix("OUTBOX")); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}